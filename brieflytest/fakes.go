@@ -0,0 +1,85 @@
+package brieflytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// SummarizeCall records one request made to a FakeSummarizer
+type SummarizeCall struct {
+	Content      string
+	CustomPrompt string
+	ContentType  models.ContentType
+}
+
+// FakeSummarizer returns canned summaries without calling an LLM. By default
+// the summary describes its input; set Respond to control it, or Err to make
+// every call fail.
+type FakeSummarizer struct {
+	Respond func(content, customPrompt string, contentType models.ContentType) (string, error)
+	Err     error
+
+	mu    sync.Mutex
+	calls []SummarizeCall
+}
+
+func (f *FakeSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, SummarizeCall{Content: content, CustomPrompt: customPrompt, ContentType: contentType})
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+	if f.Respond != nil {
+		return f.Respond(content, customPrompt, contentType)
+	}
+	return fmt.Sprintf("Summary of %d characters of %s content.", len(content), contentType), nil
+}
+
+// Calls returns the requests made so far
+func (f *FakeSummarizer) Calls() []SummarizeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SummarizeCall(nil), f.calls...)
+}
+
+// FakeTranscriber stands in for the video and podcast pipeline (yt-dlp and
+// Whisper), returning the transcript registered for each URL. As a
+// processor Transcriber it returns Default for any audio file.
+type FakeTranscriber struct {
+	Transcripts map[string]string
+	Default     string
+}
+
+func (f *FakeTranscriber) Extract(ctx context.Context, rawURL string) (string, error) {
+	transcript, ok := f.Transcripts[rawURL]
+	if !ok {
+		return "", fmt.Errorf("no transcript for %s", rawURL)
+	}
+	return transcript, nil
+}
+
+// Transcribe implements the processor's Transcriber, ignoring the audio
+func (f *FakeTranscriber) Transcribe(ctx context.Context, audioPath, lang string) (string, error) {
+	if f.Default == "" {
+		return "", fmt.Errorf("no default transcript")
+	}
+	return f.Default, nil
+}
+
+// FakeFetcher serves web articles and PDF text without network access
+type FakeFetcher struct {
+	Pages map[string]string
+}
+
+func (f *FakeFetcher) Extract(ctx context.Context, rawURL string) (string, error) {
+	page, ok := f.Pages[rawURL]
+	if !ok {
+		return "", fmt.Errorf("no page for %s", rawURL)
+	}
+	return page, nil
+}
@@ -0,0 +1,157 @@
+// Package brieflytest runs the Briefly pipeline deterministically, with fake
+// providers and an in-memory queue instead of network calls and external
+// programs.
+package brieflytest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/processor"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// maxSteps bounds Drain in case a job never leaves the queue
+const maxSteps = 1000
+
+// Harness wires a processor to fakes. Jobs are only processed when Drain is
+// called, on the calling goroutine.
+type Harness struct {
+	Config      *config.Config
+	Queue       *queue.Queue
+	Processor   *processor.Processor
+	History     *history.Store
+	Summarizer  *FakeSummarizer
+	Transcriber *FakeTranscriber
+	Fetcher     *FakeFetcher
+}
+
+// New creates a harness writing to temporary directories owned by t
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		WatchDir:     filepath.Join(dir, "inbox"),
+		OutputDir:    filepath.Join(dir, "output"),
+		TempDir:      filepath.Join(dir, "tmp"),
+		LLMProvider:  "fake",
+		LLMModel:     "fake",
+		Workers:      1,
+		Transcriber:  "local",
+		SummaryDepth: "auto",
+		MaxRetries:   3,
+		// Drain skips backoffs, whatever their length
+		RetryBackoffSeconds:    5,
+		RetryMaxBackoffSeconds: 300,
+	}
+	for _, d := range []string{cfg.WatchDir, cfg.OutputDir, cfg.TempDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", d, err)
+		}
+	}
+
+	h := &Harness{
+		Config:      cfg,
+		Queue:       queue.NewMemory(),
+		Summarizer:  &FakeSummarizer{},
+		Transcriber: &FakeTranscriber{Transcripts: make(map[string]string)},
+		Fetcher:     &FakeFetcher{Pages: make(map[string]string)},
+	}
+	h.Processor = processor.New(cfg, h.Queue, h.Summarizer, nil)
+	// Completed jobs leave the queue, so their outputs are found in history
+	h.History = history.New(filepath.Join(cfg.OutputDir, ".history.jsonl"))
+	h.Processor.SetHistory(h.History)
+	h.Processor.SetExtractor(models.ContentTypeYouTube, h.Transcriber)
+	h.Processor.SetExtractor(models.ContentTypePodcast, h.Transcriber)
+	h.Processor.SetExtractor(models.ContentTypeText, h.Fetcher)
+	h.Processor.SetExtractor(models.ContentTypePDF, h.Fetcher)
+	return h
+}
+
+// Submit queues a job for rawURL
+func (h *Harness) Submit(t testing.TB, rawURL string) *models.Job {
+	t.Helper()
+	return h.enqueue(t, models.NewJob("", rawURL, ""))
+}
+
+// SubmitText queues a job summarizing text directly
+func (h *Harness) SubmitText(t testing.TB, text string) *models.Job {
+	t.Helper()
+	return h.enqueue(t, models.NewTextJob("", text, ""))
+}
+
+func (h *Harness) enqueue(t testing.TB, job *models.Job) *models.Job {
+	t.Helper()
+	if err := h.Queue.Enqueue(job); err != nil {
+		t.Fatalf("failed to enqueue job: %v", err)
+	}
+	return job
+}
+
+// Drain processes jobs until none is pending, retries included: jobs
+// waiting for a retry or rate limit backoff are made due right away
+func (h *Harness) Drain(t testing.TB) {
+	t.Helper()
+	for i := 0; ; i++ {
+		h.skipBackoff()
+		if !h.Processor.ProcessNext() {
+			return
+		}
+		if i == maxSteps {
+			t.Fatalf("queue not drained after %d jobs", maxSteps)
+		}
+	}
+}
+
+// skipBackoff clears the due time of pending jobs
+func (h *Harness) skipBackoff() {
+	for _, job := range h.Queue.List() {
+		if job.Status == models.JobStatusPending && !job.NotBefore.IsZero() {
+			job.NotBefore = time.Time{}
+			h.Queue.Update(&job)
+		}
+	}
+}
+
+// Output returns the summary file written for job, failing t when there is
+// none. The file is looked up by job ID, so names given during processing,
+// such as generated titles, are found.
+func (h *Harness) Output(t testing.TB, job *models.Job) string {
+	t.Helper()
+	entries, err := h.History.Query(history.Filter{Status: models.JobStatusCompleted})
+	if err != nil {
+		t.Fatalf("failed to read job history: %v", err)
+	}
+	path := ""
+	for _, e := range entries {
+		if e.ID == job.ID {
+			path = e.OutputPath
+		}
+	}
+	if path == "" {
+		t.Fatalf("no summary for job %s: the job did not complete", job.ID)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("no summary for job %s: %v", job.ID, err)
+	}
+	return string(data)
+}
+
+// Failed returns the jobs left failed in the queue or moved to the
+// dead-letter list
+func (h *Harness) Failed() []models.Job {
+	failed := h.Queue.DeadLetters()
+	for _, job := range h.Queue.List() {
+		if job.Status == models.JobStatusFailed {
+			failed = append(failed, job)
+		}
+	}
+	return failed
+}
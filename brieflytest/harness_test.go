@@ -0,0 +1,47 @@
+package brieflytest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+func TestDrainWritesOutput(t *testing.T) {
+	h := New(t)
+	h.Fetcher.Pages["https://example.com/post"] = "article text"
+
+	job := h.Submit(t, "https://example.com/post")
+	h.Drain(t)
+
+	if got := h.Output(t, job); !strings.Contains(got, "Summary of 12 characters") {
+		t.Fatalf("unexpected summary:\n%s", got)
+	}
+	if failed := h.Failed(); len(failed) != 0 {
+		t.Fatalf("unexpected failed jobs: %v", failed)
+	}
+}
+
+func TestDrainRetriesFailedJobs(t *testing.T) {
+	h := New(t)
+	h.Fetcher.Pages["https://example.com/post"] = "article text"
+	attempts := 0
+	h.Summarizer.Respond = func(content, customPrompt string, contentType models.ContentType) (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", errors.New("connection reset")
+		}
+		return "Summary after a retry.", nil
+	}
+
+	job := h.Submit(t, "https://example.com/post")
+	h.Drain(t)
+
+	if got := h.Output(t, job); !strings.Contains(got, "Summary after a retry.") {
+		t.Fatalf("unexpected summary:\n%s", got)
+	}
+	if attempts != 2 {
+		t.Fatalf("got %d summarization attempts, want 2", attempts)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/processor"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+type benchmarkResult struct {
+	provider string
+	model    string
+	latency  time.Duration
+	input    int64
+	output   int64
+	cost     float64
+	summary  string
+	err      error
+}
+
+func runBenchmark(args []string) error {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	models := fs.String("models", "", "comma-separated provider:model pairs (default: configured provider and model)")
+	promptFile := fs.String("prompt", "", "file containing a custom prompt")
+	output := fs.String("output", "", "benchmark document path (default: output directory)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: briefly " + commands["benchmark"].usage)
+	}
+	url := positional[0]
+
+	cfg := config.Load()
+
+	var prompt string
+	if *promptFile != "" {
+		if prompt, err = readPromptFile(*promptFile); err != nil {
+			return err
+		}
+	}
+
+	targets := *models
+	if targets == "" {
+		targets = cfg.LLMProvider + ":" + cfg.LLMModel
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+	defer cancel()
+
+	proc := processor.New(cfg, nil, nil, nil)
+
+	slog.Info("Extracting content", "url", url)
+	contentType, content, err := proc.ExtractContent(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	var results []benchmarkResult
+	for _, target := range strings.Split(targets, ",") {
+		provider, model, _ := strings.Cut(strings.TrimSpace(target), ":")
+		if model == "" {
+			model = config.DefaultModel(provider)
+		}
+		result := benchmarkResult{provider: provider, model: model}
+
+		sum, err := newSummarizer(cfg, nil, provider, model)
+		if err != nil {
+			result.err = err
+			results = append(results, result)
+			continue
+		}
+
+		slog.Info("Summarizing", "provider", provider, "model", model)
+		usage := &summarizer.Usage{}
+		start := time.Now()
+		result.summary, result.err = sum.Summarize(summarizer.WithUsage(ctx, usage), content, prompt, contentType)
+		result.latency = time.Since(start)
+		result.input, result.output = usage.Totals()
+		result.cost = summarizer.EstimateCost(model, result.input, result.output)
+		results = append(results, result)
+	}
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(cfg.OutputDir, fmt.Sprintf("benchmark-%s.md", time.Now().Format("20060102-150405")))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Model benchmark\n\n**URL:** %s\n**Type:** %s\n**Content length:** %d chars\n**Generated:** %s\n\n",
+		url, contentType, len(content), time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "| Provider | Model | Latency | Input tokens | Output tokens | Est. cost (USD) | Status |\n")
+	fmt.Fprintf(&b, "|---|---|---|---|---|---|---|\n")
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = "error"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %.4f | %s |\n",
+			r.provider, r.model, r.latency.Round(time.Millisecond), r.input, r.output, r.cost, status)
+	}
+	for _, r := range results {
+		fmt.Fprintf(&b, "\n---\n\n## %s/%s\n\n", r.provider, r.model)
+		if r.err != nil {
+			fmt.Fprintf(&b, "**Error:** %v\n", r.err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n", r.summary)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write benchmark: %w", err)
+	}
+
+	fmt.Println(path)
+	return nil
+}
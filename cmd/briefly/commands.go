@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"benchmark": {
+			usage: "benchmark <url> [--models provider:model,...] [--prompt FILE] [--output FILE]",
+			run:   runBenchmark,
+		},
+		"config": {
+			usage: "config validate",
+			run:   runConfig,
+		},
+		"compare-prompts": {
+			usage: "compare-prompts <url> --prompt-a FILE --prompt-b FILE [--output FILE]",
+			run:   runComparePrompts,
+		},
+		"costs": {
+			usage: "costs [--since DURATION|DATE]",
+			run:   runCosts,
+		},
+		"dead-letter": {
+			usage: "dead-letter [--purge]",
+			run:   runDeadLetter,
+		},
+		"digest": {
+			usage: "digest <dir> [--recursive] [--prompt FILE] [--output FILE]",
+			run:   runDigest,
+		},
+		"doctor": {
+			usage: "doctor",
+			run:   runDoctor,
+		},
+		"history": {
+			usage: "history [--status STATUS] [--error-code CODE] [--since DURATION|DATE] [--search TEXT] [--limit N] [--json]",
+			run:   runHistory,
+		},
+		"list": {
+			usage: "list [--status STATUS] [--error-code CODE]",
+			run:   runList,
+		},
+		"notify-test": {
+			usage: "notify-test",
+			run:   runNotifyTest,
+		},
+		"purge": {
+			usage: "purge",
+			run:   runPurge,
+		},
+		"ratings": {
+			usage: "ratings",
+			run:   runRatings,
+		},
+		"reprocess": {
+			usage: "reprocess [--from provider/model] [--to provider:model] [--limit N] [--dry-run]",
+			run:   runReprocess,
+		},
+		"retry": {
+			usage: "retry <id>...",
+			run:   runRetry,
+		},
+		"search": {
+			usage: "search <query> [--limit N] [--reindex] [--json]",
+			run:   runSearch,
+		},
+		"submit": {
+			usage: "submit <url> [--prompt TEXT|@FILE] [--name NAME] [--priority low|normal|high] [--after TIME]",
+			run:   runSubmit,
+		},
+		"grep": {
+			usage: "grep <words> [--type TYPE] [--since DURATION|DATE] [--tag TAG] [--limit N] [--json]",
+			run:   runGrep,
+		},
+		"find": {
+			usage: "find <words> (same as grep)",
+			run:   runGrep,
+		},
+		"help": {
+			usage: "help",
+			run:   runHelp,
+		},
+	}
+}
+
+func runHelp(args []string) error {
+	printUsage(os.Stdout)
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: briefly [--config FILE] [command] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, Briefly runs the watcher daemon.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  briefly %s\n", commands[name].usage)
+	}
+}
+
+// parseArgs parses flags that may be interleaved with positional arguments
+// and returns the positional arguments in order.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func readPromptFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
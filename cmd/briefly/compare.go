@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/processor"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+func runComparePrompts(args []string) error {
+	fs := flag.NewFlagSet("compare-prompts", flag.ExitOnError)
+	promptA := fs.String("prompt-a", "", "file containing the first prompt")
+	promptB := fs.String("prompt-b", "", "file containing the second prompt")
+	output := fs.String("output", "", "comparison document path (default: output directory)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || *promptA == "" || *promptB == "" {
+		return errors.New("usage: briefly " + commands["compare-prompts"].usage)
+	}
+	url := positional[0]
+
+	textA, err := readPromptFile(*promptA)
+	if err != nil {
+		return err
+	}
+	textB, err := readPromptFile(*promptB)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	sum, err := initSummarizer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize summarizer: %w", err)
+	}
+	sum = summarizer.NewValidatingSummarizer(sum)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	proc := processor.New(cfg, nil, sum, nil)
+
+	slog.Info("Extracting content", "url", url)
+	contentType, content, err := proc.ExtractContent(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Summarizing with prompt A", "prompt", *promptA)
+	summaryA, err := sum.Summarize(ctx, content, textA, contentType)
+	if err != nil {
+		return fmt.Errorf("prompt A: %w", err)
+	}
+
+	slog.Info("Summarizing with prompt B", "prompt", *promptB)
+	summaryB, err := sum.Summarize(ctx, content, textB, contentType)
+	if err != nil {
+		return fmt.Errorf("prompt B: %w", err)
+	}
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(cfg.OutputDir, fmt.Sprintf("compare-%s.md", time.Now().Format("20060102-150405")))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Prompt comparison\n\n**URL:** %s\n**Type:** %s\n**Model:** %s/%s\n**Generated:** %s\n\n",
+		url, contentType, cfg.LLMProvider, cfg.LLMModel, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "| | A | B |\n|---|---|---|\n")
+	fmt.Fprintf(&b, "| Prompt file | `%s` | `%s` |\n", *promptA, *promptB)
+	fmt.Fprintf(&b, "| Summary length | %d chars | %d chars |\n\n", len(summaryA), len(summaryB))
+	fmt.Fprintf(&b, "---\n\n## A: %s\n\n### Prompt\n\n```\n%s\n```\n\n### Summary\n\n%s\n\n", filepath.Base(*promptA), textA, summaryA)
+	fmt.Fprintf(&b, "---\n\n## B: %s\n\n### Prompt\n\n```\n%s\n```\n\n### Summary\n\n%s\n", filepath.Base(*promptB), textB, summaryB)
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write comparison: %w", err)
+	}
+
+	fmt.Println(path)
+	return nil
+}
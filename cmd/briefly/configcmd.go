@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/clobrano/briefly/internal/config"
+)
+
+// runConfig checks the configuration, from the config file and the
+// environment, the way the daemon does on startup, without starting it.
+// Unknown keys in the config file are rejected when it is loaded.
+func runConfig(args []string) error {
+	if len(args) != 1 || args[0] != "validate" {
+		return fmt.Errorf("usage: briefly %s", commands["config"].usage)
+	}
+	if err := validateConfig(config.Load()); err != nil {
+		return err
+	}
+	fmt.Println("Configuration is valid")
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/history"
+)
+
+// costRow aggregates the LLM usage of the jobs of one day and provider
+type costRow struct {
+	day          string
+	provider     string
+	jobs         int
+	inputTokens  int64
+	outputTokens int64
+	usd          float64
+}
+
+func runCosts(args []string) error {
+	fs := flag.NewFlagSet("costs", flag.ExitOnError)
+	since := fs.String("since", "30d", "only count jobs finished within a duration (720h, 30d) or since a date (2006-01-02)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var filter history.Filter
+	if *since != "" {
+		t, err := parseSince(*since)
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+
+	cfg := config.Load()
+	entries, err := history.New(filepath.Join(cfg.OutputDir, ".history.jsonl")).Query(filter)
+	if err != nil {
+		return err
+	}
+
+	rows := make(map[[2]string]*costRow)
+	totals := make(map[string]*costRow)
+	for _, e := range entries {
+		if e.InputTokens == 0 && e.OutputTokens == 0 {
+			continue
+		}
+		day := e.FinishedAt.Local().Format("2006-01-02")
+		for _, row := range []*costRow{
+			lookupRow(rows, [2]string{day, e.Provider}),
+			lookupRow(totals, e.Provider),
+		} {
+			row.jobs++
+			row.inputTokens += e.InputTokens
+			row.outputTokens += e.OutputTokens
+			row.usd += e.CostUSD
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Println("No LLM usage in history")
+		return nil
+	}
+
+	byDay := make([]*costRow, 0, len(rows))
+	for key, row := range rows {
+		row.day, row.provider = key[0], key[1]
+		byDay = append(byDay, row)
+	}
+	sort.Slice(byDay, func(i, j int) bool {
+		if byDay[i].day != byDay[j].day {
+			return byDay[i].day < byDay[j].day
+		}
+		return byDay[i].provider < byDay[j].provider
+	})
+	if err := printCosts("DAY", byDay); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	byProvider := make([]*costRow, 0, len(totals))
+	for provider, row := range totals {
+		row.day, row.provider = "total", provider
+		byProvider = append(byProvider, row)
+	}
+	sort.Slice(byProvider, func(i, j int) bool { return byProvider[i].usd > byProvider[j].usd })
+	return printCosts("PERIOD", byProvider)
+}
+
+func lookupRow[K comparable](rows map[K]*costRow, key K) *costRow {
+	row, ok := rows[key]
+	if !ok {
+		row = &costRow{}
+		rows[key] = row
+	}
+	return row
+}
+
+func printCosts(first string, rows []*costRow) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tPROVIDER\tJOBS\tINPUT TOKENS\tOUTPUT TOKENS\tCOST (USD)\n", first)
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%.4f\n", row.day, row.provider, row.jobs, row.inputTokens, row.outputTokens, row.usd)
+	}
+	return tw.Flush()
+}
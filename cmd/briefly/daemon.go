@@ -0,0 +1,98 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+)
+
+// pidPath is where the running daemon records its process ID, next to the
+// queue file it owns
+func pidPath(cfg *config.Config) string {
+	return filepath.Join(cfg.OutputDir, ".briefly.pid")
+}
+
+// writePIDFile records the process ID of the daemon, so commands editing the
+// queue file can tell it is running
+func writePIDFile(cfg *config.Config) error {
+	return os.WriteFile(pidPath(cfg), []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// daemonRunning reports whether a daemon is running on the output directory
+// of cfg. A PID file left by a crash names a process that is gone, unless
+// its ID was reused since.
+func daemonRunning(cfg *config.Config) bool {
+	data, err := os.ReadFile(pidPath(cfg))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 || pid == os.Getpid() {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// Finding a process on Windows opens it, so it exists
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
+
+// errDaemonRunning explains why a command editing the queue file refuses to
+// run next to the daemon, which would overwrite the changes
+func errDaemonRunning(cfg *config.Config, command string) error {
+	return fmt.Errorf("briefly %s edits %s, which the running daemon owns: stop the daemon first (or remove %s if it is not running)",
+		command, filepath.Join(cfg.OutputDir, ".queue.json"), pidPath(cfg))
+}
+
+// apiRetry resets a job to pending through the HTTP API of the running daemon
+func apiRetry(cfg *config.Config, id string) error {
+	host, port, err := net.SplitHostPort(cfg.HTTPAddr)
+	if err != nil {
+		return fmt.Errorf("invalid BRIEFLY_HTTP_ADDR %q: %w", cfg.HTTPAddr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	endpoint := "http://" + net.JoinHostPort(host, port) + "/jobs/" + url.PathEscape(id) + "/retry"
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if cfg.HTTPToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.HTTPToken)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the running daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("failed to retry job %s: %s", id, cmp.Or(body.Error, resp.Status))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/processor"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	recursive := fs.Bool("recursive", false, "include files in subdirectories")
+	promptFile := fs.String("prompt", "", "file containing a custom prompt")
+	output := fs.String("output", "", "digest document path (default: output directory)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: briefly " + commands["digest"].usage)
+	}
+	dir := filepath.Clean(positional[0])
+
+	prompt := summarizer.DigestPrompt
+	if *promptFile != "" {
+		if prompt, err = readPromptFile(*promptFile); err != nil {
+			return err
+		}
+	}
+
+	content, files, err := processor.ReadFolder(dir, *recursive)
+	if err != nil {
+		return err
+	}
+	slog.Info("Digesting folder", "dir", dir, "files", len(files), "chars", len(content))
+
+	cfg := config.Load()
+	sum, err := initSummarizer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize summarizer: %w", err)
+	}
+	// Each file is a section, so long folders are summarized file by file
+	// before the synthesis
+	sum = summarizer.NewValidatingSummarizer(sum)
+	sum = summarizer.NewChunkingSummarizer(sum, cfg.ChunkSize, cfg.ChunkOverlap)
+	sum = summarizer.NewSectionSummarizer(sum, cfg.SectionMinLength)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+	defer cancel()
+
+	digest, err := sum.Summarize(ctx, content, prompt, models.ContentTypeDirectText)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	path := *output
+	if path == "" {
+		path = filepath.Join(cfg.OutputDir, fmt.Sprintf("digest-%s-%s.md", name, time.Now().Format("20060102-150405")))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Digest: %s\n\n**Folder:** %s\n**Files:** %d\n**Model:** %s/%s\n**Generated:** %s\n\n---\n\n%s\n",
+		name, dir, len(files), cfg.LLMProvider, cfg.LLMModel, time.Now().Format(time.RFC3339), strings.TrimSpace(digest))
+	b.WriteString("\n## Sources\n\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write digest: %w", err)
+	}
+
+	fmt.Println(path)
+	return nil
+}
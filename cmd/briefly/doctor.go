@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/processor"
+)
+
+// runDoctor reports the external tools Briefly depends on and their versions
+func runDoctor(args []string) error {
+	cfg := config.Load()
+	tools := processor.DetectTools(context.Background())
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tVERSION\tPATH")
+	for _, t := range tools {
+		version := t.Version
+		if version == "" {
+			version = "-"
+		}
+		path := t.Path
+		if path == "" {
+			path = "not found"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, version, path)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	warnings := toolWarnings(cfg, tools)
+	if len(warnings) == 0 {
+		return nil
+	}
+	fmt.Println()
+	for _, w := range warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	return fmt.Errorf("%d problem(s) found", len(warnings))
+}
+
+// reportTools logs the versions of the external tools at startup
+func reportTools(cfg *config.Config) {
+	tools := processor.DetectTools(context.Background())
+	for _, t := range tools {
+		if t.Found() && t.Version != "" {
+			slog.Info("Found tool", "tool", t.Name, "version", t.Version, "path", t.Path)
+		}
+	}
+	for _, w := range toolWarnings(cfg, tools) {
+		slog.Warn(w)
+	}
+}
+
+// toolWarnings lists missing or outdated tools needed by the configuration
+func toolWarnings(cfg *config.Config, tools []processor.ToolInfo) []string {
+	if cfg.ArticleOnly {
+		return nil
+	}
+	var warnings []string
+	for _, t := range tools {
+		if t.Name == "whisper" && cfg.Transcriber != "local" {
+			continue
+		}
+		if t.Err != nil {
+			warnings = append(warnings, t.Err.Error())
+			continue
+		}
+		if t.Name != "yt-dlp" {
+			continue
+		}
+		maxAge := time.Duration(cfg.YtDlpMaxAgeDays) * 24 * time.Hour
+		if outdated, age := processor.YtDlpOutdated(t.Version, maxAge); outdated {
+			warnings = append(warnings, fmt.Sprintf(
+				"yt-dlp %s is %d days old, outdated yt-dlp is the most common cause of download failures (pip install -U yt-dlp)",
+				t.Version, int(age.Hours()/24)))
+		}
+	}
+	if cfg.YtDlpCookies != "" {
+		if _, err := os.Stat(cfg.YtDlpCookies); err != nil {
+			warnings = append(warnings, fmt.Sprintf("yt-dlp cookies file is not readable: %v", err))
+		}
+	}
+	return warnings
+}
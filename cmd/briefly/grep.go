@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/search"
+)
+
+// runGrep finds summaries containing words, using a full-text index of the
+// output directory refreshed from the files on each run
+func runGrep(args []string) error {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	contentType := fs.String("type", "", "only summaries of this content type, e.g. youtube or text")
+	since := fs.String("since", "", "only summaries created within a duration (30d) or since a date (2006-01-02)")
+	tag := fs.String("tag", "", "only summaries with this front matter tag")
+	limit := fs.Int("limit", 20, "show at most this many summaries (0 for all)")
+	asJSON := fs.Bool("json", false, "print the results as JSON lines")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" {
+		return errors.New("usage: briefly " + commands["grep"].usage)
+	}
+
+	filter := search.TextFilter{Type: models.ContentType(*contentType), Tag: *tag}
+	if *since != "" {
+		if filter.Since, err = parseSince(*since); err != nil {
+			return err
+		}
+	}
+
+	cfg := config.Load()
+	index, err := search.OpenTextIndex(filepath.Join(cfg.OutputDir, ".fulltext.json"))
+	if err != nil {
+		return err
+	}
+	paths, err := findSummaries(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	// The summary index lists every summary, so it would match every query
+	catalog := filepath.Join(cfg.OutputDir, "index.md")
+	paths = slices.DeleteFunc(paths, func(path string) bool { return path == catalog })
+
+	changed, err := index.Refresh(paths)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := index.Save(); err != nil {
+			return err
+		}
+	}
+
+	results := index.Find(query, filter, *limit)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(results) == 0 {
+		fmt.Println("No summaries found")
+		return nil
+	}
+
+	for i, r := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		title := r.Title
+		if title == "" {
+			title = r.URL
+		}
+		fmt.Printf("%s  %s  %s\n  %s\n", r.Created.Format("2006-01-02"), r.Type, title, r.Path)
+		if r.Snippet != "" {
+			fmt.Printf("  %s\n", r.Snippet)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	status := fs.String("status", "", "only show jobs that ended with this status (completed or failed)")
+	code := fs.String("error-code", "", "only show jobs that failed with this error code")
+	since := fs.String("since", "", "only show jobs finished within a duration (24h) or since a date (2006-01-02)")
+	search := fs.String("search", "", "only show jobs whose URL, title, or file name contains this text")
+	limit := fs.Int("limit", 50, "show at most this many of the most recent jobs (0 for all)")
+	asJSON := fs.Bool("json", false, "print the entries as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := history.Filter{
+		Status:    models.JobStatus(*status),
+		ErrorCode: models.ErrorCode(*code),
+		Search:    *search,
+	}
+	if *since != "" {
+		t, err := parseSince(*since)
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+
+	cfg := config.Load()
+	entries, err := history.New(filepath.Join(cfg.OutputDir, ".history.jsonl")).Query(filter)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("No jobs in history")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FINISHED\tID\tSTATUS\tTYPE\tDURATION\tMODEL\tSOURCE\tOUTPUT")
+	for _, e := range entries {
+		result := e.OutputPath
+		if e.Status == models.JobStatusFailed {
+			result = fmt.Sprintf("%s: %s", e.ErrorCode, e.Error)
+		}
+		model := ""
+		if e.Model != "" {
+			model = e.Provider + "/" + e.Model
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.FinishedAt.Local().Format("2006-01-02 15:04"), e.ID, e.Status, e.ContentType,
+			e.Duration().Round(time.Second), model, e.Source(), result)
+	}
+	return tw.Flush()
+}
+
+// parseSince accepts either a duration before now, in days ("7d") or as a
+// Go duration, or a date
+func parseSince(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration such as 24h or 7d, or a date such as 2006-01-02", value)
+}
@@ -2,120 +2,615 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/clobrano/briefly/internal/api"
+	"github.com/clobrano/briefly/internal/budget"
+	"github.com/clobrano/briefly/internal/channels"
 	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/events"
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/logging"
+	"github.com/clobrano/briefly/internal/mailbox"
+	"github.com/clobrano/briefly/internal/models"
 	"github.com/clobrano/briefly/internal/notifier"
+	"github.com/clobrano/briefly/internal/pocket"
 	"github.com/clobrano/briefly/internal/processor"
 	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/rating"
+	"github.com/clobrano/briefly/internal/readwise"
+	"github.com/clobrano/briefly/internal/retention"
 	"github.com/clobrano/briefly/internal/summarizer"
+	"github.com/clobrano/briefly/internal/systemd"
+	"github.com/clobrano/briefly/internal/wallabag"
 	"github.com/clobrano/briefly/internal/watcher"
 )
 
 func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting Briefly...")
+	// Settings may come from a config file, given with --config or
+	// BRIEFLY_CONFIG, before the command
+	configPath := os.Getenv("BRIEFLY_CONFIG")
+	if len(os.Args) > 2 && os.Args[1] == "--config" {
+		configPath = os.Args[2]
+		os.Args = append(os.Args[:1], os.Args[3:]...)
+	} else if len(os.Args) > 1 && strings.HasPrefix(os.Args[1], "--config=") {
+		configPath = strings.TrimPrefix(os.Args[1], "--config=")
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	if configPath != "" {
+		if err := config.LoadFile(configPath); err != nil {
+			logging.Fatal("Configuration error", "error", err)
+		}
+	}
+
+	if err := logging.Setup(config.Logging()); err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	if err := setupProxy(config.Proxy()); err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+
+	// Dispatch subcommands, running the daemon without one. A mistyped
+	// command must not start the daemon.
+	if len(os.Args) > 1 {
+		cmd, ok := commands[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+			printUsage(os.Stderr)
+			os.Exit(2)
+		}
+		if err := cmd.run(os.Args[2:]); err != nil {
+			logging.Fatal("Command failed", "command", os.Args[1], "error", err)
+		}
+		return
+	}
+
+	if runAsService() {
+		return
+	}
+	runDaemon(shutdownSignals())
+}
+
+// shutdownSignals returns the reason to stop given by the first signal
+// asking the daemon to. On Windows, Ctrl+C and Ctrl+Break are interrupts,
+// and closing the console, logging off, or shutting down arrives as SIGTERM.
+func shutdownSignals() <-chan string {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan string, 1)
+	go func() {
+		stop <- "received " + (<-sigChan).String()
+	}()
+	return stop
+}
+
+// runDaemon runs Briefly until a reason to stop arrives on stop
+func runDaemon(stop <-chan string) {
+	slog.Info("Starting Briefly")
 
 	// Load configuration
 	cfg := config.Load()
 
 	// Validate configuration
 	if err := validateConfig(cfg); err != nil {
-		log.Fatalf("Configuration error: %v", err)
+		logging.Fatal("Configuration error", "error", err)
 	}
 
 	// Ensure directories exist and are writable
 	if err := os.MkdirAll(cfg.WatchDir, 0755); err != nil {
-		log.Fatalf("Failed to create watch directory: %v", err)
+		logging.Fatal("Failed to create watch directory", "error", err)
 	}
 	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+		logging.Fatal("Failed to create output directory", "error", err)
+	}
+
+	if err := os.MkdirAll(cfg.TempDir, 0755); err != nil {
+		logging.Fatal("Failed to create temp directory", "error", err)
 	}
 
 	// Verify write permissions
 	if err := checkWritePermission(cfg.WatchDir); err != nil {
-		log.Fatalf("Watch directory not readable: %v", err)
+		logging.Fatal("Watch directory not readable", "error", err)
 	}
 	if err := checkWritePermission(cfg.OutputDir); err != nil {
-		log.Fatalf("Output directory not writable: %v", err)
+		logging.Fatal("Output directory not writable", "error", err)
+	}
+
+	if err := checkWritePermission(cfg.TempDir); err != nil {
+		logging.Fatal("Temp directory not writable", "error", err)
+	}
+
+	// Remove work directories orphaned by a previous crash
+	if removed, err := processor.SweepTempDir(cfg.TempDir, processor.WorkDirMaxAge(cfg)); err != nil {
+		slog.Warn("Failed to sweep temp directory", "error", err)
+	} else if removed > 0 {
+		slog.Info("Removed orphaned work directories", "count", removed, "dir", cfg.TempDir)
+	}
+
+	// Report external tool versions, warning about missing or outdated ones
+	if cfg.ArticleOnly {
+		slog.Info("Article-only mode: YouTube and podcast inputs are rejected")
+	} else {
+		reportTools(cfg)
 	}
 
 	// Initialize queue with persistence
 	queuePath := filepath.Join(cfg.OutputDir, ".queue.json")
 	q, err := queue.New(queuePath)
 	if err != nil {
-		log.Fatalf("Failed to initialize queue: %v", err)
+		logging.Fatal("Failed to initialize queue", "error", err)
+	}
+	slog.Info("Queue initialized", "persistence", queuePath)
+	// Commands editing the queue file check it to leave the daemon's queue alone
+	if err := writePIDFile(cfg); err != nil {
+		slog.Warn("Failed to write PID file", "error", err)
+	}
+	if cfg.LeaseSeconds > 0 {
+		q.SetLease(cfg.ReplicaID, time.Duration(cfg.LeaseSeconds)*time.Second)
+		slog.Info("Job leasing enabled", "replica", cfg.ReplicaID, "lease_seconds", cfg.LeaseSeconds)
+	}
+	// Jobs a crash left processing would otherwise never be picked up again
+	if n, err := q.Recover(cfg.ReplicaID); err != nil {
+		logging.Fatal("Failed to recover interrupted jobs", "error", err)
+	} else if n > 0 {
+		slog.Info("Recovered interrupted jobs", "jobs", n)
 	}
-	log.Printf("Queue initialized (persistence: %s)", queuePath)
 
-	// Initialize summarizer
-	sum, err := initSummarizer(cfg)
+	// Initialize summarizer, sharing one LLM concurrency and rate limit
+	// across models
+	limiter := summarizer.NewLimiter(cfg.MaxLLMRequests, cfg.LLMRequestsPerMinute, cfg.LLMTokensPerMinute)
+	sum, err := newSummarizer(cfg, limiter, cfg.LLMProvider, cfg.LLMModel)
 	if err != nil {
-		log.Fatalf("Failed to initialize summarizer: %v", err)
+		logging.Fatal("Failed to initialize summarizer", "error", err)
 	}
-	log.Printf("Summarizer initialized (provider: %s, model: %s)", cfg.LLMProvider, cfg.LLMModel)
+	sum = summarizer.NewValidatingSummarizer(sum)
+	slog.Info("Summarizer initialized", "provider", cfg.LLMProvider, "model", cfg.LLMModel)
+
+	if cfg.LLMFallback != "" {
+		candidates := []summarizer.Candidate{{Provider: cfg.LLMProvider, Model: cfg.LLMModel, Summarizer: sum}}
+		for _, entry := range strings.Split(cfg.LLMFallback, ",") {
+			provider, model, _ := strings.Cut(strings.TrimSpace(entry), ":")
+			if model == "" {
+				model = config.DefaultModel(provider)
+			}
+			fallback, err := newSummarizer(cfg, limiter, provider, model)
+			if err != nil {
+				logging.Fatal("Failed to initialize fallback summarizer", "provider", entry, "error", err)
+			}
+			candidates = append(candidates, summarizer.Candidate{
+				Provider:   provider,
+				Model:      model,
+				Summarizer: summarizer.NewValidatingSummarizer(fallback),
+			})
+		}
+		sum = summarizer.NewFallbackSummarizer(candidates...)
+		slog.Info("Fallback providers configured", "providers", cfg.LLMFallback)
+	}
+	sum = summarizer.NewChunkingSummarizer(sum, cfg.ChunkSize, cfg.ChunkOverlap)
+	sum = summarizer.NewSectionSummarizer(sum, cfg.SectionMinLength)
 
 	// Initialize notifier
-	ntfy := notifier.New(cfg.NtfyTopic)
-	if ntfy != nil {
-		log.Printf("Notifier initialized (topic: %s)", cfg.NtfyTopic)
+	ntfy, err := newNotifier(cfg)
+	if err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	for _, b := range ntfy.Backends() {
+		slog.Info("Notifier initialized", "backend", b.Name())
 	}
 
 	// Initialize processor
+	// Recent job activity, kept for the dashboard and status API
+	eventLog := events.New(200, filepath.Join(cfg.OutputDir, ".events.json"))
+
 	proc := processor.New(cfg, q, sum, ntfy)
+	proc.SetEvents(eventLog)
+	var jobHistory *history.Store
+	if cfg.History {
+		jobHistory = history.New(filepath.Join(cfg.OutputDir, ".history.jsonl"))
+		proc.SetHistory(jobHistory)
+	}
+	personas, err := summarizer.LoadPersonas(cfg.PersonaDir)
+	if err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	if _, err := personas.Instruction(cfg.Persona); cfg.Persona != "" && err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	proc.SetPersonas(personas)
+	searchIndex, err := newSearchIndex(cfg)
+	if err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	if searchIndex != nil {
+		proc.SetIndex(searchIndex)
+		slog.Info("Semantic search enabled", "provider", cfg.EmbeddingsProvider)
+	}
+	outputTemplate, err := processor.LoadOutputTemplate(cfg.OutputTemplate, cfg.OutputTemplateFile)
+	if err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	if outputTemplate != nil {
+		proc.SetOutputTemplate(outputTemplate)
+		slog.Info("Using custom output template")
+	}
+	proc.SetSummarizerFactory(func(provider, model string) (summarizer.Summarizer, error) {
+		s, err := newSummarizer(cfg, limiter, provider, model)
+		if err != nil {
+			return nil, err
+		}
+		s = summarizer.NewValidatingSummarizer(s)
+		s = summarizer.NewChunkingSummarizer(s, cfg.ChunkSize, cfg.ChunkOverlap)
+		return summarizer.NewSectionSummarizer(s, cfg.SectionMinLength), nil
+	})
+
+	// Initialize budget caps
+	limits, err := loadBudgetLimits(cfg)
+	if err != nil {
+		logging.Fatal("Configuration error", "error", err)
+	}
+	if limits.Enabled() {
+		budgetPath := filepath.Join(cfg.OutputDir, ".budget.json")
+		tracker, err := budget.New(limits, budgetPath)
+		if err != nil {
+			logging.Fatal("Failed to initialize budget tracker", "error", err)
+		}
+		proc.SetBudget(tracker)
+		slog.Info("Budget caps enabled", "persistence", budgetPath)
+	}
+
+	// systemd expects keepalives at least every WatchdogSec, so they are sent
+	// twice as often
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		proc.SetWatchdog(interval/2, func() {
+			if err := systemd.Notify(systemd.Watchdog); err != nil {
+				slog.Warn("Failed to send watchdog keepalive", "error", err)
+			}
+		})
+		slog.Info("systemd watchdog enabled", "interval", interval)
+	}
 	proc.Start()
-	log.Println("Processor started")
+	slog.Info("Processor started", "workers", cfg.Workers)
 
 	// Initialize watcher
 	watch, err := watcher.New(cfg.WatchDir, q)
 	if err != nil {
-		log.Fatalf("Failed to initialize watcher: %v", err)
+		logging.Fatal("Failed to initialize watcher", "error", err)
 	}
+	ratings := rating.New(cfg.OutputDir)
+	if cfg.RegenerateBelow > 0 && cfg.RegenerateModel != "" {
+		provider, model, _ := strings.Cut(cfg.RegenerateModel, ":")
+		ratings.SetRegeneration(q, cfg.RegenerateBelow, provider, model)
+		slog.Info("Low-rated summaries are regenerated", "rating_below", cfg.RegenerateBelow, "model", cfg.RegenerateModel)
+	}
+	watch.SetRatings(ratings)
+	watch.SetLimits(cfg.WatchBatchSize, cfg.WatchPendingLimit, ntfy)
+	// Before the watcher and pollers start, so their inputs are batched
+	q.SetTriage(queue.TriageOptions{
+		Threshold: cfg.TriageThreshold,
+		Window:    time.Duration(cfg.TriageWindowSeconds) * time.Second,
+		Confirm:   cfg.TriageConfirm,
+		Estimate:  processor.NewEstimator(cfg.LLMModel, jobHistory).Estimate,
+		Announce: func(batch string, jobs []models.Job, eta time.Duration, cost float64, held bool) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := ntfy.SendTriage(ctx, batch, jobs, eta, cost, held); err != nil {
+				slog.Warn("Failed to send triage notification", "error", err)
+			}
+		},
+	})
 	if err := watch.Start(); err != nil {
-		log.Fatalf("Failed to start watcher: %v", err)
+		logging.Fatal("Failed to start watcher", "error", err)
+	}
+	slog.Info("Watching directory", "dir", cfg.WatchDir)
+	if err := systemd.Notify(systemd.Ready, systemd.Status("Watching "+cfg.WatchDir)); err != nil {
+		slog.Warn("Failed to notify systemd of readiness", "error", err)
 	}
-	log.Printf("Watching directory: %s", cfg.WatchDir)
 
-	log.Println("Briefly is running. Press Ctrl+C to stop.")
+	var outputWatch *watcher.OutputWatcher
+	if cfg.RegenerateOnDelete {
+		outputWatch, err = watcher.NewOutputWatcher(cfg.OutputDir, q)
+		if err != nil {
+			logging.Fatal("Failed to initialize output watcher", "error", err)
+		}
+		if err := outputWatch.Start(); err != nil {
+			logging.Fatal("Failed to start output watcher", "error", err)
+		}
+		slog.Info("Deleted summaries are regenerated", "dir", cfg.OutputDir)
+	}
 
-	// Wait for shutdown signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	// The janitor checks for old summaries hourly
+	const retentionInterval = time.Hour
+	var janitor *retention.Janitor
+	policy := retention.Policy{
+		MaxAge:        time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		Action:        cfg.RetentionAction,
+		HistoryMaxAge: time.Duration(cfg.HistoryRetentionDays) * 24 * time.Hour,
+		MaxSize:       int64(cfg.OutputMaxSizeMB) << 20,
+	}
+	if policy.Enabled() {
+		janitor = retention.New(cfg.OutputDir, policy, jobHistory, retentionInterval)
+		if outputWatch != nil {
+			janitor.OnRemove(outputWatch.Forget)
+		}
+		janitor.Start()
+		slog.Info("Retention enabled", "days", cfg.RetentionDays, "action", cfg.RetentionAction,
+			"history_days", cfg.HistoryRetentionDays, "max_size_mb", cfg.OutputMaxSizeMB)
+	}
+
+	var mail *mailbox.Poller
+	if cfg.IMAPAddr != "" {
+		mail, err = mailbox.New(mailbox.Options{
+			Addr:     cfg.IMAPAddr,
+			TLS:      cfg.IMAPTLS,
+			Username: cfg.IMAPUsername,
+			Password: cfg.IMAPPassword,
+			Mailbox:  cfg.IMAPMailbox,
+			MoveTo:   cfg.IMAPMoveTo,
+			Mode:     cfg.IMAPMode,
+			Interval: time.Duration(cfg.IMAPIntervalSeconds) * time.Second,
+
+			AllowedSenders: cfg.IMAPAllowedSenders,
+		}, q)
+		if err != nil {
+			logging.Fatal("Configuration error", "error", err)
+		}
+		mail.Start()
+		slog.Info("Polling mailbox", "addr", cfg.IMAPAddr, "mailbox", cfg.IMAPMailbox, "mode", cfg.IMAPMode)
+	}
+
+	var pocketPoll *pocket.Poller
+	if cfg.PocketAccessToken != "" {
+		pocketPoll = pocket.NewPoller(
+			pocket.NewClient(cfg.PocketConsumerKey, cfg.PocketAccessToken),
+			q, jobHistory, cfg.PocketTag, cfg.PocketArchive,
+			time.Duration(cfg.PocketIntervalSeconds)*time.Second,
+			filepath.Join(cfg.OutputDir, ".pocket.json"))
+		pocketPoll.Start()
+		slog.Info("Polling Pocket", "tag", cfg.PocketTag, "archive", cfg.PocketArchive)
+	}
+
+	var wallabagPoll *wallabag.Poller
+	if cfg.WallabagURL != "" {
+		wallabagPoll = wallabag.NewPoller(
+			wallabag.NewClient(cfg.WallabagURL, wallabag.Credentials{
+				ClientID:     cfg.WallabagClientID,
+				ClientSecret: cfg.WallabagClientSecret,
+				Username:     cfg.WallabagUsername,
+				Password:     cfg.WallabagPassword,
+			}),
+			q, jobHistory, cfg.WallabagTag, cfg.WallabagAnnotate,
+			time.Duration(cfg.WallabagIntervalSeconds)*time.Second,
+			filepath.Join(cfg.OutputDir, ".wallabag.json"))
+		wallabagPoll.Start()
+		slog.Info("Polling Wallabag", "url", cfg.WallabagURL, "tag", cfg.WallabagTag, "annotate", cfg.WallabagAnnotate)
+	}
+
+	var readwisePoll *readwise.Poller
+	if cfg.ReadwiseToken != "" {
+		readwisePoll = readwise.NewPoller(readwise.NewClient(cfg.ReadwiseToken),
+			q, jobHistory, cfg.ReadwiseTag, cfg.ReadwiseHighlight,
+			time.Duration(cfg.ReadwiseIntervalSeconds)*time.Second,
+			filepath.Join(cfg.OutputDir, ".readwise.json"))
+		readwisePoll.Start()
+		slog.Info("Polling Readwise Reader", "tag", cfg.ReadwiseTag, "highlight", cfg.ReadwiseHighlight)
+	}
+
+	var channelPoll *channels.Poller
+	if cfg.YouTubeChannels != "" {
+		monitored, err := channels.Parse(cfg.YouTubeChannels)
+		if err != nil {
+			logging.Fatal("Configuration error", "error", err)
+		}
+		channelPoll = channels.NewPoller(channels.NewClient(),
+			q, jobHistory, monitored,
+			time.Duration(cfg.YouTubeChannelsIntervalSeconds)*time.Second,
+			filepath.Join(cfg.OutputDir, ".channels.json"))
+		channelPoll.Start()
+		slog.Info("Monitoring YouTube channels", "channels", len(monitored))
+	}
+
+	// Initialize HTTP API
+	var server *api.Server
+	if cfg.HTTPAddr != "" {
+		server = api.New(cfg.HTTPAddr, q, cfg.OutputDir, eventLog)
+		server.SetIndex(searchIndex)
+		server.SetToken(cfg.HTTPToken)
+		if err := server.Start(); err != nil {
+			logging.Fatal("Failed to start HTTP server", "error", err)
+		}
+		slog.Info("HTTP API listening", "addr", cfg.HTTPAddr)
+	}
+
+	slog.Info("Briefly is running. Press Ctrl+C to stop.")
 
-	log.Println("Shutting down...")
+	// Wait for a signal or the service manager
+	reason := <-stop
+
+	slog.Info("Shutting down", "reason", reason)
+	systemd.Notify(systemd.Stopping, systemd.Status("Shutting down: "+reason))
 
 	// Graceful shutdown
+	if server != nil {
+		server.Stop()
+	}
+	if mail != nil {
+		mail.Stop()
+	}
+	if channelPoll != nil {
+		channelPoll.Stop()
+	}
+	if pocketPoll != nil {
+		pocketPoll.Stop()
+	}
+	if wallabagPoll != nil {
+		wallabagPoll.Stop()
+	}
+	if readwisePoll != nil {
+		readwisePoll.Stop()
+	}
+	if janitor != nil {
+		janitor.Stop()
+	}
 	watch.Stop()
+	if outputWatch != nil {
+		outputWatch.Stop()
+	}
 	proc.Stop()
+	os.Remove(pidPath(cfg))
 
-	log.Println("Briefly stopped.")
+	slog.Info("Briefly stopped")
 }
 
 func validateConfig(cfg *config.Config) error {
 	if cfg.LLMProvider == "claude" && cfg.AnthropicKey == "" {
-		log.Println("Warning: ANTHROPIC_API_KEY not set, Claude summarization will fail")
+		slog.Warn("ANTHROPIC_API_KEY not set, Claude summarization will fail")
 	}
 	if cfg.LLMProvider == "gemini" && cfg.GoogleKey == "" {
-		log.Println("Warning: GOOGLE_API_KEY not set, Gemini summarization will fail")
+		slog.Warn("GOOGLE_API_KEY not set, Gemini summarization will fail")
+	}
+	if cfg.LLMProvider == "openai" && cfg.OpenAIKey == "" && cfg.OpenAIURL == "" {
+		slog.Warn("OPENAI_API_KEY not set, OpenAI summarization will fail")
+	}
+	switch summarizer.LanguagePolicy(cfg.LanguagePolicy) {
+	case summarizer.LanguageSource, summarizer.LanguageTranslate, summarizer.LanguageBilingual:
+	default:
+		return fmt.Errorf("unknown language policy %q, expected source, translate, or bilingual", cfg.LanguagePolicy)
+	}
+	if _, err := summarizer.ParseStyle(cfg.SummaryStyle); err != nil {
+		return err
+	}
+	if _, err := processor.ParseOutputFormat(cfg.OutputFormat); err != nil {
+		return err
+	}
+	if err := processor.ValidateOutputLayout(cfg.OutputLayout); err != nil {
+		return err
+	}
+	if err := processor.ValidateCatalog(cfg.SummaryIndex); err != nil {
+		return err
+	}
+	if _, err := processor.ParseRetryClasses(cfg.RetryPolicy); err != nil {
+		return err
+	}
+	switch cfg.RetentionAction {
+	case retention.ActionCompress, retention.ActionDelete:
+	default:
+		return fmt.Errorf("unknown retention action %q, expected compress or delete", cfg.RetentionAction)
+	}
+	if cfg.SummaryIndex != "" && !cfg.History {
+		return errors.New("BRIEFLY_SUMMARY_INDEX is built from the job history, set BRIEFLY_HISTORY=true")
+	}
+	if cfg.PocketArchive && !cfg.History {
+		return errors.New("BRIEFLY_POCKET_ARCHIVE finds completed summaries in the job history, set BRIEFLY_HISTORY=true")
+	}
+	if cfg.WallabagAnnotate && !cfg.History {
+		return errors.New("BRIEFLY_WALLABAG_ANNOTATE finds summaries in the job history, set BRIEFLY_HISTORY=true")
+	}
+	if cfg.ReadwiseHighlight && !cfg.History {
+		return errors.New("BRIEFLY_READWISE_HIGHLIGHT finds summaries in the job history, set BRIEFLY_HISTORY=true")
+	}
+	switch cfg.ContextOverflow {
+	case summarizer.OverflowChunk, summarizer.OverflowTruncate:
+	default:
+		return fmt.Errorf("unknown context overflow %q, expected chunk or truncate", cfg.ContextOverflow)
+	}
+	if cfg.ArticleOnly {
+		return nil
+	}
+	switch cfg.Transcriber {
+	case "local":
+	case "api":
+		if cfg.TranscriberKey == "" {
+			slog.Warn("BRIEFLY_TRANSCRIBER_API_KEY not set, API transcription will fail")
+		}
+	default:
+		return fmt.Errorf("unknown transcriber %q, expected local or api", cfg.Transcriber)
 	}
 	return nil
 }
 
+// newNotifier returns a notifier for every configured backend, or nil
+func newNotifier(cfg *config.Config) (*notifier.Notifier, error) {
+	var backends []notifier.Backend
+	if cfg.NtfyTopic != "" {
+		backends = append(backends, notifier.NewNtfy(cfg.NtfyTopic))
+	}
+	if cfg.TelegramToken != "" && cfg.TelegramChatID != "" {
+		backends = append(backends, notifier.NewTelegram(cfg.TelegramToken, cfg.TelegramChatID))
+	}
+	n := notifier.New(cfg.PublicURL, cfg.OutputDir, backends...)
+	n.SetAPIToken(cfg.HTTPToken)
+	err := n.SetTemplates(notifier.Templates{
+		StartTitle:   cfg.NotifyStartTitle,
+		StartBody:    cfg.NotifyStartBody,
+		SuccessTitle: cfg.NotifySuccessTitle,
+		SuccessBody:  cfg.NotifySuccessBody,
+		FailureTitle: cfg.NotifyFailureTitle,
+		FailureBody:  cfg.NotifyFailureBody,
+		SkippedTitle: cfg.NotifySkippedTitle,
+		SkippedBody:  cfg.NotifySkippedBody,
+	})
+	return n, err
+}
+
 func initSummarizer(cfg *config.Config) (summarizer.Summarizer, error) {
-	switch cfg.LLMProvider {
+	return newSummarizer(cfg, nil, cfg.LLMProvider, cfg.LLMModel)
+}
+
+// newSummarizer returns the summarizer of provider and model, keeping its
+// requests within the model's context window. Content too long for it is
+// summarized in several requests, each going through limiter, when set.
+func newSummarizer(cfg *config.Config, limiter *summarizer.Limiter, provider, model string) (summarizer.Summarizer, error) {
+	s, err := newProviderSummarizer(cfg, provider, model)
+	if err != nil {
+		return nil, err
+	}
+	maxTokens := cfg.MaxInputTokens
+	if maxTokens == 0 {
+		maxTokens = summarizer.ContextWindow(provider, model)
+	}
+	return summarizer.NewContextGuard(limiter.Wrap(s), maxTokens, cfg.ContextOverflow, cfg.ChunkOverlap), nil
+}
+
+func newProviderSummarizer(cfg *config.Config, provider, model string) (summarizer.Summarizer, error) {
+	switch provider {
 	case "claude":
-		return summarizer.NewClaudeSummarizer(cfg.AnthropicKey, cfg.LLMModel)
+		return summarizer.NewClaudeSummarizer(cfg.AnthropicKey, model)
 	case "gemini":
 		ctx := context.Background()
-		return summarizer.NewGeminiSummarizer(ctx, cfg.GoogleKey, cfg.LLMModel)
+		return summarizer.NewGeminiSummarizer(ctx, cfg.GoogleKey, model)
+	case "openai":
+		return summarizer.NewOpenAISummarizer(cfg.OpenAIKey, cfg.OpenAIURL, model)
+	case "ollama":
+		return summarizer.NewOpenAISummarizer("", cfg.OllamaURL, model)
 	default:
-		return summarizer.NewClaudeSummarizer(cfg.AnthropicKey, cfg.LLMModel)
+		return nil, config.CheckProvider(provider)
+	}
+}
+
+func loadBudgetLimits(cfg *config.Config) (budget.Limits, error) {
+	var limits budget.Limits
+	var err error
+	if limits.DailyUSD, err = budget.ParseLimit(cfg.BudgetDailyUSD); err != nil {
+		return limits, err
+	}
+	if limits.MonthlyUSD, err = budget.ParseLimit(cfg.BudgetMonthlyUSD); err != nil {
+		return limits, err
+	}
+	if limits.DailyTokens, err = budget.ParseLimit(cfg.BudgetDailyTokens); err != nil {
+		return limits, err
+	}
+	if limits.MonthlyTokens, err = budget.ParseLimit(cfg.BudgetMonthlyTokens); err != nil {
+		return limits, err
 	}
+	return limits, nil
 }
 
 func checkWritePermission(dir string) error {
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/notifier"
+)
+
+// runNotifyTest sends a test message through every configured notifier
+func runNotifyTest(args []string) error {
+	cfg := config.Load()
+
+	n, err := newNotifier(cfg)
+	if err != nil {
+		return err
+	}
+	backends := n.Backends()
+	if len(backends) == 0 {
+		return errors.New("no notifier configured, set BRIEFLY_NTFY_TOPIC or BRIEFLY_TELEGRAM_TOKEN and BRIEFLY_TELEGRAM_CHAT_ID")
+	}
+
+	msg := notifier.Message{
+		Title:    "Briefly: test notification",
+		Body:     fmt.Sprintf("Notifications are working. Sent at %s.", time.Now().Format("2006-01-02 15:04:05")),
+		Priority: "default",
+		Tags:     "white_check_mark",
+	}
+
+	failed := 0
+	for _, b := range backends {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := b.Send(ctx, msg)
+		cancel()
+
+		if err != nil {
+			fmt.Printf("%-10s FAILED: %v\n", b.Name(), err)
+			failed++
+			continue
+		}
+		fmt.Printf("%-10s ok\n", b.Name())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notifier(s) failed", failed, len(backends))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// setupProxy routes the requests of every HTTP client built on the default
+// transport, which all of Briefly's clients are, through proxy. Localhost,
+// such as a local Ollama, and the noProxy hosts are reached directly.
+func setupProxy(proxy, noProxy string) error {
+	if proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy %q, expected a URL such as http://host:port or socks5://host:port", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q, expected http, https, or socks5", u.Scheme)
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("cannot configure the proxy on the default transport")
+	}
+	proxyFor := (&httpproxy.Config{HTTPProxy: proxy, HTTPSProxy: proxy, NoProxy: noProxy}).ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFor(req.URL)
+	}
+	slog.Debug("Using proxy", "proxy", u.Redacted(), "no_proxy", noProxy)
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+func openQueue(cfg *config.Config) (*queue.Queue, error) {
+	return queue.New(filepath.Join(cfg.OutputDir, ".queue.json"))
+}
+
+func runSubmit(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	prompt := fs.String("prompt", "", "custom prompt, or @FILE to read it from a file")
+	name := fs.String("name", "", "input file name without extension (default: timestamp)")
+	priority := fs.String("priority", "", "low, normal, high, or a number; higher priorities are processed first")
+	after := fs.String("after", "", "defer processing until a date, a time of day (23:30), or after a delay (2h)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: briefly " + commands["submit"].usage)
+	}
+	url := strings.TrimSpace(positional[0])
+
+	customPrompt := *prompt
+	if strings.HasPrefix(customPrompt, "@") {
+		if customPrompt, err = readPromptFile(customPrompt[1:]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := models.ParsePriority(*priority); err != nil {
+		return err
+	}
+	// Delays are resolved now, rather than when the watcher reads the file
+	notBefore, err := models.ParseNotBefore(*after, time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+
+	base := *name
+	if base == "" {
+		base = time.Now().Format("20060102-150405")
+	}
+	path := filepath.Join(cfg.WatchDir, base+".briefly")
+
+	var content string
+	if customPrompt == "" && *priority == "" && notBefore.IsZero() {
+		content = url + "\n"
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "---\nurl: %s\n", url)
+		if *priority != "" {
+			fmt.Fprintf(&b, "priority: %s\n", *priority)
+		}
+		if !notBefore.IsZero() {
+			fmt.Fprintf(&b, "process_after: %s\n", notBefore.Format(time.RFC3339))
+		}
+		if customPrompt != "" {
+			b.WriteString("prompt: |\n")
+			for _, line := range strings.Split(customPrompt, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+		b.WriteString("---\n")
+		content = b.String()
+	}
+
+	if err := writeInputFile(cfg.WatchDir, base, content); err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
+
+// writeInputFile drops "<base>.briefly" into the watch directory, through a
+// hidden temp file so the watcher never sees a partial file
+func writeInputFile(watchDir, base, content string) error {
+	path := filepath.Join(watchDir, base+".briefly")
+	tmp := filepath.Join(watchDir, "."+base+".tmp")
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write input file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write input file: %w", err)
+	}
+	return nil
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	status := fs.String("status", "", "only show jobs with this status")
+	code := fs.String("error-code", "", "only show jobs that failed with this error code")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q, err := openQueue(config.Load())
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tPRIORITY\tTYPE\tRETRIES\tSOURCE\tSTAGE\tCODE\tERROR")
+	for _, job := range q.List() {
+		if *status != "" && string(job.Status) != *status {
+			continue
+		}
+		if *code != "" && string(job.ErrorCode) != *code {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			job.ID, job.Status, job.Priority, job.ContentType, job.Retries, job.Source(), job.Stage, job.ErrorCode, job.Error)
+	}
+	return tw.Flush()
+}
+
+func runRetry(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: briefly " + commands["retry"].usage)
+	}
+
+	cfg := config.Load()
+	if daemonRunning(cfg) {
+		// The daemon would overwrite the queue file, so it retries the jobs
+		if cfg.HTTPAddr == "" {
+			return errDaemonRunning(cfg, "retry")
+		}
+		for _, id := range args {
+			if err := apiRetry(cfg, id); err != nil {
+				return err
+			}
+			fmt.Printf("Job %s reset to pending\n", id)
+		}
+		return nil
+	}
+
+	q, err := openQueue(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range args {
+		if err := q.Retry(id); err != nil {
+			return err
+		}
+		fmt.Printf("Job %s reset to pending\n", id)
+	}
+	return nil
+}
+
+func runPurge(args []string) error {
+	cfg := config.Load()
+	if daemonRunning(cfg) {
+		return errDaemonRunning(cfg, "purge")
+	}
+
+	q, err := openQueue(cfg)
+	if err != nil {
+		return err
+	}
+
+	removed, err := q.Purge()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d completed/failed job(s)\n", removed)
+	return nil
+}
+
+func runDeadLetter(args []string) error {
+	fs := flag.NewFlagSet("dead-letter", flag.ExitOnError)
+	purge := fs.Bool("purge", false, "remove every job from the dead-letter list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	if *purge && daemonRunning(cfg) {
+		return errDaemonRunning(cfg, "dead-letter --purge")
+	}
+
+	q, err := openQueue(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *purge {
+		removed, err := q.PurgeDeadLetters()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d dead-lettered job(s)\n", removed)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFAILED\tTYPE\tSOURCE\tINPUT\tCODE\tERROR")
+	for _, job := range q.DeadLetters() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			job.ID, job.UpdatedAt.Local().Format("2006-01-02 15:04"), job.ContentType, job.Source(), job.FilePath, job.ErrorCode, job.Error)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/rating"
+)
+
+func runRatings(args []string) error {
+	cfg := config.Load()
+
+	ratings, err := rating.New(cfg.OutputDir).All()
+	if err != nil {
+		return err
+	}
+	if len(ratings) == 0 {
+		fmt.Println("No ratings recorded yet")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tPROMPT\tRATINGS\tAVERAGE")
+	for _, row := range rating.Report(ratings) {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\n", row.Model, row.Prompt, row.Count, row.Average)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tNAME\tRATING\tMODEL\tCOMMENT")
+	for _, r := range ratings {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", r.RatedAt.Format("2006-01-02"), r.Name, r.Rating, r.Model, r.Comment)
+	}
+	return tw.Flush()
+}
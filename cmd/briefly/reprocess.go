@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/rating"
+)
+
+// reprocessDir keeps the summaries replaced by reprocess, hidden from the
+// watcher and dashboard
+const reprocessDir = ".reprocessed"
+
+type reprocessInput struct {
+	URL   string `yaml:"url"`
+	Name  string `yaml:"name"`
+	Model string `yaml:"model,omitempty"`
+	Feed  string `yaml:"feed,omitempty"`
+}
+
+// runReprocess queues summaries made with an old model for regeneration.
+// Jobs go through the watch directory, so the running daemon applies its
+// budget caps and LLM limits to them like to any other input.
+func runReprocess(args []string) error {
+	fs := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	from := fs.String("from", "", "only summaries whose model starts with this, e.g. claude/claude-3-7 (default: any other than the target)")
+	to := fs.String("to", "", "provider:model to regenerate with (default: the configured model)")
+	limit := fs.Int("limit", 0, "regenerate at most this many summaries (0 for all)")
+	dryRun := fs.Bool("dry-run", false, "list the summaries that would be regenerated")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+
+	target := cfg.LLMProvider + "/" + cfg.LLMModel
+	if *to != "" {
+		provider, model, _ := strings.Cut(*to, ":")
+		if model == "" {
+			model = config.DefaultModel(provider)
+		}
+		target = provider + "/" + model
+	}
+
+	summaries, err := findSummaries(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	queued, skipped := 0, 0
+	for _, path := range summaries {
+		if *limit > 0 && queued >= *limit {
+			break
+		}
+
+		header, err := rating.ParseSummaryHeader(path)
+		if err != nil {
+			return err
+		}
+		model := header["Model"]
+		if model == "" || model == target || (*from != "" && !strings.HasPrefix(model, *from)) {
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		if header["URL"] == "" {
+			fmt.Printf("skip     %s (%s): no URL to regenerate from\n", name, model)
+			skipped++
+			continue
+		}
+
+		queued++
+		if *dryRun {
+			fmt.Printf("would    %s (%s -> %s)\n", name, model, target)
+			continue
+		}
+		fmt.Printf("queue    %s (%s -> %s)\n", name, model, target)
+
+		if err := queueReprocess(cfg, path, name, header, *to); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	fmt.Printf("%d summaries queued for %s, %d skipped\n", queued, target, skipped)
+	return nil
+}
+
+// findSummaries returns the summaries in outputDir and its feed subfolders
+func findSummaries(outputDir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != outputDir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".md") && !strings.HasPrefix(name, ".") && !strings.Contains(name, ".rated-") &&
+			!strings.HasSuffix(name, ".transcript.md") && !strings.HasSuffix(name, ".archive.md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// queueReprocess moves the old summary aside and drops an input file that
+// regenerates it under the same name, keeping the user's notes
+func queueReprocess(cfg *config.Config, path, name string, header map[string]string, model string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var notes string
+	if _, after, found := strings.Cut(string(data), "\n## My notes\n"); found {
+		notes = strings.TrimSpace(after)
+	}
+
+	front, err := yaml.Marshal(reprocessInput{
+		URL:   header["URL"],
+		Name:  name,
+		Model: model,
+		Feed:  header["Feed"],
+	})
+	if err != nil {
+		return err
+	}
+	content := "---\n" + string(front) + "---\n"
+	if notes != "" {
+		content += notes + "\n"
+	}
+
+	archive := filepath.Join(cfg.OutputDir, reprocessDir)
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return err
+	}
+	archived := filepath.Join(archive, fmt.Sprintf("%s.%s.md", name, time.Now().Format("20060102-150405")))
+	if err := os.Rename(path, archived); err != nil {
+		return err
+	}
+
+	if err := writeInputFile(cfg.WatchDir, "reprocess-"+name, content); err != nil {
+		// Put the summary back so nothing is lost
+		if restoreErr := os.Rename(archived, path); restoreErr != nil {
+			return errors.Join(err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/processor"
+	"github.com/clobrano/briefly/internal/search"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+// newSearchIndex returns the semantic search index of the summaries, or nil
+// when no embeddings provider is configured
+func newSearchIndex(cfg *config.Config) (*search.Index, error) {
+	if cfg.EmbeddingsProvider == "" {
+		return nil, nil
+	}
+	model := cfg.EmbeddingsModel
+	if model == "" {
+		model = search.DefaultModel(cfg.EmbeddingsProvider)
+	}
+
+	var embedder search.Embedder
+	switch cfg.EmbeddingsProvider {
+	case "openai":
+		baseURL := cfg.OpenAIURL
+		if baseURL == "" {
+			baseURL = summarizer.DefaultOpenAIBaseURL
+		}
+		embedder = search.NewOpenAIEmbedder(baseURL, cfg.OpenAIKey, model)
+	case "ollama":
+		embedder = search.NewOpenAIEmbedder(cfg.OllamaURL, "", model)
+	case "gemini":
+		var err error
+		if embedder, err = search.NewGeminiEmbedder(context.Background(), cfg.GoogleKey, model); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q, expected openai, gemini, or ollama", cfg.EmbeddingsProvider)
+	}
+	return search.New(filepath.Join(cfg.OutputDir, ".embeddings.jsonl"), embedder), nil
+}
+
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	limit := fs.Int("limit", 10, "show at most this many summaries")
+	reindex := fs.Bool("reindex", false, "index the summaries in history that are not indexed yet, such as those written before search was enabled")
+	asJSON := fs.Bool("json", false, "print the results as JSON lines")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" && !*reindex {
+		return errors.New("usage: briefly " + commands["search"].usage)
+	}
+
+	cfg := config.Load()
+	index, err := newSearchIndex(cfg)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return errors.New("semantic search is not enabled, set BRIEFLY_EMBEDDINGS_PROVIDER")
+	}
+
+	ctx := context.Background()
+	if *reindex {
+		n, err := reindexSummaries(ctx, cfg, index)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Indexed %d summaries\n", n)
+		if query == "" {
+			return nil
+		}
+	}
+
+	results, err := index.Search(ctx, query, *limit)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(results) == 0 {
+		fmt.Println("No summaries found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tTITLE\tOUTPUT")
+	for _, r := range results {
+		title := r.Title
+		if title == "" {
+			title = r.URL
+		}
+		fmt.Fprintf(tw, "%.2f\t%s\t%s\n", r.Score, title, r.OutputPath)
+	}
+	return tw.Flush()
+}
+
+// reindexSummaries adds the summaries of completed jobs in history that are
+// missing from index, and returns their number
+func reindexSummaries(ctx context.Context, cfg *config.Config, index *search.Index) (int, error) {
+	indexed, err := index.Indexed()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := history.New(filepath.Join(cfg.OutputDir, ".history.jsonl")).Query(history.Filter{Status: models.JobStatusCompleted})
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, e := range entries {
+		if e.OutputPath == "" || indexed[e.OutputPath] {
+			continue
+		}
+		data, err := os.ReadFile(e.OutputPath)
+		if err != nil {
+			continue
+		}
+		doc := search.Document{
+			ID:          e.ID,
+			Title:       e.Title,
+			URL:         e.URL,
+			OutputPath:  e.OutputPath,
+			ContentType: e.ContentType,
+			Text:        processor.EmbeddingText(cfg, withoutFrontMatter(string(data))),
+		}
+		if err := index.Add(ctx, doc); err != nil {
+			return added, fmt.Errorf("failed to index %s: %w", e.OutputPath, err)
+		}
+		indexed[e.OutputPath] = true
+		added++
+		slog.Debug("Indexed summary", "path", e.OutputPath)
+	}
+	return added, nil
+}
+
+// withoutFrontMatter strips the YAML properties block of a summary file
+func withoutFrontMatter(text string) string {
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if _, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			return strings.TrimSpace(body)
+		}
+	}
+	return text
+}
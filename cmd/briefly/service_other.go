@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// runAsService reports whether Briefly ran as a Windows service, which it
+// never does elsewhere
+func runAsService() bool {
+	return false
+}
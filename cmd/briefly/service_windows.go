@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/svc"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/logging"
+)
+
+// runAsService runs the daemon under the Windows service manager when it
+// started Briefly, and reports whether it did
+func runAsService() bool {
+	inService, err := svc.IsWindowsService()
+	if err != nil {
+		slog.Warn("Failed to detect the Windows service manager", "error", err)
+		return false
+	}
+	if !inService {
+		return false
+	}
+
+	// Services have no console, so the log goes to a file
+	logPath := filepath.Join(config.DataDir(), "briefly.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err == nil {
+		if f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err == nil {
+			defer f.Close()
+			format, level := config.Logging()
+			if err := logging.SetupWriter(f, format, level); err != nil {
+				logging.Fatal("Configuration error", "error", err)
+			}
+		}
+	}
+
+	if err := svc.Run("briefly", daemonService{}); err != nil {
+		logging.Fatal("Failed to run as a Windows service", "error", err)
+	}
+	return true
+}
+
+// daemonService runs the daemon until the service manager stops it
+type daemonService struct{}
+
+func (daemonService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		runDaemon(stop)
+		close(done)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case <-done:
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				if req.Cmd == svc.Stop {
+					stop <- "stopped by the service manager"
+				} else {
+					stop <- "system shutdown"
+				}
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
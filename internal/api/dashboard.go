@@ -0,0 +1,211 @@
+package api
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/events"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+//go:embed templates/dashboard.html
+var templateFS embed.FS
+
+var dashboardTemplate = template.Must(template.ParseFS(templateFS, "templates/dashboard.html"))
+
+const (
+	// maxRecentSummaries bounds the summaries listed on the dashboard
+	maxRecentSummaries = 30
+	// maxRecentEvents bounds the activity entries listed on the dashboard
+	maxRecentEvents = 20
+)
+
+type summaryFile struct {
+	// Name is the path relative to the output directory, slash separated
+	Name    string
+	ModTime time.Time
+}
+
+// Link is the dashboard URL of the summary
+func (f summaryFile) Link() string {
+	return "/summaries/" + SummaryPath(f.Name)
+}
+
+// SummaryPath escapes name, a slash-separated path relative to the output
+// directory, for the GET /summaries route
+func SummaryPath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// summaryTypes are the content types of the output formats
+var summaryTypes = map[string]string{
+	".md":   "text/markdown; charset=utf-8",
+	".json": "application/json",
+	".html": "text/html; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+}
+
+// isSummary reports whether the file name is a summary, rather than a
+// transcript, an archived or rated-down copy, or Briefly's own state
+func isSummary(name string) bool {
+	return summaryTypes[filepath.Ext(name)] != "" && !strings.HasPrefix(name, ".") && !strings.Contains(name, ".rated-") &&
+		!strings.HasSuffix(name, ".transcript.md") && !strings.HasSuffix(name, ".archive.md")
+}
+
+type dashboardData struct {
+	Jobs       []models.Job
+	Summaries  []summaryFile
+	Events     []events.Event
+	Pending    int
+	Processing int
+	Failed     int
+}
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	data := dashboardData{
+		Jobs:   s.queue.List(),
+		Events: s.events.Recent(maxRecentEvents),
+	}
+	for _, job := range data.Jobs {
+		switch job.Status {
+		case models.JobStatusPending:
+			data.Pending++
+		case models.JobStatusProcessing:
+			data.Processing++
+		case models.JobStatusFailed:
+			data.Failed++
+		}
+	}
+
+	summaries, err := s.recentSummaries()
+	if err != nil {
+		slog.Warn("Failed to list summaries", "error", err)
+	}
+	data.Summaries = summaries
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		slog.Warn("Failed to render dashboard", "error", err)
+	}
+}
+
+func (s *Server) handleDashboardSubmit(w http.ResponseWriter, r *http.Request) {
+	url := strings.TrimSpace(r.FormValue("url"))
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	job := models.NewJob("", url, "")
+	if err := s.queue.Enqueue(job); err != nil {
+		http.Error(w, "failed to enqueue job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Queued job via dashboard", "job_id", job.ID, "url", job.URL)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleDashboardRetry(w http.ResponseWriter, r *http.Request) {
+	if err := s.queue.Retry(r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleDashboardDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteJob(r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// handleSummary serves a summary by its path relative to the output
+// directory, which it cannot leave
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean(r.PathValue("path"))
+	if !filepath.IsLocal(name) || !isSummary(path.Base(name)) || isIndex(name) {
+		http.NotFound(w, r)
+		return
+	}
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if strings.HasPrefix(dir, ".") && dir != "." {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.outputDir, filepath.FromSlash(name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", summaryTypes[path.Ext(name)])
+	// HTML summaries carry model output, so their scripts do not run
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Write(data)
+}
+
+// isIndex reports whether name, relative to the output directory, is the
+// summary index
+func isIndex(name string) bool {
+	return name == "index.md" || name == "index.json"
+}
+
+// recentSummaries returns the newest summary files in the output directory
+// and its subfolders, such as feed and date folders
+func (s *Server) recentSummaries() ([]summaryFile, error) {
+	var files []summaryFile
+	err := filepath.WalkDir(s.outputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != s.outputDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !isSummary(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.outputDir, p)
+		if err != nil || isIndex(filepath.ToSlash(rel)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, summaryFile{Name: filepath.ToSlash(rel), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	if len(files) > maxRecentSummaries {
+		files = files[:maxRecentSummaries]
+	}
+	return files, nil
+}
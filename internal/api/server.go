@@ -0,0 +1,344 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/events"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/search"
+)
+
+type Server struct {
+	httpServer *http.Server
+	mux        *http.ServeMux
+	queue      *queue.Queue
+	outputDir  string
+	events     *events.Log
+	index      *search.Index
+	token      string
+}
+
+// submitRequest is the body accepted by POST /jobs
+type submitRequest struct {
+	URL      string `json:"url"`
+	Text     string `json:"text,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+	Feed     string `json:"feed,omitempty"`
+	Persona  string `json:"persona,omitempty"`
+	Style    string `json:"style,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	// Language is the ISO 639-1 code the summary is written in, and
+	// SourceLanguage the one of the content, detected when empty
+	Language       string `json:"language,omitempty"`
+	SourceLanguage string `json:"source_language,omitempty"`
+	// Provider and Model select the LLM, see config.SplitModel
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Questions are answered from the content next to the summary
+	Questions []string `json:"questions,omitempty"`
+	// ProcessAfter defers the job, see models.ParseNotBefore
+	ProcessAfter string `json:"process_after,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func New(addr string, q *queue.Queue, outputDir string, ev *events.Log) *Server {
+	mux := http.NewServeMux()
+	s := &Server{mux: mux, queue: q, outputDir: outputDir, events: ev}
+
+	mux.HandleFunc("POST /jobs", s.handleSubmit)
+	mux.HandleFunc("GET /jobs", s.handleList)
+	mux.HandleFunc("GET /jobs/{id}", s.handleGet)
+	mux.HandleFunc("DELETE /jobs/{id}", s.handleDelete)
+	mux.HandleFunc("POST /jobs/{id}/retry", s.handleRetry)
+	mux.HandleFunc("GET /dead-letter", s.handleDeadLetter)
+	mux.HandleFunc("POST /batches/{id}/confirm", s.handleConfirmBatch)
+	mux.HandleFunc("DELETE /batches/{id}", s.handleCancelBatch)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /search", s.handleSearch)
+
+	// Web dashboard
+	mux.HandleFunc("GET /{$}", s.handleDashboard)
+	mux.HandleFunc("POST /ui/submit", s.handleDashboardSubmit)
+	mux.HandleFunc("POST /ui/jobs/{id}/retry", s.handleDashboardRetry)
+	mux.HandleFunc("POST /ui/jobs/{id}/delete", s.handleDashboardDelete)
+	mux.HandleFunc("GET /summaries/{path...}", s.handleSummary)
+
+	// Browsers are kept from posting to the API on behalf of other sites
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           http.NewCrossOriginProtection().Handler(s.authorize(mux)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetIndex enables GET /search over the summaries in x
+func (s *Server) SetIndex(x *search.Index) {
+	s.index = x
+}
+
+// SetToken requires token for requests that change anything: submitting,
+// retrying, deleting, and confirming. It is given as a bearer token, or as
+// the password of HTTP basic authentication, which browsers prompt for.
+func (s *Server) SetToken(token string) {
+	s.token = token
+}
+
+// authorize rejects requests other than reads without the token
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			_, token, _ = r.BasicAuth()
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Briefly"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server error", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req submitRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	req.Text = strings.TrimSpace(req.Text)
+	if req.URL == "" && req.Text == "" {
+		writeError(w, http.StatusBadRequest, "url or text is required")
+		return
+	}
+	priority, err := models.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	notBefore, err := models.ParseNotBefore(req.ProcessAfter, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var job *models.Job
+	if req.Text != "" {
+		job = models.NewTextJob("", req.Text, strings.TrimSpace(req.Prompt))
+	} else {
+		job = models.NewJob("", req.URL, strings.TrimSpace(req.Prompt))
+	}
+	job.Notes = strings.TrimSpace(req.Notes)
+	job.Feed = strings.TrimSpace(req.Feed)
+	job.Persona = strings.TrimSpace(req.Persona)
+	job.Style = strings.TrimSpace(req.Style)
+	job.OutputFormat = strings.ToLower(strings.TrimSpace(req.Format))
+	for _, q := range req.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			job.Questions = append(job.Questions, q)
+		}
+	}
+	job.SummaryLanguage = strings.ToLower(strings.TrimSpace(req.Language))
+	job.Language = strings.ToLower(strings.TrimSpace(req.SourceLanguage))
+	job.Provider, job.Model = config.SplitModel(strings.TrimSpace(req.Provider), strings.TrimSpace(req.Model))
+	if job.Provider != "" {
+		if err := config.CheckProvider(job.Provider); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	job.Priority = priority
+	job.NotBefore = notBefore
+	if err := s.queue.Enqueue(job); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to enqueue job: "+err.Error())
+		return
+	}
+
+	slog.Info("Queued job via API", "job_id", job.ID, "url", job.URL)
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	jobs := s.queue.List()
+	status := r.URL.Query().Get("status")
+	code := r.URL.Query().Get("error_code")
+	if status != "" || code != "" {
+		filtered := jobs[:0]
+		for _, job := range jobs {
+			if (status == "" || string(job.Status) == status) && (code == "" || string(job.ErrorCode) == code) {
+				filtered = append(filtered, job)
+			}
+		}
+		jobs = filtered
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteJob(r.PathValue("id")); err != nil {
+		if errors.Is(err, queue.ErrJobNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to remove job: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRetry(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.queue.Retry(id); err != nil {
+		if errors.Is(err, queue.ErrJobNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	job, _ := s.queue.Get(id)
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleDeadLetter(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.queue.DeadLetters())
+}
+
+func (s *Server) handleConfirmBatch(w http.ResponseWriter, r *http.Request) {
+	released, err := s.queue.ReleaseBatch(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"released": released})
+}
+
+// handleCancelBatch drops the held jobs of a batch with their input files
+func (s *Server) handleCancelBatch(w http.ResponseWriter, r *http.Request) {
+	dropped, err := s.queue.DropBatch(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	for _, job := range dropped {
+		if job.FilePath != "" {
+			os.Remove(job.FilePath)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	writeJSON(w, http.StatusOK, s.events.Recent(limit))
+}
+
+// handleSearch returns the summaries most related in meaning to ?q=
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if s.index == nil {
+		writeError(w, http.StatusNotFound, "semantic search is not enabled, set BRIEFLY_EMBEDDINGS_PROVIDER")
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	results, err := s.index.Search(r.Context(), query, limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if results == nil {
+		results = []search.Result{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// deleteJob removes a job from the queue together with its input file,
+// otherwise the watcher would pick the file up again on restart.
+func (s *Server) deleteJob(id string) error {
+	job, ok := s.queue.Get(id)
+	if !ok {
+		return queue.ErrJobNotFound
+	}
+	if err := s.queue.Remove(job.ID); err != nil {
+		return err
+	}
+	if job.FilePath != "" {
+		os.Remove(job.FilePath)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write HTTP response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,194 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+// Limits caps spending per provider. A zero value disables the limit.
+// The "*" key applies to providers without a specific entry.
+type Limits struct {
+	DailyUSD      map[string]float64
+	MonthlyUSD    map[string]float64
+	DailyTokens   map[string]float64
+	MonthlyTokens map[string]float64
+}
+
+// Enabled reports whether any limit is configured
+func (l Limits) Enabled() bool {
+	return len(l.DailyUSD)+len(l.MonthlyUSD)+len(l.DailyTokens)+len(l.MonthlyTokens) > 0
+}
+
+// ParseLimit parses either a single number applying to every provider
+// ("5") or a per-provider list ("claude=5,gemini=1.5,*=10").
+func ParseLimit(spec string) (map[string]float64, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	limits := make(map[string]float64)
+	for _, part := range strings.Split(spec, ",") {
+		provider, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			provider, value = "*", provider
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budget %q: %w", part, err)
+		}
+		limits[strings.ToLower(strings.TrimSpace(provider))] = v
+	}
+	return limits, nil
+}
+
+func limitFor(limits map[string]float64, provider string) float64 {
+	if v, ok := limits[provider]; ok {
+		return v
+	}
+	return limits["*"]
+}
+
+// spend is the usage accumulated by one provider in one period
+type spend struct {
+	Tokens int64   `json:"tokens"`
+	USD    float64 `json:"usd"`
+}
+
+type state struct {
+	Day     string            `json:"day"`
+	Month   string            `json:"month"`
+	Daily   map[string]*spend `json:"daily"`
+	Monthly map[string]*spend `json:"monthly"`
+}
+
+// Tracker accumulates provider spending and persists it across restarts
+type Tracker struct {
+	mu          sync.Mutex
+	limits      Limits
+	persistPath string
+	state       state
+	now         func() time.Time
+}
+
+func New(limits Limits, persistPath string) (*Tracker, error) {
+	t := &Tracker{
+		limits:      limits,
+		persistPath: persistPath,
+		now:         time.Now,
+	}
+	if err := t.load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	t.roll()
+	return t, nil
+}
+
+// Record adds the usage of one job to provider's totals
+func (t *Tracker) Record(provider, model string, input, output int64) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.roll()
+
+	cost := summarizer.EstimateCost(model, input, output)
+	for _, m := range []map[string]*spend{t.state.Daily, t.state.Monthly} {
+		s, ok := m[provider]
+		if !ok {
+			s = &spend{}
+			m[provider] = s
+		}
+		s.Tokens += input + output
+		s.USD += cost
+	}
+	return t.persist()
+}
+
+// Exhausted reports whether provider has reached any of its limits, with a
+// human readable reason and the time the exhausted period resets.
+func (t *Tracker) Exhausted(provider string) (bool, string, time.Time) {
+	if t == nil {
+		return false, "", time.Time{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.roll()
+
+	now := t.now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+
+	daily := t.state.Daily[provider]
+	if daily == nil {
+		daily = &spend{}
+	}
+	monthly := t.state.Monthly[provider]
+	if monthly == nil {
+		monthly = &spend{}
+	}
+
+	if l := limitFor(t.limits.MonthlyUSD, provider); l > 0 && monthly.USD >= l {
+		return true, fmt.Sprintf("monthly budget of $%.2f reached", l), nextMonth
+	}
+	if l := limitFor(t.limits.MonthlyTokens, provider); l > 0 && float64(monthly.Tokens) >= l {
+		return true, fmt.Sprintf("monthly budget of %.0f tokens reached", l), nextMonth
+	}
+	if l := limitFor(t.limits.DailyUSD, provider); l > 0 && daily.USD >= l {
+		return true, fmt.Sprintf("daily budget of $%.2f reached", l), tomorrow
+	}
+	if l := limitFor(t.limits.DailyTokens, provider); l > 0 && float64(daily.Tokens) >= l {
+		return true, fmt.Sprintf("daily budget of %.0f tokens reached", l), tomorrow
+	}
+	return false, "", time.Time{}
+}
+
+// roll resets the counters when a new day or month starts
+func (t *Tracker) roll() {
+	now := t.now()
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+
+	if t.state.Day != day || t.state.Daily == nil {
+		t.state.Day = day
+		t.state.Daily = make(map[string]*spend)
+	}
+	if t.state.Month != month || t.state.Monthly == nil {
+		t.state.Month = month
+		t.state.Monthly = make(map[string]*spend)
+	}
+}
+
+func (t *Tracker) persist() error {
+	if t.persistPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(t.persistPath, data, 0644)
+}
+
+func (t *Tracker) load() error {
+	if t.persistPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.persistPath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &t.state)
+}
@@ -0,0 +1,134 @@
+package channels
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// channelID matches a YouTube channel ID, as used by upload feeds
+var channelID = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+
+// canonicalChannel finds the channel ID in the canonical link of a channel page
+var canonicalChannel = regexp.MustCompile(`youtube\.com/channel/(UC[A-Za-z0-9_-]{22})`)
+
+// Channel is a YouTube channel to monitor
+type Channel struct {
+	// Spec is the channel as configured: an ID, a "@handle", or a channel URL
+	Spec string
+	// Filter, when set, only lets through the videos whose title matches
+	Filter *regexp.Regexp
+}
+
+// Parse reads a comma-separated list of channels, each optionally followed
+// by "=<regexp>" to filter videos by title
+func Parse(spec string) ([]Channel, error) {
+	var channels []Channel
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, filter, _ := strings.Cut(entry, "=")
+		c := Channel{Spec: strings.TrimSpace(name)}
+		if filter = strings.TrimSpace(filter); filter != "" {
+			re, err := regexp.Compile(filter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid title filter for channel %s: %w", c.Spec, err)
+			}
+			c.Filter = re
+		}
+		channels = append(channels, c)
+	}
+	return channels, nil
+}
+
+// Video is an entry of a channel upload feed
+type Video struct {
+	ID        string    `xml:"videoId"`
+	Title     string    `xml:"title"`
+	Published time.Time `xml:"published"`
+}
+
+// URL is the watch page of the video
+func (v Video) URL() string {
+	return "https://www.youtube.com/watch?v=" + v.ID
+}
+
+// Feed is the upload feed of a channel, with its latest videos first
+type Feed struct {
+	Title  string  `xml:"title"`
+	Videos []Video `xml:"entry"`
+}
+
+// Client reads channel upload feeds, which need no API key
+type Client struct {
+	client *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Resolve returns the channel ID of spec, looking up handles and custom
+// channel URLs on their page
+func (c *Client) Resolve(ctx context.Context, spec string) (string, error) {
+	if channelID.MatchString(spec) {
+		return spec, nil
+	}
+	if m := canonicalChannel.FindStringSubmatch(spec); m != nil {
+		return m[1], nil
+	}
+
+	page := spec
+	if strings.HasPrefix(spec, "@") {
+		page = "https://www.youtube.com/" + spec
+	}
+	if u, err := url.Parse(page); err != nil || u.Scheme == "" {
+		return "", fmt.Errorf("not a YouTube channel: %s", spec)
+	}
+	body, err := c.get(ctx, page)
+	if err != nil {
+		return "", err
+	}
+	m := canonicalChannel.FindSubmatch(body)
+	if m == nil {
+		return "", fmt.Errorf("no channel ID found on %s", page)
+	}
+	return string(m[1]), nil
+}
+
+// Uploads reads the upload feed of the channel with id
+func (c *Client) Uploads(ctx context.Context, id string) (*Feed, error) {
+	body, err := c.get(ctx, "https://www.youtube.com/feeds/videos.xml?channel_id="+url.QueryEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	var feed Feed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to decode channel feed: %w", err)
+	}
+	return &feed, nil
+}
+
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("youtube returned status %d for %s", resp.StatusCode, rawURL)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,187 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// Poller queues the new uploads of YouTube channels on an interval. Its
+// state is persisted so videos are queued once across restarts.
+type Poller struct {
+	client   *Client
+	queue    *queue.Queue
+	history  *history.Store
+	channels []Channel
+	interval time.Duration
+	done     chan struct{}
+
+	mu sync.Mutex
+	// ids caches the channel ID of each configured channel
+	ids         map[string]string
+	state       state
+	persistPath string
+}
+
+type state struct {
+	// Seen maps each channel ID to the videos of its last feed. A channel
+	// missing here is new, and its current videos are not queued.
+	Seen map[string][]string `json:"seen,omitempty"`
+}
+
+// NewPoller polls the upload feeds of channels. Videos already in history,
+// when set, are not queued again.
+func NewPoller(client *Client, q *queue.Queue, hist *history.Store, channels []Channel, interval time.Duration, persistPath string) *Poller {
+	p := &Poller{
+		client:      client,
+		queue:       q,
+		history:     hist,
+		channels:    channels,
+		interval:    interval,
+		done:        make(chan struct{}),
+		ids:         make(map[string]string),
+		persistPath: persistPath,
+	}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			slog.Warn("Failed to load channel state", "path", persistPath, "error", err)
+		}
+	}
+	if p.state.Seen == nil {
+		p.state.Seen = make(map[string][]string)
+	}
+	return p
+}
+
+func (p *Poller) Start() {
+	go p.run()
+}
+
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if n, err := p.Poll(ctx); err != nil {
+			slog.Warn("Failed to poll YouTube channels", "error", err)
+		} else if n > 0 {
+			slog.Info("Queued YouTube videos", "jobs", n)
+		}
+		cancel()
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll queues the videos uploaded since the last poll and returns their
+// number. A channel that fails is skipped until the next poll.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queued := 0
+	for _, channel := range p.channels {
+		n, err := p.pollChannel(ctx, channel)
+		queued += n
+		if err != nil {
+			slog.Warn("Failed to poll YouTube channel", "channel", channel.Spec, "error", err)
+		}
+	}
+	return queued, p.persist()
+}
+
+func (p *Poller) pollChannel(ctx context.Context, channel Channel) (int, error) {
+	id, ok := p.ids[channel.Spec]
+	if !ok {
+		var err error
+		if id, err = p.client.Resolve(ctx, channel.Spec); err != nil {
+			return 0, err
+		}
+		p.ids[channel.Spec] = id
+	}
+
+	feed, err := p.client.Uploads(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	seen, known := p.state.Seen[id]
+	current := make([]string, 0, len(feed.Videos))
+	for _, video := range feed.Videos {
+		current = append(current, video.ID)
+	}
+	if !known {
+		p.state.Seen[id] = current
+		slog.Info("Monitoring YouTube channel", "channel", feed.Title, "videos", len(current))
+		return 0, nil
+	}
+
+	skip := make(map[string]bool, len(seen))
+	for _, videoID := range seen {
+		skip[videoID] = true
+	}
+	queued := 0
+	// The feed lists the latest videos first; they are queued oldest first
+	for i := len(feed.Videos) - 1; i >= 0; i-- {
+		video := feed.Videos[i]
+		if skip[video.ID] || video.ID == "" {
+			continue
+		}
+		if channel.Filter != nil && !channel.Filter.MatchString(video.Title) {
+			continue
+		}
+		if p.summarized(video) {
+			slog.Debug("Skipping video already in history", "url", video.URL())
+			continue
+		}
+		job := models.NewJob("", video.URL(), "")
+		job.Title = video.Title
+		job.Feed = feed.Title
+		if err := p.queue.EnqueueInput(job); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	p.state.Seen[id] = current
+	return queued, nil
+}
+
+// summarized reports whether video was already summarized, for example when
+// its link was dropped in the watch directory before it showed up in the feed
+func (p *Poller) summarized(video Video) bool {
+	if p.history == nil {
+		return false
+	}
+	entries, err := p.history.Query(history.Filter{Status: models.JobStatusCompleted, Search: video.ID})
+	if err != nil {
+		slog.Warn("Failed to query history", "error", err)
+		return false
+	}
+	return len(entries) > 0
+}
+
+func (p *Poller) persist() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.persistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to persist channel state: %w", err)
+	}
+	return nil
+}
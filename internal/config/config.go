@@ -1,7 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -12,8 +17,318 @@ type Config struct {
 	LLMModel     string
 	AnthropicKey string
 	GoogleKey    string
+	OpenAIKey    string
+	OpenAIURL    string
+	OllamaURL    string
+
+	// LLMFallback lists "provider" or "provider:model" entries tried in order
+	// when the primary provider fails
+	LLMFallback  string
 	NtfyTopic    string
 	WhisperModel string
+	TempDir      string
+	HTTPAddr     string
+	PublicURL    string
+	Workers      int
+
+	// HTTPToken, when set, is required by the HTTP API to change anything
+	HTTPToken string
+
+	// ReplicaID names this instance in job leases, LeaseSeconds is how long
+	// a job stays claimed without a heartbeat (0 disables leasing)
+	ReplicaID    string
+	LeaseSeconds int
+
+	// MaxRetries is how often a failed job is retried, waiting
+	// RetryBackoffSeconds the first time and twice as long each time after,
+	// up to RetryMaxBackoffSeconds, varied by RetryJitterPercent either way.
+	// RetryPolicy overrides the retries of error codes, e.g.
+	// "provider_rejected=never,timeout=always".
+	MaxRetries             int
+	RetryBackoffSeconds    int
+	RetryMaxBackoffSeconds int
+	RetryJitterPercent     int
+	RetryPolicy            string
+
+	// Timeouts of the stages of a job, 0 for none. Extraction covers
+	// fetching and reading content; audio and video get the download and
+	// transcription timeouts on top of it.
+	DownloadTimeoutMinutes      int
+	TranscriptionTimeoutMinutes int
+	ExtractionTimeoutMinutes    int
+	SummarizationTimeoutMinutes int
+
+	// Transcriber is "local" for a local Whisper installation or "api" for
+	// an OpenAI-compatible transcription endpoint
+	Transcriber      string
+	TranscriberURL   string
+	TranscriberKey   string
+	TranscriberModel string
+
+	// TranscriptionLanguage is the ISO 639-1 code of the spoken language of
+	// videos and recordings; empty detects it
+	TranscriptionLanguage string
+
+	// VisionImages is the number of article images sent to vision-capable
+	// models along with the text, 0 disables it
+	VisionImages int
+
+	// Twitter/X threads are unrolled through the X API with XBearerToken,
+	// otherwise through the comma-separated NitterInstances
+	XBearerToken    string
+	NitterInstances string
+
+	// RedditComments is the number of top comments summarized along with
+	// a Reddit post
+	RedditComments int
+
+	// GitHubToken authenticates GitHub API calls for repository summaries
+	GitHubToken string
+
+	// Telegram notifications are sent by the bot with TelegramToken to
+	// TelegramChatID when both are set
+	TelegramToken  string
+	TelegramChatID string
+
+	// NotifyProgress sends a low-priority notification at each processing
+	// stage of a job
+	NotifyProgress bool
+
+	// Notify*Title and Notify*Body are text/template overrides for job
+	// notifications, executed with the job
+	NotifyStartTitle   string
+	NotifyStartBody    string
+	NotifySuccessTitle string
+	NotifySuccessBody  string
+	NotifyFailureTitle string
+	NotifyFailureBody  string
+	NotifySkippedTitle string
+	NotifySkippedBody  string
+
+	// ArticleOnly disables the video and audio pipeline, so no external
+	// programs (yt-dlp, ffmpeg, whisper) are needed
+	ArticleOnly bool
+
+	// YouTubeCaptions uses existing captions instead of Whisper when available
+	YouTubeCaptions bool
+
+	// YouTubeChapters structures video summaries by chapter, linking the
+	// timestamps they cite back into the video
+	YouTubeChapters bool
+
+	// SponsorBlock lists the SponsorBlock categories removed from YouTube
+	// videos before transcription, comma-separated; empty disables it
+	SponsorBlock string
+
+	// YtDlpCookies is a cookies.txt file and YtDlpArgs extra space-separated
+	// arguments for yt-dlp, for age-restricted, members-only, or
+	// region-locked videos
+	YtDlpCookies string
+	YtDlpArgs    string
+
+	// Proxy is the HTTP or SOCKS5 proxy for outbound requests, see Proxy
+	Proxy string
+
+	// VideoProbe asks yt-dlp whether article URLs are videos it can download
+	VideoProbe bool
+
+	// PlaylistMax is the number of videos queued from a YouTube playlist,
+	// 0 for all of them
+	PlaylistMax int
+
+	// YtDlpMaxAgeDays is the yt-dlp release age in days above which a
+	// warning is logged at startup, 0 disables the check
+	YtDlpMaxAgeDays int
+
+	// MaxLLMRequests bounds simultaneous LLM calls, 0 means one per worker
+	MaxLLMRequests int
+
+	// LLMRequestsPerMinute and LLMTokensPerMinute pace LLM calls below the
+	// provider rate limits, 0 means no limit
+	LLMRequestsPerMinute int
+	LLMTokensPerMinute   int
+
+	// SummaryDepth is "auto" to scale with source length, or a fixed depth
+	SummaryDepth    string
+	DepthThresholds string
+
+	// LanguagePolicy is "source", "translate", or "bilingual"; translated
+	// summaries are written in SummaryLanguage
+	LanguagePolicy  string
+	SummaryLanguage string
+
+	// Persona is the default summary persona preset, PersonaDir holds
+	// additional "<name>.txt" presets
+	Persona    string
+	PersonaDir string
+
+	// SummaryStyle is the default summary style: tldr, bullets, detailed,
+	// eli5, or executive; empty uses the prompt of each content type
+	SummaryStyle string
+
+	// OutputFormat is the default format of summary files: markdown, json,
+	// html, or text
+	OutputFormat string
+
+	// TriageThreshold inputs arriving within TriageWindowSeconds of each
+	// other are announced together, and held for confirmation with
+	// TriageConfirm
+	TriageThreshold     int
+	TriageWindowSeconds int
+	TriageConfirm       bool
+
+	// WatchBatchSize files are queued per second during mass syncs, with a
+	// warning once more than WatchPendingLimit files are waiting
+	WatchBatchSize    int
+	WatchPendingLimit int
+
+	// MaxInputTokens is the context window of the models, 0 for the known
+	// window of each model; content exceeding it is chunked or truncated
+	// according to ContextOverflow
+	MaxInputTokens  int
+	ContextOverflow string
+
+	// SummaryCacheDays keeps summaries by content hash for that many days,
+	// 0 disables the cache
+	SummaryCacheDays int
+
+	// Content longer than ChunkSize characters is summarized chunk by chunk
+	ChunkSize    int
+	ChunkOverlap int
+
+	// SectionMinLength is the content length from which articles with their
+	// own headings are summarized section by section, 0 disables it
+	SectionMinLength int
+
+	// OutputTemplate is a text/template replacing the default summary file
+	// layout, given inline or read from OutputTemplateFile
+	OutputTemplate     string
+	OutputTemplateFile string
+
+	// Redact replaces emails, phone numbers, and API keys in content sent
+	// to cloud providers
+	Redact bool
+
+	// LintOutput normalizes the generated markdown before it is saved
+	LintOutput bool
+
+	// FrontMatter starts summaries with Obsidian-compatible YAML properties
+	FrontMatter bool
+
+	// SaveTranscript writes the extracted text or transcript of each input
+	// next to its summary, as "<name>.transcript.md"
+	SaveTranscript bool
+
+	// ArchiveArticles writes an offline Markdown copy of each article next
+	// to its summary, as "<name>.archive.md"
+	ArchiveArticles bool
+
+	// URLDedup skips URLs that were already summarized while their summary
+	// exists
+	URLDedup bool
+
+	// DeadLetter moves permanently failed jobs out of the queue, and their
+	// input files into the "failed" directory of the inbox
+	DeadLetter bool
+
+	// History keeps the final state of every job in the output directory
+	History bool
+
+	// Receipts writes "<name>.done" or "<name>.failed" files to the watch
+	// directory once an input is processed
+	Receipts bool
+
+	// FeedSubfolders writes summaries of feed-originated jobs to a
+	// subfolder named after the feed
+	FeedSubfolders bool
+
+	// OutputLayout organizes summaries in dated subfolders, with
+	// strftime-like directives such as "%Y/%m"; empty writes them flat
+	OutputLayout string
+
+	// SummaryIndex maintains an index of all summaries in the output
+	// directory: "markdown" (index.md), "json" (index.json), or "both"
+	SummaryIndex string
+
+	// RetentionDays is the age in days after which summaries are compressed
+	// or deleted, per RetentionAction; HistoryRetentionDays prunes the job
+	// history, and OutputMaxSizeMB caps the size of the output directory.
+	// Zero keeps everything.
+	RetentionDays        int
+	RetentionAction      string
+	HistoryRetentionDays int
+	OutputMaxSizeMB      int
+
+	// GenerateTitles names untitled inputs after an LLM-generated title
+	GenerateTitles bool
+
+	// GenerateTags adds LLM-generated topic tags to the front matter
+	GenerateTags bool
+
+	// EmbeddingsProvider (openai, gemini, or ollama) embeds summaries for
+	// semantic search with EmbeddingsModel; empty disables search
+	EmbeddingsProvider string
+	EmbeddingsModel    string
+
+	// RegenerateOnDelete re-enqueues the source of summaries deleted from OutputDir
+	RegenerateOnDelete bool
+
+	// IMAP mailbox polled for newsletters and links; disabled when IMAPAddr
+	// is empty. Processed messages are marked read and moved to IMAPMoveTo,
+	// if set. IMAPMode is "auto", "links", or "text".
+	IMAPAddr            string
+	IMAPTLS             bool
+	IMAPUsername        string
+	IMAPPassword        string
+	IMAPMailbox         string
+	IMAPMoveTo          string
+	IMAPMode            string
+	IMAPIntervalSeconds int
+	// IMAPAllowedSenders lists the addresses and domains whose messages are
+	// processed, comma separated; empty allows every sender
+	IMAPAllowedSenders string
+
+	// Pocket items saved with PocketTag are queued; disabled without an
+	// access token. PocketArchive archives items once summarized.
+	PocketConsumerKey     string
+	PocketAccessToken     string
+	PocketTag             string
+	PocketArchive         bool
+	PocketIntervalSeconds int
+
+	// Wallabag entries are queued, only those tagged WallabagTag when set;
+	// disabled when WallabagURL is empty. WallabagAnnotate adds summaries
+	// back to their entry.
+	WallabagURL             string
+	WallabagClientID        string
+	WallabagClientSecret    string
+	WallabagUsername        string
+	WallabagPassword        string
+	WallabagTag             string
+	WallabagAnnotate        bool
+	WallabagIntervalSeconds int
+
+	// Readwise Reader documents tagged ReadwiseTag are queued; disabled
+	// without a token. ReadwiseHighlight saves summaries back as highlights.
+	ReadwiseToken           string
+	ReadwiseTag             string
+	ReadwiseHighlight       bool
+	ReadwiseIntervalSeconds int
+
+	// New uploads of YouTubeChannels, comma-separated channel IDs, handles,
+	// or URLs each optionally followed by "=<title regexp>", are queued
+	YouTubeChannels                string
+	YouTubeChannelsIntervalSeconds int
+
+	// Low-rated summaries are regenerated with RegenerateModel ("provider:model")
+	RegenerateBelow int
+	RegenerateModel string
+
+	// Budget limits, either a single value or "provider=value,..." lists
+	BudgetDailyUSD      string
+	BudgetMonthlyUSD    string
+	BudgetDailyTokens   string
+	BudgetMonthlyTokens string
 }
 
 func Load() *Config {
@@ -22,29 +337,308 @@ func Load() *Config {
 
 	// Set default model based on provider if not specified
 	if model == "" {
-		switch provider {
-		case "claude":
-			model = "claude-3-7-sonnet-latest"
-		case "gemini":
-			model = "gemini-2.5-flash"
-		}
+		model = DefaultModel(provider)
+	}
+
+	// Choosing a summary language means summaries are written in it, unless
+	// a policy says otherwise
+	languagePolicy := "source"
+	if lookup("BRIEFLY_SUMMARY_LANGUAGE") != "" {
+		languagePolicy = "translate"
 	}
 
 	return &Config{
-		WatchDir:     getEnv("BRIEFLY_WATCH_DIR", "/data/inbox"),
-		OutputDir:    getEnv("BRIEFLY_OUTPUT_DIR", "/data/output"),
+		WatchDir:     getEnv("BRIEFLY_WATCH_DIR", filepath.Join(DataDir(), "inbox")),
+		OutputDir:    getEnv("BRIEFLY_OUTPUT_DIR", filepath.Join(DataDir(), "output")),
 		LLMProvider:  provider,
 		LLMModel:     model,
 		AnthropicKey: getEnv("ANTHROPIC_API_KEY", ""),
 		GoogleKey:    getEnv("GOOGLE_API_KEY", ""),
+		OpenAIKey:    getEnv("OPENAI_API_KEY", ""),
+		OpenAIURL:    getEnv("BRIEFLY_OPENAI_BASE_URL", ""),
+		OllamaURL:    getEnv("BRIEFLY_OLLAMA_URL", "http://localhost:11434/v1"),
+		LLMFallback:  getEnv("BRIEFLY_LLM_FALLBACK", ""),
 		NtfyTopic:    getEnv("BRIEFLY_NTFY_TOPIC", ""),
 		WhisperModel: getEnv("BRIEFLY_WHISPER_MODEL", "base"),
+		TempDir:      getEnv("BRIEFLY_TMP_DIR", os.TempDir()),
+		HTTPAddr:     getEnv("BRIEFLY_HTTP_ADDR", ""),
+		PublicURL:    getEnv("BRIEFLY_PUBLIC_URL", ""),
+		HTTPToken:    getEnv("BRIEFLY_HTTP_TOKEN", ""),
+		Workers:      getEnvInt("BRIEFLY_WORKERS", 1),
+		ReplicaID:    getEnv("BRIEFLY_REPLICA_ID", hostname()),
+		LeaseSeconds: getEnvInt("BRIEFLY_LEASE_SECONDS", 0),
+
+		MaxRetries:             getEnvInt("BRIEFLY_MAX_RETRIES", 3),
+		RetryBackoffSeconds:    getEnvInt("BRIEFLY_RETRY_BACKOFF_SECONDS", 5),
+		RetryMaxBackoffSeconds: getEnvInt("BRIEFLY_RETRY_MAX_BACKOFF_SECONDS", 300),
+		RetryJitterPercent:     getEnvInt("BRIEFLY_RETRY_JITTER_PERCENT", 20),
+		RetryPolicy:            getEnv("BRIEFLY_RETRY_POLICY", "provider_rejected=never"),
+
+		DownloadTimeoutMinutes:      getEnvInt("BRIEFLY_DOWNLOAD_TIMEOUT_MINUTES", 30),
+		TranscriptionTimeoutMinutes: getEnvInt("BRIEFLY_TRANSCRIPTION_TIMEOUT_MINUTES", 60),
+		ExtractionTimeoutMinutes:    getEnvInt("BRIEFLY_EXTRACTION_TIMEOUT_MINUTES", 5),
+		SummarizationTimeoutMinutes: getEnvInt("BRIEFLY_SUMMARIZATION_TIMEOUT_MINUTES", 10),
+
+		TelegramToken:  getEnv("BRIEFLY_TELEGRAM_TOKEN", ""),
+		TelegramChatID: getEnv("BRIEFLY_TELEGRAM_CHAT_ID", ""),
+
+		NotifyProgress: getEnvBool("BRIEFLY_NOTIFY_PROGRESS", false),
+
+		NotifyStartTitle:   getEnv("BRIEFLY_NOTIFY_START_TITLE", ""),
+		NotifyStartBody:    getEnv("BRIEFLY_NOTIFY_START_BODY", ""),
+		NotifySuccessTitle: getEnv("BRIEFLY_NOTIFY_SUCCESS_TITLE", ""),
+		NotifySuccessBody:  getEnv("BRIEFLY_NOTIFY_SUCCESS_BODY", ""),
+		NotifyFailureTitle: getEnv("BRIEFLY_NOTIFY_FAILURE_TITLE", ""),
+		NotifyFailureBody:  getEnv("BRIEFLY_NOTIFY_FAILURE_BODY", ""),
+		NotifySkippedTitle: getEnv("BRIEFLY_NOTIFY_SKIPPED_TITLE", ""),
+		NotifySkippedBody:  getEnv("BRIEFLY_NOTIFY_SKIPPED_BODY", ""),
+
+		ArticleOnly: getEnvBool("BRIEFLY_ARTICLE_ONLY", false),
+
+		YouTubeCaptions: getEnvBool("BRIEFLY_YOUTUBE_CAPTIONS", false),
+		YouTubeChapters: getEnvBool("BRIEFLY_YOUTUBE_CHAPTERS", true),
+		SponsorBlock:    getEnv("BRIEFLY_SPONSORBLOCK", ""),
+		YtDlpCookies:    getEnv("BRIEFLY_YTDLP_COOKIES", ""),
+		YtDlpArgs:       getEnv("BRIEFLY_YTDLP_ARGS", ""),
+		Proxy:           getEnv("BRIEFLY_PROXY", ""),
+		VideoProbe:      getEnvBool("BRIEFLY_VIDEO_PROBE", true),
+		PlaylistMax:     getEnvInt("BRIEFLY_PLAYLIST_MAX", 25),
+		YtDlpMaxAgeDays: getEnvInt("BRIEFLY_YTDLP_MAX_AGE_DAYS", 90),
+
+		Transcriber:      getEnv("BRIEFLY_TRANSCRIBER", "local"),
+		TranscriberURL:   getEnv("BRIEFLY_TRANSCRIBER_URL", ""),
+		TranscriberKey:   getEnv("BRIEFLY_TRANSCRIBER_API_KEY", getEnv("OPENAI_API_KEY", "")),
+		TranscriberModel: getEnv("BRIEFLY_TRANSCRIBER_MODEL", "whisper-1"),
+
+		TranscriptionLanguage: getEnv("BRIEFLY_TRANSCRIPTION_LANGUAGE", ""),
+
+		VisionImages: getEnvInt("BRIEFLY_VISION_IMAGES", 0),
+
+		XBearerToken:    getEnv("BRIEFLY_X_BEARER_TOKEN", ""),
+		NitterInstances: getEnv("BRIEFLY_NITTER_INSTANCES", "nitter.net"),
+
+		RedditComments: getEnvInt("BRIEFLY_REDDIT_COMMENTS", 20),
+
+		GitHubToken: getEnv("BRIEFLY_GITHUB_TOKEN", getEnv("GITHUB_TOKEN", "")),
+
+		MaxLLMRequests:       getEnvInt("BRIEFLY_MAX_LLM_REQUESTS", 0),
+		LLMRequestsPerMinute: getEnvInt("BRIEFLY_LLM_REQUESTS_PER_MINUTE", 0),
+		LLMTokensPerMinute:   getEnvInt("BRIEFLY_LLM_TOKENS_PER_MINUTE", 0),
+
+		SummaryDepth:    strings.ToLower(getEnv("BRIEFLY_SUMMARY_DEPTH", "auto")),
+		DepthThresholds: getEnv("BRIEFLY_DEPTH_THRESHOLDS", ""),
+
+		LanguagePolicy:  strings.ToLower(getEnv("BRIEFLY_LANGUAGE_POLICY", languagePolicy)),
+		SummaryLanguage: strings.ToLower(getEnv("BRIEFLY_SUMMARY_LANGUAGE", "en")),
+
+		Persona:    getEnv("BRIEFLY_PERSONA", ""),
+		PersonaDir: getEnv("BRIEFLY_PERSONA_DIR", ""),
+
+		SummaryStyle: getEnv("BRIEFLY_SUMMARY_STYLE", ""),
+
+		OutputFormat: strings.ToLower(getEnv("BRIEFLY_OUTPUT_FORMAT", "markdown")),
+
+		TriageThreshold:     getEnvInt("BRIEFLY_TRIAGE_THRESHOLD", 5),
+		TriageWindowSeconds: getEnvInt("BRIEFLY_TRIAGE_WINDOW_SECONDS", 10),
+		TriageConfirm:       getEnvBool("BRIEFLY_TRIAGE_CONFIRM", false),
+
+		WatchBatchSize:    getEnvInt("BRIEFLY_WATCH_BATCH_SIZE", 20),
+		WatchPendingLimit: getEnvInt("BRIEFLY_WATCH_PENDING_LIMIT", 100),
+
+		MaxInputTokens:  getEnvInt("BRIEFLY_MAX_INPUT_TOKENS", 0),
+		ContextOverflow: getEnv("BRIEFLY_CONTEXT_OVERFLOW", "chunk"),
+
+		SummaryCacheDays: getEnvInt("BRIEFLY_SUMMARY_CACHE_DAYS", 30),
+
+		ChunkSize:    getEnvInt("BRIEFLY_CHUNK_SIZE", 100000),
+		ChunkOverlap: getEnvInt("BRIEFLY_CHUNK_OVERLAP", 2000),
+
+		SectionMinLength: getEnvInt("BRIEFLY_SECTION_MIN_LENGTH", 30000),
+
+		OutputTemplate:     getEnv("BRIEFLY_OUTPUT_TEMPLATE", ""),
+		OutputTemplateFile: getEnv("BRIEFLY_OUTPUT_TEMPLATE_FILE", ""),
+
+		Redact: getEnvBool("BRIEFLY_REDACT", false),
+
+		FrontMatter: getEnvBool("BRIEFLY_FRONT_MATTER", false),
+
+		SaveTranscript: getEnvBool("BRIEFLY_SAVE_TRANSCRIPT", false),
+
+		ArchiveArticles: getEnvBool("BRIEFLY_ARCHIVE_ARTICLES", false),
+
+		LintOutput: getEnvBool("BRIEFLY_LINT_OUTPUT", true),
+
+		Receipts: getEnvBool("BRIEFLY_RECEIPTS", false),
+
+		History: getEnvBool("BRIEFLY_HISTORY", true),
+
+		DeadLetter: getEnvBool("BRIEFLY_DEAD_LETTER", true),
+
+		URLDedup: getEnvBool("BRIEFLY_URL_DEDUP", true),
+
+		FeedSubfolders: getEnvBool("BRIEFLY_FEED_SUBFOLDERS", false),
+
+		OutputLayout: getEnv("BRIEFLY_OUTPUT_LAYOUT", ""),
+
+		SummaryIndex: strings.ToLower(getEnv("BRIEFLY_SUMMARY_INDEX", "")),
+
+		RetentionDays:        getEnvInt("BRIEFLY_RETENTION_DAYS", 0),
+		RetentionAction:      strings.ToLower(getEnv("BRIEFLY_RETENTION_ACTION", "compress")),
+		HistoryRetentionDays: getEnvInt("BRIEFLY_HISTORY_RETENTION_DAYS", 0),
+		OutputMaxSizeMB:      getEnvInt("BRIEFLY_OUTPUT_MAX_SIZE_MB", 0),
+
+		GenerateTitles: getEnvBool("BRIEFLY_GENERATE_TITLES", true),
+		GenerateTags:   getEnvBool("BRIEFLY_GENERATE_TAGS", true),
+
+		EmbeddingsProvider: strings.ToLower(getEnv("BRIEFLY_EMBEDDINGS_PROVIDER", "")),
+		EmbeddingsModel:    getEnv("BRIEFLY_EMBEDDINGS_MODEL", ""),
+
+		RegenerateOnDelete: getEnvBool("BRIEFLY_REGENERATE_ON_DELETE", false),
+
+		IMAPAddr:            getEnv("BRIEFLY_IMAP_ADDR", ""),
+		IMAPTLS:             getEnvBool("BRIEFLY_IMAP_TLS", true),
+		IMAPUsername:        getEnv("BRIEFLY_IMAP_USERNAME", ""),
+		IMAPPassword:        getEnv("BRIEFLY_IMAP_PASSWORD", ""),
+		IMAPMailbox:         getEnv("BRIEFLY_IMAP_MAILBOX", "INBOX"),
+		IMAPMoveTo:          getEnv("BRIEFLY_IMAP_MOVE_TO", ""),
+		IMAPMode:            getEnv("BRIEFLY_IMAP_MODE", "auto"),
+		IMAPIntervalSeconds: getEnvInt("BRIEFLY_IMAP_INTERVAL_SECONDS", 300),
+		IMAPAllowedSenders:  getEnv("BRIEFLY_IMAP_ALLOWED_SENDERS", ""),
+
+		PocketConsumerKey:     getEnv("BRIEFLY_POCKET_CONSUMER_KEY", ""),
+		PocketAccessToken:     getEnv("BRIEFLY_POCKET_ACCESS_TOKEN", ""),
+		PocketTag:             getEnv("BRIEFLY_POCKET_TAG", "briefly"),
+		PocketArchive:         getEnvBool("BRIEFLY_POCKET_ARCHIVE", false),
+		PocketIntervalSeconds: getEnvInt("BRIEFLY_POCKET_INTERVAL_SECONDS", 300),
+
+		WallabagURL:             getEnv("BRIEFLY_WALLABAG_URL", ""),
+		WallabagClientID:        getEnv("BRIEFLY_WALLABAG_CLIENT_ID", ""),
+		WallabagClientSecret:    getEnv("BRIEFLY_WALLABAG_CLIENT_SECRET", ""),
+		WallabagUsername:        getEnv("BRIEFLY_WALLABAG_USERNAME", ""),
+		WallabagPassword:        getEnv("BRIEFLY_WALLABAG_PASSWORD", ""),
+		WallabagTag:             getEnv("BRIEFLY_WALLABAG_TAG", ""),
+		WallabagAnnotate:        getEnvBool("BRIEFLY_WALLABAG_ANNOTATE", false),
+		WallabagIntervalSeconds: getEnvInt("BRIEFLY_WALLABAG_INTERVAL_SECONDS", 300),
+
+		ReadwiseToken:           getEnv("BRIEFLY_READWISE_TOKEN", ""),
+		ReadwiseTag:             getEnv("BRIEFLY_READWISE_TAG", "briefly"),
+		ReadwiseHighlight:       getEnvBool("BRIEFLY_READWISE_HIGHLIGHT", false),
+		ReadwiseIntervalSeconds: getEnvInt("BRIEFLY_READWISE_INTERVAL_SECONDS", 300),
+
+		YouTubeChannels:                getEnv("BRIEFLY_YOUTUBE_CHANNELS", ""),
+		YouTubeChannelsIntervalSeconds: getEnvInt("BRIEFLY_YOUTUBE_CHANNELS_INTERVAL_SECONDS", 900),
+
+		RegenerateBelow: getEnvInt("BRIEFLY_REGENERATE_BELOW", 0),
+		RegenerateModel: getEnv("BRIEFLY_REGENERATE_MODEL", ""),
+
+		BudgetDailyUSD:      getEnv("BRIEFLY_BUDGET_DAILY_USD", ""),
+		BudgetMonthlyUSD:    getEnv("BRIEFLY_BUDGET_MONTHLY_USD", ""),
+		BudgetDailyTokens:   getEnv("BRIEFLY_BUDGET_DAILY_TOKENS", ""),
+		BudgetMonthlyTokens: getEnv("BRIEFLY_BUDGET_MONTHLY_TOKENS", ""),
+	}
+}
+
+// Logging returns the log format ("text" or "json") and level, read before
+// the rest of the configuration so that loading it is logged as configured
+func Logging() (format, level string) {
+	return getEnv("BRIEFLY_LOG_FORMAT", "text"), getEnv("BRIEFLY_LOG_LEVEL", "info")
+}
+
+// Proxy returns the proxy URL for outbound requests and the comma-separated
+// hosts that bypass it, read before the rest of the configuration so that
+// every command uses them
+func Proxy() (proxy, noProxy string) {
+	return getEnv("BRIEFLY_PROXY", ""), getEnv("BRIEFLY_NO_PROXY", getEnv("NO_PROXY", ""))
+}
+
+// DefaultModel returns the model used for provider when none is configured
+func DefaultModel(provider string) string {
+	switch provider {
+	case "claude":
+		return "claude-3-7-sonnet-latest"
+	case "gemini":
+		return "gemini-2.5-flash"
+	case "openai":
+		return "gpt-4o-mini"
+	case "ollama":
+		return "llama3.2"
 	}
+	return ""
+}
+
+// CheckProvider returns an error when provider is not one Briefly can
+// summarize with
+func CheckProvider(provider string) error {
+	if DefaultModel(provider) == "" {
+		return fmt.Errorf("unknown provider %q, expected claude, gemini, openai, or ollama", provider)
+	}
+	return nil
+}
+
+// SplitModel returns the provider and model chosen for a single input,
+// whose model may also be given as "provider:model". Model names such as
+// Ollama's "llama3:8b" contain a colon too, so only a known provider prefix
+// is split off. An empty provider means the configured one.
+func SplitModel(provider, model string) (string, string) {
+	provider = strings.ToLower(provider)
+	if provider == "" {
+		if prefix, rest, ok := strings.Cut(model, ":"); ok && DefaultModel(strings.ToLower(prefix)) != "" {
+			return strings.ToLower(prefix), rest
+		}
+	}
+	return provider, model
+}
+
+// DataDir is the default parent of the watch and output directories: /data,
+// where the container mounts them, or Briefly in the application data
+// folder (%APPDATA%) on Windows
+func DataDir() string {
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "Briefly")
+		}
+	}
+	return "/data"
+}
+
+// hostname is the default replica ID, the pod name in Kubernetes
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "briefly"
+	}
+	return name
 }
 
 func getEnv(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
+	if val := lookup(key); val != "" {
 		return val
 	}
 	return defaultVal
 }
+
+func getEnvInt(key string, defaultVal int) int {
+	val := lookup(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		slog.Warn("Invalid integer setting, using default", "key", key, "value", val, "default", defaultVal)
+		return defaultVal
+	}
+	return n
+}
+
+func getEnvBool(key string, defaultVal bool) bool {
+	val := lookup(key)
+	if val == "" {
+		return defaultVal
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		slog.Warn("Invalid boolean setting, using default", "key", key, "value", val, "default", defaultVal)
+		return defaultVal
+	}
+	return b
+}
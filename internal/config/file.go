@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileValues holds the settings read from the config file, keyed by their
+// environment variable name without the BRIEFLY_ prefix
+var fileValues map[string]string
+
+// recorded collects the settings looked up while knownKeys runs
+var recorded map[string]bool
+
+// LoadFile reads settings from a YAML or TOML file (by extension). Keys are
+// the environment variable names in lower case, with or without the
+// "briefly_" prefix (watch_dir, llm_provider, anthropic_api_key, ...).
+// Environment variables take precedence over the file. Unknown keys, such
+// as misspelled ones, are rejected.
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml, or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	known := knownKeys()
+	var unknown []string
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		name := strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(key, "-", "_")), "BRIEFLY_")
+		if !known[name] {
+			unknown = append(unknown, key)
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []any:
+			// Lists such as llm_fallback are comma separated in env vars
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			return fmt.Errorf("invalid config file %s: %s must be a single value", path, key)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("invalid config file %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	fileValues = values
+	return nil
+}
+
+// knownKeys returns the names of all settings, without the BRIEFLY_ prefix,
+// by loading the configuration while recording what it looks up
+func knownKeys() map[string]bool {
+	recorded = make(map[string]bool)
+	defer func() { recorded = nil }()
+	Load()
+	Logging()
+	Proxy()
+	return recorded
+}
+
+// lookup returns the value of key from the environment, falling back to the
+// config file
+func lookup(key string) string {
+	if recorded != nil {
+		// Values are left out, so invalid ones are not reported twice
+		recorded[strings.TrimPrefix(key, "BRIEFLY_")] = true
+		return ""
+	}
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fileValues[strings.TrimPrefix(key, "BRIEFLY_")]
+}
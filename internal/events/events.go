@@ -0,0 +1,104 @@
+package events
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeStarted   Type = "started"
+	TypeStage     Type = "stage"
+	TypeRetry     Type = "retry"
+	TypeCompleted Type = "completed"
+	TypeSkipped   Type = "skipped"
+	TypeFailed    Type = "failed"
+)
+
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     Type      `json:"type"`
+	JobID    string    `json:"job_id,omitempty"`
+	Filename string    `json:"filename,omitempty"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// Log keeps a bounded ring of recent job events in memory and on disk
+type Log struct {
+	mu          sync.Mutex
+	events      []Event
+	size        int
+	persistPath string
+}
+
+func New(size int, persistPath string) *Log {
+	l := &Log{size: size, persistPath: persistPath}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		json.Unmarshal(data, &l.events)
+		l.trim()
+	}
+	return l
+}
+
+// Record appends an event, dropping the oldest ones beyond the ring size
+func (l *Log) Record(eventType Type, jobID, filename, message string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, Event{
+		Time:     time.Now(),
+		Type:     eventType,
+		JobID:    jobID,
+		Filename: filename,
+		Message:  message,
+	})
+	l.trim()
+
+	if err := l.persist(); err != nil {
+		slog.Warn("Failed to persist events", "error", err)
+	}
+}
+
+// Recent returns up to n events, newest first
+func (l *Log) Recent(n int) []Event {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 || n > len(l.events) {
+		n = len(l.events)
+	}
+	recent := make([]Event, 0, n)
+	for i := len(l.events) - 1; i >= len(l.events)-n; i-- {
+		recent = append(recent, l.events[i])
+	}
+	return recent
+}
+
+func (l *Log) trim() {
+	if len(l.events) > l.size {
+		l.events = append([]Event(nil), l.events[len(l.events)-l.size:]...)
+	}
+}
+
+func (l *Log) persist() error {
+	if l.persistPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.events, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.persistPath, data, 0644)
+}
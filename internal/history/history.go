@@ -0,0 +1,233 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Entry is the final state of one job
+type Entry struct {
+	ID          string             `json:"id"`
+	Filename    string             `json:"filename"`
+	URL         string             `json:"url,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Feed        string             `json:"feed,omitempty"`
+	ContentType models.ContentType `json:"content_type"`
+	Status      models.JobStatus   `json:"status"`
+	ErrorCode   models.ErrorCode   `json:"error_code,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Provider    string             `json:"provider,omitempty"`
+	Model       string             `json:"model,omitempty"`
+	OutputPath  string             `json:"output_path,omitempty"`
+	Retries     int                `json:"retries"`
+	CreatedAt   time.Time          `json:"created_at"`
+	StartedAt   time.Time          `json:"started_at,omitzero"`
+	FinishedAt  time.Time          `json:"finished_at"`
+
+	// Tags are the topics of the summary
+	Tags []string `json:"tags,omitempty"`
+
+	// InputTokens, OutputTokens, and CostUSD are the LLM usage of the job
+	InputTokens  int64   `json:"input_tokens,omitempty"`
+	OutputTokens int64   `json:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
+}
+
+// Duration is the time from the first processing attempt to the final state
+func (e Entry) Duration() time.Duration {
+	if e.StartedAt.IsZero() {
+		return 0
+	}
+	return e.FinishedAt.Sub(e.StartedAt)
+}
+
+// Source describes where the job content came from
+func (e Entry) Source() string {
+	if e.ContentType == models.ContentTypeMedia {
+		return "media file " + e.Filename
+	}
+	if e.URL == "" {
+		return "direct text"
+	}
+	return e.URL
+}
+
+// Filter selects history entries; zero fields match everything
+type Filter struct {
+	// ID matches the entries of one job
+	ID        string
+	Status    models.JobStatus
+	ErrorCode models.ErrorCode
+	Since     time.Time
+	// Search matches the URL, title, or file name, ignoring case
+	Search string
+}
+
+func (f Filter) match(e Entry) bool {
+	if f.ID != "" && e.ID != f.ID {
+		return false
+	}
+	if f.Status != "" && e.Status != f.Status {
+		return false
+	}
+	if f.ErrorCode != "" && e.ErrorCode != f.ErrorCode {
+		return false
+	}
+	if !f.Since.IsZero() && e.FinishedAt.Before(f.Since) {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(e.URL+"\n"+e.Title+"\n"+e.Filename), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store appends finished jobs to a JSON-lines file
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// Record appends the final state of job
+func (s *Store) Record(job *models.Job) error {
+	if s == nil {
+		return nil
+	}
+
+	// Costs are charged to the provider that served the job
+	entry := Entry{
+		ID:          job.ID,
+		Filename:    job.Filename,
+		URL:         job.URL,
+		Title:       job.Title,
+		Feed:        job.Feed,
+		ContentType: job.ContentType,
+		Status:      job.Status,
+		ErrorCode:   job.ErrorCode,
+		Error:       job.Error,
+		OutputPath:  job.OutputPath,
+		Retries:     job.Retries,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.UpdatedAt,
+
+		Tags: job.Tags,
+
+		InputTokens:  job.InputTokens,
+		OutputTokens: job.OutputTokens,
+		CostUSD:      job.CostUSD,
+	}
+	entry.Provider, entry.Model = job.ServedBy()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching filter, oldest first. A missing
+// history file is an empty history.
+func (s *Store) Query(filter Filter) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		// A line cut short by a crash is skipped rather than failing the query
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if filter.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Prune removes the entries of jobs finished before cutoff and returns
+// their number
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var kept []byte
+	pruned := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			// Lines cut short by a crash go with the pruned ones
+			pruned++
+			continue
+		}
+		if e.FinishedAt.Before(cutoff) {
+			pruned++
+			continue
+		}
+		kept = append(kept, line+"\n"...)
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, kept, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write history: %w", err)
+	}
+	return pruned, nil
+}
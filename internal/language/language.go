@@ -0,0 +1,113 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords holds frequent short words that are distinctive for each language
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "in", "that", "it", "with", "for", "was", "this", "are", "you", "have"},
+	"it": {"il", "che", "di", "la", "non", "per", "una", "sono", "del", "della", "con", "gli", "anche", "come", "questo"},
+	"es": {"el", "que", "de", "los", "las", "por", "una", "con", "para", "del", "es", "como", "pero", "más", "esta"},
+	"fr": {"le", "les", "des", "est", "que", "une", "pour", "dans", "qui", "pas", "sur", "avec", "sont", "du", "cette"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "mit", "den", "sich", "auch", "auf", "für", "eine", "wir"},
+	"pt": {"que", "não", "uma", "os", "para", "com", "do", "da", "em", "são", "mais", "como", "mas", "foi", "isso"},
+	"nl": {"de", "het", "een", "van", "en", "niet", "dat", "ik", "zijn", "voor", "met", "ook", "maar", "wordt", "deze"},
+}
+
+var names = map[string]string{
+	"en": "English",
+	"it": "Italian",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"nl": "Dutch",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+	"ru": "Russian",
+	"pl": "Polish",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"ar": "Arabic",
+}
+
+// sampleWords bounds how much text is inspected
+const sampleWords = 2000
+
+// Detect guesses the ISO 639-1 code of text using stopword frequencies.
+// It returns an empty string when the text is too short or ambiguous.
+func Detect(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > sampleWords {
+		words = words[:sampleWords]
+	}
+	if len(words) < 20 {
+		return ""
+	}
+
+	sets := make(map[string]map[string]bool, len(stopwords))
+	for lang, list := range stopwords {
+		set := make(map[string]bool, len(list))
+		for _, w := range list {
+			set[w] = true
+		}
+		sets[lang] = set
+	}
+
+	scores := make(map[string]int)
+	for _, w := range words {
+		for lang, set := range sets {
+			if set[w] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, second := "", 0
+	for lang, score := range scores {
+		if best == "" || score > scores[best] || (score == scores[best] && lang < best) {
+			if best != "" {
+				second = max(second, scores[best])
+			}
+			best = lang
+		} else {
+			second = max(second, score)
+		}
+	}
+
+	// Require a clear winner with a meaningful share of stopwords
+	if best == "" || scores[best]*20 < len(words) || scores[best] <= second {
+		return ""
+	}
+	return best
+}
+
+// Name returns the English name of an ISO 639-1 code, or the code itself
+func Name(code string) string {
+	if name, ok := names[strings.ToLower(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// Code returns the ISO 639-1 code of a language given by its code or its
+// English name, such as the "italian" reported by transcription APIs, or an
+// empty string for names it does not know
+func Code(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 2 {
+		return name
+	}
+	for code, n := range names {
+		if strings.ToLower(n) == name {
+			return code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+// Package logging configures the process-wide slog logger
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup installs the default slog logger writing to stderr. format is
+// "text" or "json", level one of debug, info, warn, or error. Messages from
+// the standard log package go through the same handler.
+func Setup(format, level string) error {
+	return SetupWriter(os.Stderr, format, level)
+}
+
+// SetupWriter is Setup writing to w, for services without a console
+func SetupWriter(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return fmt.Errorf("invalid log level %q, expected debug, info, warn, or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(0)
+	return nil
+}
+
+// Fatal logs msg as an error and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,209 @@
+package mailbox
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client speaks the small part of IMAP4rev1 (RFC 3501) needed to fetch and
+// file messages: login, select, search, fetch, store, and move
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+
+	// caps are the capabilities the server announced after login
+	caps map[string]bool
+}
+
+// response is an untagged server response with the literals it carried
+type response struct {
+	line     string
+	literals [][]byte
+}
+
+func dial(ctx context.Context, addr string, useTLS bool) (*client, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c := &client{conn: conn, r: bufio.NewReader(conn)}
+	greeting, _, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting)
+	}
+	return c, nil
+}
+
+func (c *client) Close() error {
+	c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// command sends a command and returns its untagged responses, failing
+// unless the server completes it with OK
+func (c *client) command(format string, args ...any) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("b%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []response
+	for {
+		line, literals, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(rest, "OK") {
+				verb, _, _ := strings.Cut(format, " ")
+				return nil, fmt.Errorf("IMAP %s failed: %s", verb, rest)
+			}
+			return responses, nil
+		}
+		if strings.HasPrefix(line, "* ") {
+			responses = append(responses, response{line: line[2:], literals: literals})
+		}
+	}
+}
+
+// readLine reads a response line, including the literals ("{n}" followed by
+// n bytes) it contains, which are replaced by "{}" in the returned line
+func (c *client) readLine() (string, [][]byte, error) {
+	var b strings.Builder
+	var literals [][]byte
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read IMAP response: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		open := strings.LastIndexByte(line, '{')
+		if open < 0 || !strings.HasSuffix(line, "}") {
+			b.WriteString(line)
+			return b.String(), literals, nil
+		}
+		n, err := strconv.Atoi(line[open+1 : len(line)-1])
+		if err != nil {
+			b.WriteString(line)
+			return b.String(), literals, nil
+		}
+		literal := make([]byte, n)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return "", nil, fmt.Errorf("failed to read IMAP literal: %w", err)
+		}
+		b.WriteString(line[:open] + "{}")
+		literals = append(literals, literal)
+	}
+}
+
+func (c *client) login(username, password string) error {
+	if _, err := c.command("LOGIN %s %s", quote(username), quote(password)); err != nil {
+		return err
+	}
+	// Servers may announce more once logged in
+	responses, err := c.command("CAPABILITY")
+	if err != nil {
+		return err
+	}
+	c.caps = make(map[string]bool)
+	for _, r := range responses {
+		if rest, ok := strings.CutPrefix(r.line, "CAPABILITY"); ok {
+			for _, cap := range strings.Fields(rest) {
+				c.caps[strings.ToUpper(cap)] = true
+			}
+		}
+	}
+	return nil
+}
+
+func (c *client) selectMailbox(name string) error {
+	_, err := c.command("SELECT %s", quote(name))
+	return err
+}
+
+// unseen returns the UIDs of unread messages
+func (c *client) unseen() ([]string, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, r := range responses {
+		if rest, ok := strings.CutPrefix(r.line, "SEARCH"); ok {
+			uids = append(uids, strings.Fields(rest)...)
+		}
+	}
+	return uids, nil
+}
+
+// fetch returns the raw message with uid, without marking it read
+func (c *client) fetch(uid string) ([]byte, error) {
+	responses, err := c.command("UID FETCH %s BODY.PEEK[]", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range responses {
+		if strings.Contains(r.line, "FETCH") && len(r.literals) > 0 {
+			return r.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message %s not returned by the server", uid)
+}
+
+func (c *client) markSeen(uid string) error {
+	_, err := c.command(`UID STORE %s +FLAGS (\Seen)`, uid)
+	return err
+}
+
+// move files a message in another mailbox. Servers without the MOVE
+// extension get a copy and the message is flagged deleted; it is only
+// expunged with UIDPLUS, since a plain EXPUNGE would also remove any other
+// message the user flagged deleted.
+func (c *client) move(uid, mailbox string) error {
+	if c.caps["MOVE"] {
+		_, err := c.command("UID MOVE %s %s", uid, quote(mailbox))
+		return err
+	}
+	if _, err := c.command("UID COPY %s %s", uid, quote(mailbox)); err != nil {
+		return err
+	}
+	if _, err := c.command(`UID STORE %s +FLAGS (\Deleted)`, uid); err != nil {
+		return err
+	}
+	if !c.caps["UIDPLUS"] {
+		return nil
+	}
+	_, err := c.command("UID EXPUNGE %s", uid)
+	return err
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,163 @@
+package mailbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+)
+
+// message is the part of an email used to create jobs
+type message struct {
+	Subject string
+	From    string
+	// Address is the sender's email address, in lower case
+	Address string
+	Text    string
+	Links   []string
+}
+
+var (
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"')\]]+`)
+
+	// skippedLink matches links that are never worth summarizing
+	skippedLink = regexp.MustCompile(`(?i)unsubscribe|/preferences|list-manage\.com|/optout|/opt-out|\.(png|jpe?g|gif|webp|svg)(\?|$)`)
+)
+
+// parseMessage decodes a raw RFC 5322 message, preferring its text/plain
+// body and falling back to the readable text of its HTML body
+func parseMessage(raw []byte) (*message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	dec := new(mime.WordDecoder)
+	msg := &message{}
+	if msg.Subject, err = dec.DecodeHeader(m.Header.Get("Subject")); err != nil {
+		msg.Subject = m.Header.Get("Subject")
+	}
+	if from, err := mail.ParseAddress(m.Header.Get("From")); err == nil {
+		msg.Address = strings.ToLower(from.Address)
+		msg.From = from.Name
+		if msg.From == "" {
+			msg.From = from.Address
+		}
+	}
+
+	var plain, htmlBody string
+	walkParts(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body, func(mediaType string, body []byte) {
+		switch {
+		case mediaType == "text/plain" && plain == "":
+			plain = string(body)
+		case mediaType == "text/html" && htmlBody == "":
+			htmlBody = string(body)
+		}
+	})
+
+	switch {
+	case plain != "":
+		msg.Text = strings.TrimSpace(plain)
+		msg.Links = urlPattern.FindAllString(plain, -1)
+	case htmlBody != "":
+		if article, err := readability.FromReader(strings.NewReader(htmlBody), nil); err == nil {
+			msg.Text = strings.TrimSpace(article.TextContent)
+		}
+		msg.Links = htmlLinks(htmlBody)
+	}
+	msg.Links = filterLinks(msg.Links)
+	return msg, nil
+}
+
+// walkParts calls fn with the decoded body of every leaf MIME part
+func walkParts(contentType, encoding string, body io.Reader, fn func(mediaType string, body []byte)) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			walkParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, fn)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return
+	}
+	fn(mediaType, data)
+}
+
+// newlineStripper drops the line breaks of base64 bodies
+type newlineStripper struct{ r io.Reader }
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	kept := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// htmlLinks returns the href targets of the anchors in an HTML body
+func htmlLinks(body string) []string {
+	var links []string
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" && urlPattern.Match(val) {
+					links = append(links, string(val))
+				}
+			}
+		}
+	}
+}
+
+// filterLinks drops duplicates and links to unsubscribe pages and images
+func filterLinks(links []string) []string {
+	seen := make(map[string]bool)
+	var kept []string
+	for _, link := range links {
+		link = strings.TrimRight(link, ".,;:")
+		if seen[link] || skippedLink.MatchString(link) {
+			continue
+		}
+		seen[link] = true
+		kept = append(kept, link)
+	}
+	return kept
+}
@@ -0,0 +1,204 @@
+// Package mailbox turns newsletters and forwarded links arriving in an IMAP
+// mailbox into jobs.
+package mailbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// Modes decide what a message turns into
+const (
+	// ModeLinks enqueues one job per link in the message
+	ModeLinks = "links"
+	// ModeText summarizes the message body itself
+	ModeText = "text"
+	// ModeAuto enqueues the links of short messages that are mostly links,
+	// such as forwarded articles, and summarizes the body of the others
+	ModeAuto = "auto"
+)
+
+// autoMaxLinks and autoMaxText bound a message that ModeAuto treats as a
+// list of links rather than a newsletter
+const (
+	autoMaxLinks = 5
+	autoMaxText  = 1000
+)
+
+type Options struct {
+	Addr     string
+	TLS      bool
+	Username string
+	Password string
+	Mailbox  string
+	// MoveTo is the mailbox processed messages are moved to; they are only
+	// marked read when empty
+	MoveTo   string
+	Mode     string
+	Interval time.Duration
+
+	// AllowedSenders is a comma-separated list of addresses and domains
+	// (example.com or @example.com) whose messages are processed; empty
+	// allows every sender
+	AllowedSenders string
+}
+
+// Poller checks the mailbox for unread messages on an interval
+type Poller struct {
+	opts    Options
+	queue   *queue.Queue
+	allowed []string
+	done    chan struct{}
+}
+
+func New(opts Options, q *queue.Queue) (*Poller, error) {
+	switch opts.Mode {
+	case ModeAuto, ModeLinks, ModeText:
+	default:
+		return nil, fmt.Errorf("unknown email mode %q, expected auto, links, or text", opts.Mode)
+	}
+	var allowed []string
+	for _, sender := range strings.Split(opts.AllowedSenders, ",") {
+		if sender = strings.ToLower(strings.TrimSpace(sender)); sender != "" {
+			allowed = append(allowed, sender)
+		}
+	}
+	return &Poller{opts: opts, queue: q, allowed: allowed, done: make(chan struct{})}, nil
+}
+
+// allows reports whether messages from address are processed
+func (p *Poller) allows(address string) bool {
+	if len(p.allowed) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(address, "@")
+	for _, sender := range p.allowed {
+		if address == sender || domain == strings.TrimPrefix(sender, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Poller) Start() {
+	go p.run()
+}
+
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		if n, err := p.Poll(ctx); err != nil {
+			slog.Warn("Failed to poll mailbox", "mailbox", p.opts.Mailbox, "error", err)
+		} else if n > 0 {
+			slog.Info("Queued jobs from email", "mailbox", p.opts.Mailbox, "jobs", n)
+		}
+		cancel()
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll enqueues jobs for every unread message and files the messages, so
+// each is processed once. It returns the number of jobs queued.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	c, err := dial(ctx, p.opts.Addr, p.opts.TLS)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	if err := c.login(p.opts.Username, p.opts.Password); err != nil {
+		return 0, err
+	}
+	if err := c.selectMailbox(p.opts.Mailbox); err != nil {
+		return 0, err
+	}
+	uids, err := c.unseen()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, uid := range uids {
+		raw, err := c.fetch(uid)
+		if err != nil {
+			return queued, err
+		}
+
+		msg, err := parseMessage(raw)
+		if err != nil {
+			// Left unread, so it is retried on the next poll
+			slog.Warn("Failed to parse email", "uid", uid, "error", err)
+			continue
+		}
+		var jobs []*models.Job
+		if p.allows(msg.Address) {
+			jobs = p.jobs(msg)
+		} else {
+			// Filed like the others, so it is not checked again
+			slog.Warn("Ignoring email from a sender not allowed", "from", msg.Address, "subject", msg.Subject)
+		}
+		for _, job := range jobs {
+			if err := p.queue.EnqueueInput(job); err != nil {
+				return queued, err
+			}
+			queued++
+		}
+		slog.Info("Processed email", "subject", msg.Subject, "from", msg.From, "jobs", len(jobs))
+
+		if err := c.markSeen(uid); err != nil {
+			return queued, err
+		}
+		if p.opts.MoveTo != "" {
+			if err := c.move(uid, p.opts.MoveTo); err != nil {
+				return queued, err
+			}
+		}
+	}
+	return queued, nil
+}
+
+// jobs returns the jobs for a message according to the mode
+func (p *Poller) jobs(msg *message) []*models.Job {
+	mode := p.opts.Mode
+	if mode == ModeAuto {
+		mode = ModeText
+		if len(msg.Links) > 0 && len(msg.Links) <= autoMaxLinks && len(msg.Text) <= autoMaxText {
+			mode = ModeLinks
+		}
+	}
+
+	var jobs []*models.Job
+	switch mode {
+	case ModeLinks:
+		for _, link := range msg.Links {
+			jobs = append(jobs, models.NewJob("", link, ""))
+		}
+	case ModeText:
+		if msg.Text != "" {
+			job := models.NewTextJob("", msg.Text, "")
+			job.Title = msg.Subject
+			jobs = append(jobs, job)
+		}
+	}
+	for _, job := range jobs {
+		job.Feed = msg.From
+	}
+	return jobs
+}
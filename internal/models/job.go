@@ -1,19 +1,117 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type ContentType string
 
 const (
-	ContentTypeYouTube ContentType = "youtube"
-	ContentTypeText    ContentType = "text"
-	ContentTypeUnknown ContentType = "unknown"
+	ContentTypeYouTube    ContentType = "youtube"
+	ContentTypeVideo      ContentType = "video"
+	ContentTypePodcast    ContentType = "podcast"
+	ContentTypePDF        ContentType = "pdf"
+	ContentTypeThread     ContentType = "thread"
+	ContentTypeReddit     ContentType = "reddit"
+	ContentTypeRepository ContentType = "repository"
+	ContentTypeIssue      ContentType = "issue"
+	ContentTypeMedia      ContentType = "media"
+	ContentTypeText       ContentType = "text"
+	ContentTypeDirectText ContentType = "direct_text"
+	ContentTypeUnknown    ContentType = "unknown"
 )
 
+// ErrorCode classifies why a job failed, so failures can be filtered and
+// handled automatically
+type ErrorCode string
+
+const (
+	ErrorDownloadFailed  ErrorCode = "download_failed"
+	ErrorExtractionEmpty ErrorCode = "extraction_empty"
+	ErrorRateLimited     ErrorCode = "rate_limited"
+	ErrorTimeout         ErrorCode = "timeout"
+	ErrorProviderError   ErrorCode = "provider_error"
+	ErrorRejected        ErrorCode = "provider_rejected"
+	ErrorUnsupported     ErrorCode = "unsupported_content"
+	ErrorInvalidInput    ErrorCode = "invalid_input"
+	ErrorOutputFailed    ErrorCode = "output_failed"
+	ErrorInternal        ErrorCode = "internal"
+)
+
+// Priority orders pending jobs; higher priorities are processed first
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// ParsePriority accepts "low", "normal", "high", or an integer
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "normal":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return PriorityNormal, fmt.Errorf("invalid priority %q, expected low, normal, high, or a number", s)
+	}
+	return Priority(n), nil
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// ParseNotBefore parses when a deferred job becomes due: a timestamp
+// ("2006-01-02T15:04:05Z07:00", "2006-01-02 15:04", "2006-01-02"), a time of
+// day ("23:30", the next such time after now), or a delay ("2h") after now
+func ParseNotBefore(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		due := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !due.After(now) {
+			due = due.AddDate(0, 0, 1)
+		}
+		return due, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid process_after %q, expected a date, a time of day such as 23:30, or a delay such as 2h", s)
+}
+
 type JobStatus string
 
 const (
@@ -21,6 +119,9 @@ const (
 	JobStatusProcessing JobStatus = "processing"
 	JobStatusCompleted  JobStatus = "completed"
 	JobStatusFailed     JobStatus = "failed"
+
+	// JobStatusHeld jobs wait for their batch to be confirmed
+	JobStatusHeld JobStatus = "held"
 )
 
 type Job struct {
@@ -29,14 +130,82 @@ type Job struct {
 	FilePath     string      `json:"file_path"`
 	URL          string      `json:"url"`
 	CustomPrompt string      `json:"custom_prompt,omitempty"`
+	Text         string      `json:"text,omitempty"`
+	IsDirectText bool        `json:"is_direct_text,omitempty"`
+	ContentHash  string      `json:"content_hash,omitempty"`
+	Notes        string      `json:"notes,omitempty"`
+	Feed         string      `json:"feed,omitempty"`
+	Persona      string      `json:"persona,omitempty"`
+	Priority     Priority    `json:"priority,omitempty"`
+	Batch        string      `json:"batch,omitempty"`
+	NotBefore    time.Time   `json:"not_before,omitzero"`
+	OutputPath   string      `json:"output_path,omitempty"`
+	OutputName   string      `json:"output_name,omitempty"`
+	Provider     string      `json:"provider,omitempty"`
+	Model        string      `json:"model,omitempty"`
+	Language     string      `json:"language,omitempty"`
+	Title        string      `json:"title,omitempty"`
 	ContentType  ContentType `json:"content_type"`
 	Status       JobStatus   `json:"status"`
 	Content      string      `json:"content,omitempty"`
 	Summary      string      `json:"summary,omitempty"`
 	Error        string      `json:"error,omitempty"`
+	ErrorCode    ErrorCode   `json:"error_code,omitempty"`
 	CreatedAt    time.Time   `json:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at"`
+	StartedAt    time.Time   `json:"started_at,omitzero"`
 	Retries      int         `json:"retries"`
+
+	// SummaryLanguage is the ISO 639-1 code the summary is written in,
+	// whatever the language of the content; Language is the detected one
+	SummaryLanguage string `json:"summary_language,omitempty"`
+
+	// Style is the summary style, such as "tldr" or "bullets", overriding
+	// the configured one
+	Style string `json:"style,omitempty"`
+
+	// OutputFormat is the format of the summary file: markdown, json, html,
+	// or text, overriding the configured one
+	OutputFormat string `json:"output_format,omitempty"`
+
+	// Tags are the topics of the summary, written to its front matter
+	Tags []string `json:"tags,omitempty"`
+
+	// Questions are answered from the content in a Q&A section after the
+	// summary
+	Questions []string `json:"questions,omitempty"`
+
+	// Stage is the step a processing job is at, such as "transcribing
+	// audio"; failed jobs keep the step they failed at
+	Stage string `json:"stage,omitempty"`
+
+	// LeaseOwner is the replica processing the job, until LeaseExpires
+	LeaseOwner   string    `json:"lease_owner,omitempty"`
+	LeaseExpires time.Time `json:"lease_expires,omitzero"`
+
+	// Escalation counts the timeouts after which the job moved to a lighter
+	// transcription pipeline
+	Escalation int `json:"escalation,omitempty"`
+
+	// RateLimited counts the times the provider rate limited the job, which
+	// wait and try again without counting as retries
+	RateLimited int `json:"rate_limited,omitempty"`
+
+	// ServedProvider and ServedModel wrote the summary, a fallback when the
+	// requested Provider and Model failed; retries start from the requested
+	// ones again
+	ServedProvider string `json:"served_provider,omitempty"`
+	ServedModel    string `json:"served_model,omitempty"`
+
+	// NoCache asks for a fresh summary rather than a cached one, when the
+	// previous summary was deleted or rated low
+	NoCache bool `json:"no_cache,omitempty"`
+
+	// InputTokens and OutputTokens are the LLM tokens used by every attempt
+	// of the job, and CostUSD their estimated price
+	InputTokens  int64   `json:"input_tokens,omitempty"`
+	OutputTokens int64   `json:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty"`
 }
 
 func NewJob(filePath, url, customPrompt string) *Job {
@@ -45,8 +214,14 @@ func NewJob(filePath, url, customPrompt string) *Job {
 	base := filepath.Base(filePath)
 	filename := strings.TrimSuffix(base, filepath.Ext(base))
 
+	id := generateID()
+	if filePath == "" {
+		// Jobs submitted without an input file are named after their ID
+		filename = id
+	}
+
 	return &Job{
-		ID:           generateID(),
+		ID:           id,
 		Filename:     filename,
 		FilePath:     filePath,
 		URL:          url,
@@ -58,6 +233,65 @@ func NewJob(filePath, url, customPrompt string) *Job {
 	}
 }
 
+// NewTextJob creates a job summarizing text submitted directly instead of a URL
+func NewTextJob(filePath, text, customPrompt string) *Job {
+	job := NewJob(filePath, "", customPrompt)
+	job.Text = text
+	job.IsDirectText = true
+	job.ContentType = ContentTypeDirectText
+	job.ContentHash = HashText(text)
+	return job
+}
+
+// NewMediaJob creates a job transcribing an audio or video file dropped
+// into the watch directory
+func NewMediaJob(filePath string) *Job {
+	job := NewJob(filePath, "", "")
+	job.ContentType = ContentTypeMedia
+	return job
+}
+
+// HashText returns a stable hash of text, ignoring surrounding whitespace
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Source describes where the job content comes from, for logs and notifications
+func (j *Job) Source() string {
+	if j.IsDirectText {
+		return fmt.Sprintf("direct text (%d chars)", len(j.Text))
+	}
+	if j.ContentType == ContentTypeMedia {
+		return "media file " + filepath.Base(j.FilePath)
+	}
+	return j.URL
+}
+
+// ServedBy returns the provider and model that wrote the summary, or the
+// requested ones for jobs not summarized yet
+func (j *Job) ServedBy() (string, string) {
+	if j.ServedProvider == "" {
+		return j.Provider, j.Model
+	}
+	return j.ServedProvider, j.ServedModel
+}
+
+var (
+	idMu   sync.Mutex
+	lastID time.Time
+)
+
+// generateID returns a timestamp ID, moved forward by a millisecond when
+// jobs are created within the same millisecond so IDs stay unique
 func generateID() string {
-	return time.Now().Format("20060102-150405.000")
+	idMu.Lock()
+	defer idMu.Unlock()
+
+	now := time.Now().Truncate(time.Millisecond)
+	if !now.After(lastID) {
+		now = lastID.Add(time.Millisecond)
+	}
+	lastID = now
+	return now.Format("20060102-150405.000")
 }
@@ -0,0 +1,368 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Message is a notification, rendered by each backend in its own format
+type Message struct {
+	Title string
+	Body  string
+
+	// Priority is "low", "default", or "high"
+	Priority string
+
+	// Tags are ntfy tag names, comma separated
+	Tags string
+
+	Actions []Action
+}
+
+// Action is a button attached to a notification. With an empty Method it
+// opens URL, otherwise it calls URL with that HTTP method and Headers.
+type Action struct {
+	Label   string
+	URL     string
+	Method  string
+	Headers map[string]string
+}
+
+// Backend delivers notifications to one service
+type Backend interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Notifier sends job notifications through every configured backend
+type Notifier struct {
+	backends  []Backend
+	publicURL string
+	outputDir string
+	apiToken  string
+	templates map[string]*template.Template
+}
+
+// Templates are text/template strings replacing the default title and body
+// of job notifications. They are executed with the job, so fields such as
+// {{.URL}}, {{.Title}}, {{.Error}}, {{.OutputPath}}, and {{.Source}} are
+// available. Empty templates keep the default text.
+type Templates struct {
+	StartTitle   string
+	StartBody    string
+	SuccessTitle string
+	SuccessBody  string
+	FailureTitle string
+	FailureBody  string
+	SkippedTitle string
+	SkippedBody  string
+}
+
+// New creates a notifier for the given backends, or nil when there are none.
+// publicURL is the externally reachable base URL of the HTTP API; when set,
+// notifications carry Retry and Open summary action buttons, linking to the
+// summaries by their path in outputDir.
+func New(publicURL, outputDir string, backends ...Backend) *Notifier {
+	if len(backends) == 0 {
+		return nil
+	}
+	return &Notifier{
+		backends:  backends,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		outputDir: outputDir,
+	}
+}
+
+// SetAPIToken sets the token the HTTP API requires, sent by the action
+// buttons that call it
+func (n *Notifier) SetAPIToken(token string) {
+	if n == nil {
+		return
+	}
+	n.apiToken = token
+}
+
+// SetTemplates parses the notification templates, failing on syntax errors
+func (n *Notifier) SetTemplates(t Templates) error {
+	if n == nil {
+		return nil
+	}
+
+	sources := map[string]string{
+		"start.title":   t.StartTitle,
+		"start.body":    t.StartBody,
+		"success.title": t.SuccessTitle,
+		"success.body":  t.SuccessBody,
+		"failure.title": t.FailureTitle,
+		"failure.body":  t.FailureBody,
+		"skipped.title": t.SkippedTitle,
+		"skipped.body":  t.SkippedBody,
+	}
+	templates := make(map[string]*template.Template)
+	for name, text := range sources {
+		if text == "" {
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid %s notification template: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+	n.templates = templates
+	return nil
+}
+
+// Backends returns the configured backends
+func (n *Notifier) Backends() []Backend {
+	if n == nil {
+		return nil
+	}
+	return n.backends
+}
+
+func (n *Notifier) SendStart(ctx context.Context, job *models.Job) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    n.render("start.title", job, fmt.Sprintf("Briefly: processing %s", job.ContentType)),
+		Body:     n.render("start.body", job, fmt.Sprintf("Started processing %s\n\nFile: %s", job.Source(), job.Filename)),
+		Priority: "default",
+		Tags:     n.getTagForContentType(job.ContentType),
+		Actions:  n.actions(n.openURLAction(job)),
+	})
+}
+
+func (n *Notifier) SendSuccess(ctx context.Context, job *models.Job) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    n.render("success.title", job, fmt.Sprintf("Briefly: %s summary ready", job.ContentType)),
+		Body:     n.render("success.body", job, fmt.Sprintf("Summary for %s is ready.\n\nFile: %s", job.Source(), job.Filename)),
+		Priority: "default",
+		Tags:     n.getTagForContentType(job.ContentType),
+		Actions:  n.actions(n.openSummaryAction(job), n.openURLAction(job)),
+	})
+}
+
+func (n *Notifier) SendFailure(ctx context.Context, job *models.Job) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    n.render("failure.title", job, fmt.Sprintf("Briefly: %s processing failed", job.ContentType)),
+		Body:     n.render("failure.body", job, fmt.Sprintf("Failed to process %s\n\nError (%s): %s\n\nFile: %s", job.Source(), job.ErrorCode, job.Error, job.Filename)),
+		Priority: "high",
+		Tags:     "x",
+		Actions:  n.actions(n.retryAction(job), n.openURLAction(job)),
+	})
+}
+
+// SendProgress reports the stage a long job reached, with low priority so
+// it does not buzz
+func (n *Notifier) SendProgress(ctx context.Context, job *models.Job) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    fmt.Sprintf("Briefly: %s", job.Stage),
+		Body:     fmt.Sprintf("%s\n\nFile: %s", job.Source(), job.Filename),
+		Priority: "low",
+		Tags:     "hourglass_flowing_sand",
+	})
+}
+
+func (n *Notifier) SendSkipped(ctx context.Context, job *models.Job) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    n.render("skipped.title", job, "Briefly: skipped duplicate"),
+		Body:     n.render("skipped.body", job, fmt.Sprintf("Already processed %s\n\nFile: %s", job.Source(), job.Filename)),
+		Priority: "low",
+		Tags:     "repeat",
+		Actions:  n.actions(n.openURLAction(job)),
+	})
+}
+
+func (n *Notifier) SendBudgetExhausted(ctx context.Context, provider, reason string, resetAt time.Time) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    fmt.Sprintf("Briefly: %s budget exhausted", provider),
+		Body:     fmt.Sprintf("%s. New jobs stay queued until %s.", reason, resetAt.Format("2006-01-02 15:04")),
+		Priority: "high",
+		Tags:     "moneybag",
+	})
+}
+
+func (n *Notifier) SendOverflow(ctx context.Context, pending, batchSize int) error {
+	if n == nil {
+		return nil
+	}
+
+	return n.send(ctx, Message{
+		Title:    "Briefly: inbox flood",
+		Body:     fmt.Sprintf("%d files arrived at once. They are being queued in batches of %d.", pending, batchSize),
+		Priority: "high",
+		Tags:     "warning",
+	})
+}
+
+// SendTriage lists a batch of jobs that arrived together, with the expected
+// processing time and cost. Held batches carry Confirm and Cancel buttons.
+func (n *Notifier) SendTriage(ctx context.Context, batch string, jobs []models.Job, eta time.Duration, cost float64, held bool) error {
+	if n == nil {
+		return nil
+	}
+
+	const maxListed = 10
+	var b strings.Builder
+	for i, job := range jobs {
+		if i == maxListed {
+			fmt.Fprintf(&b, "... and %d more\n", len(jobs)-maxListed)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", job.Source())
+	}
+	fmt.Fprintf(&b, "\nEstimated: %s of processing, about $%.2f", eta.Round(time.Minute), cost)
+	if held {
+		fmt.Fprintf(&b, "\nHeld until confirmed: drop %s.confirm (or %s.cancel) into the inbox", batch, batch)
+	}
+
+	var actions []Action
+	if held && n.publicURL != "" {
+		actions = []Action{
+			{Label: "Confirm", URL: fmt.Sprintf("%s/batches/%s/confirm", n.publicURL, batch), Method: "POST", Headers: n.apiHeaders()},
+			{Label: "Cancel", URL: fmt.Sprintf("%s/batches/%s", n.publicURL, batch), Method: "DELETE", Headers: n.apiHeaders()},
+		}
+	}
+
+	return n.send(ctx, Message{
+		Title:    fmt.Sprintf("Briefly: %d items queued", len(jobs)),
+		Body:     b.String(),
+		Priority: "default",
+		Tags:     "inbox_tray",
+		Actions:  actions,
+	})
+}
+
+// render executes the named template with job, or returns def when the
+// template is not set or fails
+func (n *Notifier) render(name string, job *models.Job, def string) string {
+	tmpl, ok := n.templates[name]
+	if !ok {
+		return def
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, job); err != nil {
+		slog.Warn("Failed to render notification template", "template", name, "error", err)
+		return def
+	}
+	return b.String()
+}
+
+func (n *Notifier) getTagForContentType(contentType models.ContentType) string {
+	switch contentType {
+	case models.ContentTypeYouTube, models.ContentTypeVideo:
+		return "video"
+	case models.ContentTypePodcast:
+		return "headphones"
+	case models.ContentTypePDF:
+		return "page_facing_up"
+	case models.ContentTypeText:
+		return "reading"
+	case models.ContentTypeThread:
+		return "bird"
+	case models.ContentTypeReddit:
+		return "speech_balloon"
+	case models.ContentTypeRepository:
+		return "package"
+	case models.ContentTypeIssue:
+		return "octopus"
+	case models.ContentTypeMedia:
+		return "studio_microphone"
+	case models.ContentTypeDirectText:
+		return "memo"
+	default:
+		return "hourglass"
+	}
+}
+
+func (n *Notifier) openURLAction(job *models.Job) *Action {
+	if job.URL == "" {
+		return nil
+	}
+	return &Action{Label: "Open original", URL: job.URL}
+}
+
+func (n *Notifier) openSummaryAction(job *models.Job) *Action {
+	if n.publicURL == "" || job.OutputPath == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(n.outputDir, job.OutputPath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil
+	}
+	// Escaped segment by segment, as served by GET /summaries/{path...}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return &Action{Label: "Open summary", URL: fmt.Sprintf("%s/summaries/%s", n.publicURL, strings.Join(segments, "/"))}
+}
+
+func (n *Notifier) retryAction(job *models.Job) *Action {
+	if n.publicURL == "" {
+		return nil
+	}
+	return &Action{Label: "Retry", URL: fmt.Sprintf("%s/jobs/%s/retry", n.publicURL, job.ID), Method: "POST", Headers: n.apiHeaders()}
+}
+
+// apiHeaders authorizes the action buttons calling the HTTP API
+func (n *Notifier) apiHeaders() map[string]string {
+	if n.apiToken == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + n.apiToken}
+}
+
+// actions drops the actions that do not apply
+func (n *Notifier) actions(candidates ...*Action) []Action {
+	var actions []Action
+	for _, a := range candidates {
+		if a != nil {
+			actions = append(actions, *a)
+		}
+	}
+	return actions
+}
+
+// send delivers msg through every backend, reporting the ones that failed
+func (n *Notifier) send(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, b := range n.backends {
+		if err := b.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
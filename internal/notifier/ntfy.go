@@ -5,21 +5,18 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
-
-	"github.com/clobrano/briefly/internal/models"
 )
 
-type Notifier struct {
+// Ntfy publishes notifications to an ntfy.sh topic
+type Ntfy struct {
 	topic  string
 	client *http.Client
 }
 
-func New(topic string) *Notifier {
-	if topic == "" {
-		return nil
-	}
-	return &Notifier{
+func NewNtfy(topic string) *Ntfy {
+	return &Ntfy{
 		topic: topic,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
@@ -27,74 +24,38 @@ func New(topic string) *Notifier {
 	}
 }
 
-func (n *Notifier) SendStart(ctx context.Context, job *models.Job) error {
-	if n == nil || n.topic == "" {
-		return nil
-	}
-
-	title := fmt.Sprintf("Briefly: processing %s", job.ContentType)
-	message := fmt.Sprintf("Started processing %s\n\nFile: %s", job.URL, job.Filename)
-	tag := n.getTagForContentType(job.ContentType)
-
-	return n.send(ctx, title, message, "default", tag)
+func (n *Ntfy) Name() string {
+	return "ntfy"
 }
 
-func (n *Notifier) SendSuccess(ctx context.Context, job *models.Job) error {
-	if n == nil || n.topic == "" {
-		return nil
-	}
-
-	title := fmt.Sprintf("Briefly: %s summary ready", job.ContentType)
-	message := fmt.Sprintf("Summary for %s is ready.\n\nFile: %s", job.URL, job.Filename)
-	tag := n.getTagForContentType(job.ContentType)
-
-	return n.send(ctx, title, message, "default", tag)
-}
-
-func (n *Notifier) SendFailure(ctx context.Context, job *models.Job) error {
-	if n == nil || n.topic == "" {
-		return nil
-	}
-
-	title := fmt.Sprintf("Briefly: %s processing failed", job.ContentType)
-	message := fmt.Sprintf("Failed to process %s\n\nError: %s\n\nFile: %s", job.URL, job.Error, job.Filename)
-
-	return n.send(ctx, title, message, "high", "x")
-}
-
-func (n *Notifier) SendSkipped(ctx context.Context, job *models.Job) error {
-	if n == nil || n.topic == "" {
-		return nil
-	}
-
-	title := "Briefly: skipped duplicate"
-	message := fmt.Sprintf("Already processed %s\n\nFile: %s", job.URL, job.Filename)
-
-	return n.send(ctx, title, message, "low", "repeat")
-}
-
-func (n *Notifier) getTagForContentType(contentType models.ContentType) string {
-	switch contentType {
-	case models.ContentTypeYouTube:
-		return "video"
-	case models.ContentTypeText:
-		return "reading"
-	default:
-		return "hourglass"
-	}
-}
-
-func (n *Notifier) send(ctx context.Context, title, message, priority, tags string) error {
+// Send posts a message to the topic, with actions as ntfy action buttons
+func (n *Ntfy) Send(ctx context.Context, msg Message) error {
 	url := fmt.Sprintf("https://ntfy.sh/%s", n.topic)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(message))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(msg.Body))
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Title", title)
-	req.Header.Set("Priority", priority)
-	req.Header.Set("Tags", tags)
+	req.Header.Set("Title", msg.Title)
+	req.Header.Set("Priority", msg.Priority)
+	req.Header.Set("Tags", msg.Tags)
+
+	var buttons []string
+	for _, a := range msg.Actions {
+		if a.Method == "" {
+			buttons = append(buttons, fmt.Sprintf("view, %s, %s", a.Label, a.URL))
+		} else {
+			button := fmt.Sprintf("http, %s, %s, method=%s", a.Label, a.URL, a.Method)
+			for name, value := range a.Headers {
+				button += fmt.Sprintf(", headers.%s=%s", name, value)
+			}
+			buttons = append(buttons, button+", clear=true")
+		}
+	}
+	if len(buttons) > 0 {
+		req.Header.Set("Actions", strings.Join(buttons, "; "))
+	}
 
 	resp, err := n.client.Do(req)
 	if err != nil {
@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Telegram sends notifications as messages from a bot to a chat
+type Telegram struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+type telegramButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+type telegramMessage struct {
+	ChatID              string `json:"chat_id"`
+	Text                string `json:"text"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+	ReplyMarkup         *struct {
+		InlineKeyboard [][]telegramButton `json:"inline_keyboard"`
+	} `json:"reply_markup,omitempty"`
+}
+
+// Send posts msg to the chat. Link actions become inline buttons; actions
+// that need an HTTP call cannot be expressed in Telegram and are dropped.
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	payload := telegramMessage{
+		ChatID:              t.chatID,
+		Text:                msg.Title + "\n\n" + msg.Body,
+		DisableNotification: msg.Priority == "low",
+	}
+
+	var row []telegramButton
+	for _, a := range msg.Actions {
+		if a.Method == "" {
+			row = append(row, telegramButton{Text: a.Label, URL: a.URL})
+		}
+	}
+	if len(row) > 0 {
+		payload.ReplyMarkup = &struct {
+			InlineKeyboard [][]telegramButton `json:"inline_keyboard"`
+		}{InlineKeyboard: [][]telegramButton{row}}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The error embeds the URL, which contains the bot token
+		return fmt.Errorf("failed to send notification: %w", redactToken(err, t.token))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("telegram returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+func redactToken(err error, token string) error {
+	if token == "" {
+		return err
+	}
+	return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), token, "<token>"))
+}
@@ -0,0 +1,132 @@
+// Package pocket queues the articles saved to a Pocket account with a tag,
+// and archives them once summarized.
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const apiURL = "https://getpocket.com/v3"
+
+// Item is a saved article
+type Item struct {
+	ID    string
+	URL   string
+	Title string
+}
+
+// Client calls the Pocket API with an app consumer key and a user access token
+type Client struct {
+	consumerKey string
+	accessToken string
+	client      *http.Client
+}
+
+func NewClient(consumerKey, accessToken string) *Client {
+	return &Client{
+		consumerKey: consumerKey,
+		accessToken: accessToken,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Unread returns the unread items with tag changed since the given Pocket
+// timestamp, and the timestamp to pass to the next call
+func (c *Client) Unread(ctx context.Context, tag string, since int64) ([]Item, int64, error) {
+	req := map[string]any{
+		"state":      "unread",
+		"tag":        tag,
+		"detailType": "simple",
+	}
+	if since > 0 {
+		req["since"] = since
+	}
+
+	var resp struct {
+		List json.RawMessage `json:"list"`
+		// Since is a number in most responses, but has been seen as a string
+		Since json.Number `json:"since"`
+	}
+	if err := c.call(ctx, "/get", req, &resp); err != nil {
+		return nil, since, err
+	}
+
+	// An empty list is returned as [] rather than {}
+	var list map[string]struct {
+		ItemID        string `json:"item_id"`
+		GivenURL      string `json:"given_url"`
+		ResolvedURL   string `json:"resolved_url"`
+		GivenTitle    string `json:"given_title"`
+		ResolvedTitle string `json:"resolved_title"`
+	}
+	if len(resp.List) > 0 && resp.List[0] == '{' {
+		if err := json.Unmarshal(resp.List, &list); err != nil {
+			return nil, since, fmt.Errorf("failed to decode Pocket items: %w", err)
+		}
+	}
+
+	items := make([]Item, 0, len(list))
+	for _, entry := range list {
+		item := Item{ID: entry.ItemID, URL: entry.ResolvedURL, Title: entry.ResolvedTitle}
+		if item.URL == "" {
+			item.URL = entry.GivenURL
+		}
+		if item.Title == "" {
+			item.Title = entry.GivenTitle
+		}
+		items = append(items, item)
+	}
+
+	next, err := resp.Since.Int64()
+	if err != nil {
+		next = since
+	}
+	return items, next, nil
+}
+
+// Archive moves items to the Pocket archive
+func (c *Client) Archive(ctx context.Context, itemIDs ...string) error {
+	actions := make([]map[string]string, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		actions = append(actions, map[string]string{"action": "archive", "item_id": id})
+	}
+	return c.call(ctx, "/send", map[string]any{"actions": actions}, nil)
+}
+
+func (c *Client) call(ctx context.Context, path string, body map[string]any, out any) error {
+	body["consumer_key"] = c.consumerKey
+	body["access_token"] = c.accessToken
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Pocket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		// Pocket explains errors in a header rather than the body
+		return fmt.Errorf("pocket returned status %d: %s", resp.StatusCode, resp.Header.Get("X-Error"))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,181 @@
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// Poller queues newly saved items on an interval. Its state is persisted so
+// items are queued once across restarts.
+type Poller struct {
+	client   *Client
+	queue    *queue.Queue
+	history  *history.Store
+	tag      string
+	archive  bool
+	interval time.Duration
+	done     chan struct{}
+
+	mu          sync.Mutex
+	state       state
+	persistPath string
+}
+
+type state struct {
+	// Since is the Pocket timestamp of the last poll
+	Since int64 `json:"since"`
+	// Pending maps the jobs not yet summarized to their item, to archive it
+	Pending map[string]string `json:"pending,omitempty"`
+}
+
+// NewPoller polls the items saved with tag; with archive set, items are
+// archived in Pocket once their summary shows up in the job history h
+func NewPoller(client *Client, q *queue.Queue, h *history.Store, tag string, archive bool, interval time.Duration, persistPath string) *Poller {
+	p := &Poller{
+		client:      client,
+		queue:       q,
+		history:     h,
+		tag:         tag,
+		archive:     archive,
+		interval:    interval,
+		done:        make(chan struct{}),
+		persistPath: persistPath,
+	}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			slog.Warn("Failed to load Pocket state", "path", persistPath, "error", err)
+		}
+	}
+	if p.state.Pending == nil {
+		p.state.Pending = make(map[string]string)
+	}
+	return p
+}
+
+func (p *Poller) Start() {
+	go p.run()
+}
+
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if n, err := p.Poll(ctx); err != nil {
+			slog.Warn("Failed to poll Pocket", "tag", p.tag, "error", err)
+		} else if n > 0 {
+			slog.Info("Queued Pocket items", "tag", p.tag, "jobs", n)
+		}
+		if err := p.archiveDone(ctx); err != nil {
+			slog.Warn("Failed to archive Pocket items", "error", err)
+		}
+		cancel()
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll queues the items saved since the last poll and returns their number
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	items, since, err := p.client.Unread(ctx, p.tag, p.state.Since)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, item := range items {
+		if item.URL == "" {
+			continue
+		}
+		job := models.NewJob("", item.URL, "")
+		job.Title = item.Title
+		job.Feed = "Pocket"
+		if err := p.queue.EnqueueInput(job); err != nil {
+			return queued, err
+		}
+		if p.archive {
+			p.state.Pending[job.ID] = item.ID
+		}
+		queued++
+	}
+
+	p.state.Since = since
+	return queued, p.persist()
+}
+
+// archiveDone archives the items whose job completed. Completed jobs leave
+// the queue, so they are found in history; jobs gone from the queue without
+// completing, because they failed or were purged, are forgotten.
+func (p *Poller) archiveDone(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var done, items []string
+	for jobID, itemID := range p.state.Pending {
+		if _, queued := p.queue.Get(jobID); queued {
+			continue
+		}
+		done = append(done, jobID)
+		if p.completed(jobID) {
+			items = append(items, itemID)
+		}
+	}
+	if len(done) == 0 {
+		return nil
+	}
+
+	if len(items) > 0 {
+		if err := p.client.Archive(ctx, items...); err != nil {
+			return err
+		}
+		slog.Info("Archived Pocket items", "count", len(items))
+	}
+	for _, jobID := range done {
+		delete(p.state.Pending, jobID)
+	}
+	return p.persist()
+}
+
+// completed reports whether the job with jobID completed
+func (p *Poller) completed(jobID string) bool {
+	if p.history == nil {
+		return false
+	}
+	entries, err := p.history.Query(history.Filter{ID: jobID, Status: models.JobStatusCompleted})
+	if err != nil {
+		slog.Warn("Failed to query history", "error", err)
+		return false
+	}
+	return len(entries) > 0
+}
+
+func (p *Poller) persist() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.persistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to persist Pocket state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	readability "github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// archiveSuffix ends the name of the file holding the offline copy of an
+// article next to its summary
+const archiveSuffix = ".archive.md"
+
+type archiveKey struct{}
+
+// withArchive makes the text extractor hand the readable article it
+// extracted to keep
+func withArchive(ctx context.Context, keep func(article readability.Article)) context.Context {
+	return context.WithValue(ctx, archiveKey{}, keep)
+}
+
+// archiveArticle hands article to the job running with ctx, if it archives
+// articles
+func archiveArticle(ctx context.Context, article readability.Article) {
+	if keep, ok := ctx.Value(archiveKey{}).(func(readability.Article)); ok {
+		keep(article)
+	}
+}
+
+// saveArchive writes article as Markdown next to the summary of job,
+// replacing the copy kept for a previous summary under the same name
+func saveArchive(job *models.Job, article readability.Article) error {
+	var b strings.Builder
+	title := archiveTitle(article.Title, job.Title)
+	fmt.Fprintf(&b, "# %s\n\n**URL:** %s\n", title, job.URL)
+	if article.Byline != "" {
+		fmt.Fprintf(&b, "**Author:** %s\n", strings.TrimSpace(article.Byline))
+	}
+	if article.SiteName != "" {
+		fmt.Fprintf(&b, "**Site:** %s\n", strings.TrimSpace(article.SiteName))
+	}
+	if article.PublishedTime != nil {
+		fmt.Fprintf(&b, "**Published:** %s\n", article.PublishedTime.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "**Archived:** %s\n\n---\n\n", time.Now().Format(time.RFC3339))
+
+	body := article.TextContent
+	if article.Node != nil {
+		body = articleMarkdown(article.Node)
+	}
+	b.WriteString(strings.TrimSpace(body) + "\n")
+
+	path := strings.TrimSuffix(job.OutputPath, filepath.Ext(job.OutputPath)) + archiveSuffix
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// archiveTitle returns the first non-blank title, or "Article"
+func archiveTitle(titles ...string) string {
+	for _, t := range titles {
+		if t = strings.TrimSpace(t); t != "" {
+			return t
+		}
+	}
+	return "Article"
+}
+
+// markdownBlocks are the elements rendered as blocks of their own
+var markdownBlocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true,
+	"footer": true, "main": true, "aside": true, "blockquote": true, "pre": true,
+	"ul": true, "ol": true, "li": true, "table": true, "figure": true,
+	"figcaption": true, "hr": true, "dl": true, "dt": true, "dd": true,
+	"details": true, "summary": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true,
+}
+
+// articleMarkdown renders the readable article as Markdown, keeping its
+// headings, lists, quotes, code, tables, links, emphasis, and images
+func articleMarkdown(node *html.Node) string {
+	return strings.Join(markdownChildren(node), "\n\n")
+}
+
+// markdownChildren renders the children of n as Markdown blocks, grouping
+// consecutive inline children into paragraphs
+func markdownChildren(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := collapseSpace(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isMarkdownBlock(c) {
+			flush()
+			blocks = append(blocks, markdownBlock(c)...)
+			continue
+		}
+		inline.WriteString(markdownInline(c))
+	}
+	flush()
+	return blocks
+}
+
+// isMarkdownBlock reports whether n is a block, or an inline element such as
+// <span> or <body> wrapping blocks
+func isMarkdownBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if markdownBlocks[n.Data] {
+		return true
+	}
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && markdownBlocks[d.Data] {
+			return true
+		}
+	}
+	return false
+}
+
+func markdownBlock(n *html.Node) []string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := collapseSpace(strings.ReplaceAll(markdownInlineChildren(n), "\n", " "))
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(n.Data[1]-'0')) + " " + text}
+	case "pre":
+		return []string{"```\n" + strings.Trim(nodeText(n), "\n") + "\n```"}
+	case "blockquote":
+		lines := strings.Split(strings.Join(markdownChildren(n), "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return []string{strings.Join(lines, "\n")}
+	case "ul", "ol":
+		if list := markdownList(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "hr":
+		return []string{"---"}
+	case "table":
+		if table := markdownTable(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case "figcaption":
+		if text := collapseSpace(markdownInlineChildren(n)); text != "" {
+			return []string{"*" + text + "*"}
+		}
+		return nil
+	}
+	return markdownChildren(n)
+}
+
+// markdownList renders the items of a list, nested blocks indented below
+// their item's marker
+func markdownList(n *html.Node) string {
+	number := 1
+	if start := htmlAttr(n, "start"); start != "" {
+		fmt.Sscanf(start, "%d", &number)
+	}
+
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(strings.Join(markdownChildren(li), "\n"), "\n")
+		for i, line := range lines {
+			if i > 0 && line != "" {
+				lines[i] = indent + line
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// markdownTable renders the rows of a table, the first one as its header
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var row []string
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+					cell := collapseSpace(strings.ReplaceAll(markdownInlineChildren(c), "\n", " "))
+					row = append(row, strings.ReplaceAll(cell, "|", `\|`))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return n.Data
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.Data {
+	case "script", "style", "noscript":
+		return ""
+	case "br":
+		return "\n"
+	case "img":
+		if src := htmlAttr(n, "src"); src != "" {
+			return fmt.Sprintf("![%s](%s)", htmlAttr(n, "alt"), src)
+		}
+		return ""
+	case "code":
+		if text := nodeText(n); text != "" {
+			return "`" + text + "`"
+		}
+		return ""
+	case "strong", "b":
+		return emphasize(markdownInlineChildren(n), "**")
+	case "em", "i":
+		return emphasize(markdownInlineChildren(n), "*")
+	case "a":
+		text := markdownInlineChildren(n)
+		href := htmlAttr(n, "href")
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return fmt.Sprintf("[%s](%s)", strings.TrimSpace(text), href)
+	}
+	return markdownInlineChildren(n)
+}
+
+func markdownInlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(markdownInline(c))
+	}
+	return b.String()
+}
+
+// emphasize wraps text in mark, keeping its surrounding spaces outside so
+// the emphasis stays valid Markdown
+func emphasize(text, mark string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + mark + trimmed + mark + text[start+len(trimmed):]
+}
+
+// collapseSpace collapses the whitespace of each line of text, dropping
+// empty lines
+func collapseSpace(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlAttr returns the value of the key attribute of n, or ""
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+// summaryCache keeps generated summaries by the hash of everything that
+// shaped them, so retrying a job whose output could not be saved, or the
+// same content submitted under another URL, does not call the LLM again.
+type summaryCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cachedSummary is a cache entry
+type cachedSummary struct {
+	Summary  string    `json:"summary"`
+	Title    string    `json:"title,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Created  time.Time `json:"created"`
+}
+
+// newSummaryCache keeps entries in dir for ttl; a nil cache is disabled
+func newSummaryCache(dir string, ttl time.Duration) *summaryCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &summaryCache{dir: dir, ttl: ttl}
+}
+
+// summaryCacheKey hashes the content sent to the model and the settings of
+// its summary
+func summaryCacheKey(job *models.Job, content string, opts summarizer.PromptOptions) string {
+	h := sha256.New()
+	for _, part := range []string{
+		string(job.ContentType), job.Provider, job.Model, job.CustomPrompt,
+		opts.SourceLanguage, string(opts.LanguagePolicy), opts.TargetLanguage,
+		string(opts.Depth), opts.Persona, string(opts.Style), content,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *summaryCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the summary cached under key, unless it expired
+func (c *summaryCache) Get(key string) (cachedSummary, bool) {
+	var entry cachedSummary
+	if c == nil || key == "" {
+		return entry, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.Created) > c.ttl {
+		os.Remove(c.path(key))
+		return entry, false
+	}
+	return entry, true
+}
+
+func (c *summaryCache) Put(key string, entry cachedSummary) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create summary cache: %w", err)
+	}
+	entry.Created = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key), data, 0644)
+}
+
+// Sweep removes the expired entries
+func (c *summaryCache) Sweep() {
+	if c == nil {
+		return
+	}
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	removed := 0
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || !strings.HasSuffix(f.Name(), ".json") || time.Since(info.ModTime()) <= c.ttl {
+			continue
+		}
+		if os.Remove(filepath.Join(c.dir, f.Name())) == nil {
+			removed++
+		}
+	}
+	if removed > 0 {
+		slog.Debug("Removed expired cached summaries", "count", removed)
+	}
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// vttTag matches inline WebVTT markup such as <c>, </c>, and <00:00:01.520>
+var vttTag = regexp.MustCompile(`<[^>]*>`)
+
+// cue is a caption line and the second of the video it starts at
+type cue struct {
+	Start float64
+	Text  string
+}
+
+// fetchCaptions downloads the video's subtitles, or its automatic captions
+// when there are none, in lang. It returns no cues when the video has no
+// captions in that language.
+func (y *YouTubeProcessor) fetchCaptions(ctx context.Context, url, workDir, lang string) ([]cue, error) {
+	args := []string{
+		"--skip-download",
+		"--write-subs",
+		"--write-auto-subs",
+		"--sub-langs", fmt.Sprintf("%s,%s-orig,%s-*", lang, lang, lang),
+		"--sub-format", "vtt",
+		"-o", filepath.Join(workDir, "captions.%(ext)s"),
+		"--no-playlist",
+		"--no-warnings",
+		url,
+	}
+
+	cmd := y.ytdlp(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("yt-dlp failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	matches, err := filepath.Glob(filepath.Join(workDir, "captions*.vtt"))
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	// Prefer the plain language track over regional or original variants
+	sort.Slice(matches, func(i, j int) bool { return len(matches[i]) < len(matches[j]) })
+
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read captions: %w", err)
+	}
+	return parseVTT(string(data)), nil
+}
+
+// parseVTT extracts the spoken lines from a WebVTT file. Automatic captions
+// repeat each line while it scrolls, so consecutive duplicates are dropped.
+func parseVTT(data string) []cue {
+	var cues []cue
+	last := ""
+	start := 0.0
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	inHeader := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// The header runs until the first blank line
+		if inHeader {
+			if line == "" {
+				inHeader = false
+			}
+			continue
+		}
+		if from, _, ok := strings.Cut(line, "-->"); ok {
+			start = parseCueTime(strings.TrimSpace(from))
+			continue
+		}
+		if line == "" || isCueNumber(line) ||
+			strings.HasPrefix(line, "NOTE") || strings.HasPrefix(line, "STYLE") {
+			continue
+		}
+
+		text := strings.TrimSpace(vttTag.ReplaceAllString(line, ""))
+		if text == "" || text == last {
+			continue
+		}
+		cues = append(cues, cue{Start: start, Text: text})
+		last = text
+	}
+
+	return cues
+}
+
+// cueText joins the lines of cues into a plain transcript
+func cueText(cues []cue) string {
+	lines := make([]string, len(cues))
+	for i, c := range cues {
+		lines[i] = c.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseCueTime reads a WebVTT timestamp, "mm:ss.ttt" or "hh:mm:ss.ttt", as
+// seconds
+func parseCueTime(ts string) float64 {
+	seconds := 0.0
+	for _, part := range strings.Split(ts, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds
+}
+
+func isCueNumber(line string) bool {
+	for _, r := range line {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
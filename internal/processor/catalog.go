@@ -0,0 +1,187 @@
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Summary index formats
+const (
+	CatalogMarkdown = "markdown"
+	CatalogJSON     = "json"
+	CatalogBoth     = "both"
+)
+
+// catalogMarker starts the Markdown index, so a summary that happens to be
+// named "index" is never overwritten
+const catalogMarker = "<!-- briefly index: regenerated as summaries complete, edits are lost -->"
+
+// catalogItem is a summary listed in the index
+type catalogItem struct {
+	Title string             `json:"title"`
+	URL   string             `json:"url,omitempty"`
+	Path  string             `json:"path"`
+	Date  time.Time          `json:"date"`
+	Type  models.ContentType `json:"type"`
+	Feed  string             `json:"feed,omitempty"`
+	Tags  []string           `json:"tags,omitempty"`
+}
+
+// ValidateCatalog checks the summary index format
+func ValidateCatalog(format string) error {
+	switch format {
+	case "", CatalogMarkdown, CatalogJSON, CatalogBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown summary index %q, expected markdown, json, or both", format)
+}
+
+// updateCatalog rewrites the index of the summaries in history whose file
+// still exists, newest first
+func (p *Processor) updateCatalog() {
+	if p.cfg.SummaryIndex == "" || p.history == nil {
+		return
+	}
+	p.catalogMu.Lock()
+	defer p.catalogMu.Unlock()
+
+	entries, err := p.history.Query(history.Filter{Status: models.JobStatusCompleted})
+	if err != nil {
+		slog.Warn("Failed to update the summary index", "error", err)
+		return
+	}
+	items := catalogItems(p.cfg.OutputDir, entries)
+
+	if p.cfg.SummaryIndex != CatalogJSON {
+		if err := writeMarkdownCatalog(filepath.Join(p.cfg.OutputDir, "index.md"), items); err != nil {
+			slog.Warn("Failed to update the summary index", "error", err)
+		}
+	}
+	if p.cfg.SummaryIndex != CatalogMarkdown {
+		if err := writeJSONCatalog(filepath.Join(p.cfg.OutputDir, "index.json"), items); err != nil {
+			slog.Warn("Failed to update the summary index", "error", err)
+		}
+	}
+}
+
+// catalogItems keeps the latest entry of each summary that still exists
+func catalogItems(outputDir string, entries []history.Entry) []catalogItem {
+	seen := make(map[string]bool)
+	var items []catalogItem
+	// History is oldest first, and a regenerated summary replaces its entry
+	for _, e := range slices.Backward(entries) {
+		if e.OutputPath == "" || seen[e.OutputPath] {
+			continue
+		}
+		seen[e.OutputPath] = true
+		if _, err := os.Stat(e.OutputPath); err != nil {
+			continue
+		}
+
+		path, err := filepath.Rel(outputDir, e.OutputPath)
+		if err != nil {
+			path = e.OutputPath
+		}
+		title := e.Title
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(e.OutputPath), filepath.Ext(e.OutputPath))
+		}
+		items = append(items, catalogItem{
+			Title: title,
+			URL:   e.URL,
+			Path:  filepath.ToSlash(path),
+			Date:  e.FinishedAt,
+			Type:  e.ContentType,
+			Feed:  e.Feed,
+			Tags:  e.Tags,
+		})
+	}
+	slices.SortStableFunc(items, func(a, b catalogItem) int { return b.Date.Compare(a.Date) })
+	return items
+}
+
+// writeMarkdownCatalog writes the index as a Markdown list grouped by month
+func writeMarkdownCatalog(path string, items []catalogItem) error {
+	if !isCatalog(path) {
+		return fmt.Errorf("%s is not a summary index, leaving it alone", path)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n# Summaries\n\n%d summaries, updated %s\n", catalogMarker, len(items), time.Now().Format("2006-01-02 15:04"))
+	month := ""
+	for _, item := range items {
+		if m := item.Date.Format("January 2006"); m != month {
+			month = m
+			fmt.Fprintf(&b, "\n## %s\n\n", month)
+		}
+		link := (&url.URL{Path: item.Path}).String()
+		fmt.Fprintf(&b, "- %s [%s](%s) · %s", item.Date.Format("2006-01-02"), strings.NewReplacer("[", `\[`, "]", `\]`).Replace(item.Title), link, item.Type)
+		if item.URL != "" {
+			fmt.Fprintf(&b, " · [source](%s)", item.URL)
+		}
+		for _, tag := range item.Tags {
+			b.WriteString(" #" + tag)
+		}
+		b.WriteString("\n")
+	}
+	return writeFileAtomic(path, []byte(b.String()))
+}
+
+// writeJSONCatalog writes the index as JSON for other tools
+func writeJSONCatalog(path string, items []catalogItem) error {
+	if data, err := os.ReadFile(path); err == nil {
+		var previous struct {
+			Summaries json.RawMessage `json:"summaries"`
+		}
+		if json.Unmarshal(data, &previous) != nil || previous.Summaries == nil {
+			return fmt.Errorf("%s is not a summary index, leaving it alone", path)
+		}
+	}
+	if items == nil {
+		items = []catalogItem{}
+	}
+	data, err := json.MarshalIndent(struct {
+		Updated   time.Time     `json:"updated"`
+		Summaries []catalogItem `json:"summaries"`
+	}{time.Now(), items}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// isCatalog reports whether path is missing or a previously written index
+func isCatalog(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	defer f.Close()
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	return strings.TrimSpace(line) == catalogMarker
+}
+
+// writeFileAtomic replaces path with data, so readers never see a partial
+// file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paragraphSeconds is the span of captions grouped under one timestamp
+const paragraphSeconds = 30
+
+// timestampRef matches the "[m:ss]" and "[h:mm:ss]" timestamps a summary
+// cites, and whether they are already a link
+var timestampRef = regexp.MustCompile(`\[((?:\d+:)?\d{1,2}:\d{2})\](\()?`)
+
+// chapter is a chapter marker from the video metadata
+type chapter struct {
+	Start float64 `json:"start_time"`
+	Title string  `json:"title"`
+}
+
+// SetChapters enables structuring transcripts by the video's chapters, with
+// timestamps the summary can cite
+func (y *YouTubeProcessor) SetChapters(enabled bool) {
+	y.useChapters = enabled
+}
+
+// fetchChapters asks yt-dlp for the chapter markers of the video. Videos
+// without chapters, or whose metadata cannot be read, have none.
+func (y *YouTubeProcessor) fetchChapters(ctx context.Context, url string) []chapter {
+	cmd := y.ytdlp(ctx, "--skip-download", "--no-playlist", "--no-warnings", "--print", "%(chapters)j", url)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var chapters []chapter
+	if err := json.Unmarshal(out, &chapters); err != nil {
+		return nil
+	}
+	return chapters
+}
+
+// timedTranscript renders captions as paragraphs starting with their
+// timestamp, under a "##" heading for each chapter
+func timedTranscript(chapters []chapter, cues []cue) string {
+	var b strings.Builder
+	next := 0
+	paragraphStart := -1.0
+	for _, c := range cues {
+		newChapter := false
+		for next < len(chapters) && c.Start >= chapters[next].Start {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			fmt.Fprintf(&b, "## [%s] %s", formatTimestamp(chapters[next].Start), chapters[next].Title)
+			next++
+			newChapter = true
+		}
+		if newChapter || paragraphStart < 0 || c.Start-paragraphStart >= paragraphSeconds {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			fmt.Fprintf(&b, "[%s]", formatTimestamp(c.Start))
+			paragraphStart = c.Start
+		}
+		b.WriteString(" " + c.Text)
+	}
+	return b.String()
+}
+
+// withChapters prepends the chapter list to a transcript without timing,
+// such as a Whisper transcript
+func withChapters(chapters []chapter, transcript string) string {
+	if len(chapters) == 0 {
+		return transcript
+	}
+	var b strings.Builder
+	b.WriteString("=== CHAPTERS ===\n")
+	for _, c := range chapters {
+		fmt.Fprintf(&b, "[%s] %s\n", formatTimestamp(c.Start), c.Title)
+	}
+	b.WriteString("\n=== TRANSCRIPT ===\n")
+	b.WriteString(transcript)
+	return b.String()
+}
+
+func formatTimestamp(seconds float64) string {
+	s := int(seconds)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// linkTimestamps turns the timestamps cited in a summary into links that
+// open the video at that moment
+func linkTimestamps(summary, videoURL string) string {
+	u, err := url.Parse(videoURL)
+	if err != nil {
+		return summary
+	}
+	return timestampRef.ReplaceAllStringFunc(summary, func(match string) string {
+		m := timestampRef.FindStringSubmatch(match)
+		if m[2] != "" {
+			return match
+		}
+		seconds := 0
+		for _, part := range strings.Split(m[1], ":") {
+			v, _ := strconv.Atoi(part)
+			seconds = seconds*60 + v
+		}
+		link := *u
+		query := link.Query()
+		query.Del("t")
+		link.RawQuery = query.Encode()
+		if link.RawQuery != "" {
+			link.RawQuery += "&"
+		}
+		link.RawQuery += fmt.Sprintf("t=%ds", seconds)
+		return fmt.Sprintf("[%s](%s)", m[1], link.String())
+	})
+}
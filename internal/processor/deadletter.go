@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// failedDir holds the input files of dead-lettered jobs, inside the watch
+// directory where the watcher does not look
+func (p *Processor) failedDir() string {
+	return filepath.Join(p.cfg.WatchDir, "failed")
+}
+
+// deadLetter moves a permanently failed job out of the queue into the
+// dead-letter list, and its input file into the failed directory, so neither
+// lingers in the queue or the inbox. Retrying the job moves it back.
+func (p *Processor) deadLetter(job *models.Job) {
+	if job.FilePath != "" && filepath.Dir(job.FilePath) != p.failedDir() {
+		dest := filepath.Join(p.failedDir(), filepath.Base(job.FilePath))
+		if err := os.MkdirAll(p.failedDir(), 0755); err != nil {
+			jobLogger(job).Warn("Failed to create failed directory", "error", err)
+		} else if err := os.Rename(job.FilePath, dest); err != nil {
+			jobLogger(job).Warn("Failed to move input file", "error", err)
+		} else {
+			job.FilePath = dest
+		}
+	}
+
+	if err := p.queue.Update(job); err != nil {
+		return
+	}
+	if err := p.queue.Bury(job.ID); err != nil {
+		jobLogger(job).Warn("Failed to move job to the dead-letter list", "error", err)
+		return
+	}
+	jobLogger(job).Info("Moved job to the dead-letter list", "input", job.FilePath)
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// dedupIndex maps content keys to the summary written for them, so identical
+// submissions can be skipped while their output still exists.
+type dedupIndex struct {
+	mu          sync.Mutex
+	entries     map[string]string
+	persistPath string
+}
+
+func newDedupIndex(persistPath string) *dedupIndex {
+	idx := &dedupIndex{
+		entries:     make(map[string]string),
+		persistPath: persistPath,
+	}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		json.Unmarshal(data, &idx.entries)
+	}
+	return idx
+}
+
+// Lookup returns the output path recorded for key if that file still exists
+func (d *dedupIndex) Lookup(key string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path, ok := d.entries[key]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(path); err != nil {
+		delete(d.entries, key)
+		return "", false
+	}
+	return path, true
+}
+
+func (d *dedupIndex) Add(key, outputPath string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries[key] = outputPath
+	if d.persistPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(d.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.persistPath, data, 0644)
+}
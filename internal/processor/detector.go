@@ -2,6 +2,7 @@ package processor
 
 import (
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/clobrano/briefly/internal/models"
@@ -20,6 +21,41 @@ func DetectContentType(rawURL string) models.ContentType {
 		return models.ContentTypeYouTube
 	}
 
+	// Other video platforms yt-dlp downloads from
+	if isVideoURL(u) {
+		return models.ContentTypeVideo
+	}
+
+	// Twitter/X threads
+	if _, _, ok := parseStatusURL(u); ok {
+		return models.ContentTypeThread
+	}
+
+	// Reddit posts
+	if _, ok := parseRedditURL(u); ok {
+		return models.ContentTypeReddit
+	}
+
+	// GitHub repositories
+	if _, _, ok := parseRepoURL(u); ok {
+		return models.ContentTypeRepository
+	}
+
+	// GitHub issues and pull requests
+	if _, _, _, ok := parseIssueURL(u); ok {
+		return models.ContentTypeIssue
+	}
+
+	// PDF documents
+	if (u.Scheme == "http" || u.Scheme == "https") && isPDFURL(u) {
+		return models.ContentTypePDF
+	}
+
+	// Podcast episodes, feeds, and direct audio links
+	if (u.Scheme == "http" || u.Scheme == "https") && isPodcastURL(u) {
+		return models.ContentTypePodcast
+	}
+
 	// Default to text for any other HTTP(S) URL
 	if u.Scheme == "http" || u.Scheme == "https" {
 		return models.ContentTypeText
@@ -27,3 +63,92 @@ func DetectContentType(rawURL string) models.ContentType {
 
 	return models.ContentTypeUnknown
 }
+
+// videoHosts are video platforms whose pages are videos rather than articles
+var videoHosts = []string{
+	"vimeo.com",
+	"dailymotion.com",
+	"dai.ly",
+	"twitch.tv",
+	"rumble.com",
+	"odysee.com",
+	"bilibili.com",
+	"streamable.com",
+	"bitchute.com",
+}
+
+// peerTubePath matches PeerTube video pages, which live on many domains
+var peerTubePath = regexp.MustCompile(`^/(w|videos/watch)/[A-Za-z0-9-]+$`)
+
+// isVideoURL reports whether u is a video on a platform other than YouTube.
+// Video pages elsewhere, such as on news sites, are found by probing with
+// yt-dlp before processing.
+func isVideoURL(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	for _, h := range videoHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	if host == "ted.com" && strings.HasPrefix(u.Path, "/talks/") {
+		return true
+	}
+	return peerTubePath.MatchString(u.Path)
+}
+
+// trackingParams are query parameters that do not change the content
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "mc_cid": true, "mc_eid": true,
+	"ref": true, "ref_src": true, "si": true, "feature": true,
+}
+
+// NormalizeURL returns a canonical form of rawURL, so the same content
+// submitted through different links is recognized: scheme and host are
+// lower-cased without "www.", the fragment, tracking parameters, and a
+// trailing slash are dropped, query parameters are sorted, and YouTube links
+// reduce to their video ID.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	if _, id, ok := parseStatusURL(u); ok {
+		return "tweet:" + id
+	}
+	if id, ok := parseRedditURL(u); ok {
+		return "reddit:" + id
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	if host == "youtu.be" {
+		return "youtube:" + strings.Trim(u.Path, "/")
+	}
+	if host == "youtube.com" {
+		if id := u.Query().Get("v"); id != "" {
+			return "youtube:" + id
+		}
+		if id, ok := strings.CutPrefix(u.Path, "/shorts/"); ok {
+			return "youtube:" + strings.Trim(id, "/")
+		}
+	}
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+
+	n := url.URL{
+		Scheme:   strings.ToLower(u.Scheme),
+		Host:     host,
+		Path:     strings.TrimSuffix(u.EscapedPath(), "/"),
+		RawQuery: query.Encode(),
+	}
+	if port := u.Port(); port != "" {
+		n.Host += ":" + port
+	}
+	return n.String()
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+// ErrNoContent is returned when a source yields no text to summarize
+var ErrNoContent = errors.New("no text content extracted")
+
+// codedError attaches an error code to a job failure
+type codedError struct {
+	code models.ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+func withCode(code models.ErrorCode, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the code attached to err, if any
+func errorCode(err error) models.ErrorCode {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return models.ErrorInternal
+}
+
+// extractionError classifies a failure to fetch or extract content
+func extractionError(ctx context.Context, err error) error {
+	switch {
+	case isTimeout(ctx, err):
+		return withCode(models.ErrorTimeout, err)
+	case errors.Is(err, ErrNoContent):
+		return withCode(models.ErrorExtractionEmpty, err)
+	}
+	return withCode(models.ErrorDownloadFailed, err)
+}
+
+// providerError classifies a failed summarization request
+func providerError(ctx context.Context, err error) error {
+	switch {
+	case isTimeout(ctx, err):
+		return withCode(models.ErrorTimeout, err)
+	case isRateLimit(err):
+		return withCode(models.ErrorRateLimited, err)
+	case isRejected(err):
+		return withCode(models.ErrorRejected, err)
+	}
+	return withCode(models.ErrorProviderError, err)
+}
+
+// isRejected recognizes client errors of the providers, such as an invalid
+// API key or request, which repeating the request would not fix
+func isRejected(err error) bool {
+	status := summarizer.StatusCode(err)
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+func isTimeout(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded
+}
+
+// isRateLimit recognizes the rate limit and quota errors of the providers,
+// which the SDKs report with different types
+func isRateLimit(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"429", "rate limit", "rate_limit", "too many requests", "resource_exhausted", "quota"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"strings"
+)
+
+// whisperModels are the Whisper model sizes from lightest to heaviest
+var whisperModels = []string{"tiny", "base", "small", "medium", "large"}
+
+type lightPipelineKey struct{}
+
+// withLightPipeline asks the transcription pipeline to go level steps
+// lighter than configured: captions are always tried first, and Whisper
+// runs with a model level sizes smaller.
+func withLightPipeline(ctx context.Context, level int) context.Context {
+	if level <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, lightPipelineKey{}, level)
+}
+
+func lightPipelineFrom(ctx context.Context) int {
+	level, _ := ctx.Value(lightPipelineKey{}).(int)
+	return level
+}
+
+// lighterWhisperModel returns the model steps sizes smaller than model,
+// keeping the English-only variant. Unknown models ("turbo", custom paths)
+// fall back to "base".
+func lighterWhisperModel(model string, steps int) string {
+	if steps <= 0 {
+		return model
+	}
+
+	name, english := strings.CutSuffix(model, ".en")
+	index := -1
+	for i, m := range whisperModels {
+		if name == m || strings.HasPrefix(name, m+"-") {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return "base"
+	}
+
+	index -= steps
+	if index < 0 {
+		index = 0
+	}
+	lighter := whisperModels[index]
+	if english && lighter != "large" {
+		lighter += ".en"
+	}
+	return lighter
+}
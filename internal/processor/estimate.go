@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+// typicalJob is the assumed duration and input size of a content type
+// before any job of that type is in the history
+type typicalJob struct {
+	duration    time.Duration
+	inputTokens int64
+}
+
+var typicalJobs = map[models.ContentType]typicalJob{
+	models.ContentTypeYouTube:    {5 * time.Minute, 15000},
+	models.ContentTypeVideo:      {5 * time.Minute, 15000},
+	models.ContentTypePodcast:    {10 * time.Minute, 25000},
+	models.ContentTypePDF:        {time.Minute, 12000},
+	models.ContentTypeText:       {30 * time.Second, 4000},
+	models.ContentTypeThread:     {30 * time.Second, 3000},
+	models.ContentTypeReddit:     {30 * time.Second, 5000},
+	models.ContentTypeRepository: {30 * time.Second, 8000},
+	models.ContentTypeIssue:      {30 * time.Second, 6000},
+	models.ContentTypeMedia:      {10 * time.Minute, 20000},
+}
+
+// estimatedOutputTokens is the assumed length of a summary
+const estimatedOutputTokens = 1000
+
+// Estimator predicts the processing time and cost of queued jobs, using the
+// average duration of past jobs of the same type where the history has them
+type Estimator struct {
+	model     string
+	durations map[models.ContentType]time.Duration
+}
+
+// NewEstimator returns an estimator for jobs summarized with model by
+// default. h may be nil.
+func NewEstimator(model string, h *history.Store) *Estimator {
+	e := &Estimator{model: model, durations: make(map[models.ContentType]time.Duration)}
+	if h == nil {
+		return e
+	}
+
+	entries, err := h.Query(history.Filter{Status: models.JobStatusCompleted})
+	if err != nil {
+		return e
+	}
+	totals := make(map[models.ContentType]time.Duration)
+	counts := make(map[models.ContentType]int)
+	for _, entry := range entries {
+		if d := entry.Duration(); d > 0 {
+			totals[entry.ContentType] += d
+			counts[entry.ContentType]++
+		}
+	}
+	for ct, total := range totals {
+		e.durations[ct] = total / time.Duration(counts[ct])
+	}
+	return e
+}
+
+// Estimate returns the expected processing time and cost in USD of job
+func (e *Estimator) Estimate(job models.Job) (time.Duration, float64) {
+	contentType := job.ContentType
+	if contentType == models.ContentTypeUnknown && !job.IsDirectText {
+		contentType = DetectContentType(job.URL)
+	}
+
+	typical, ok := typicalJobs[contentType]
+	if !ok {
+		typical = typicalJobs[models.ContentTypeText]
+	}
+	if job.IsDirectText {
+		typical.inputTokens = int64(len(job.Text) / 4)
+	}
+	duration := typical.duration
+	if d, ok := e.durations[contentType]; ok {
+		duration = d
+	}
+
+	model := e.model
+	if job.Model != "" {
+		model = job.Model
+	}
+	return duration, summarizer.EstimateCost(model, typical.inputTokens, estimatedOutputTokens)
+}
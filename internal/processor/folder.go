@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// folderExtensions are the note files read from a folder
+var folderExtensions = map[string]bool{".md": true, ".markdown": true, ".txt": true}
+
+// ReadFolder concatenates the markdown and text files in dir in name order,
+// each under a heading with its relative path and modification date, and
+// returns the content with the paths read. Hidden files and directories are
+// skipped; subdirectories are read only when recursive is set.
+func ReadFolder(dir string, recursive bool) (string, []string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if folderExtensions[strings.ToLower(filepath.Ext(path))] {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read folder: %w", err)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	var read []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", nil, err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(&b, "# %s (%s)\n\n%s\n\n", rel, info.ModTime().Format("2006-01-02"), text)
+		read = append(read, rel)
+	}
+	if len(read) == 0 {
+		return "", nil, fmt.Errorf("%w: no markdown or text files in %s", ErrNoContent, dir)
+	}
+	return b.String(), read, nil
+}
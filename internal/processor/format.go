@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Output formats of summary files
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+var formatExtensions = map[string]string{
+	FormatMarkdown: ".md",
+	FormatJSON:     ".json",
+	FormatHTML:     ".html",
+	FormatText:     ".txt",
+}
+
+// ParseOutputFormat validates an output format name; empty is Markdown
+func ParseOutputFormat(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "", "md":
+		return FormatMarkdown, nil
+	case "txt", "plain":
+		return FormatText, nil
+	}
+	if _, ok := formatExtensions[name]; !ok {
+		return "", fmt.Errorf("unknown output format %q, expected markdown, json, html, or text", name)
+	}
+	return name, nil
+}
+
+// formatFor returns the output format of job, its own or the configured one
+func (p *Processor) formatFor(job *models.Job) (string, error) {
+	if job.OutputFormat != "" {
+		return ParseOutputFormat(job.OutputFormat)
+	}
+	return ParseOutputFormat(p.cfg.OutputFormat)
+}
+
+// outputExtension is the file extension of job's summary
+func (p *Processor) outputExtension(job *models.Job) string {
+	format, err := p.formatFor(job)
+	if err != nil {
+		return formatExtensions[FormatMarkdown]
+	}
+	return formatExtensions[format]
+}
+
+// jsonSummary is the document written by the JSON output format
+type jsonSummary struct {
+	Title        string             `json:"title"`
+	URL          string             `json:"url,omitempty"`
+	Source       string             `json:"source"`
+	Type         models.ContentType `json:"type"`
+	Provider     string             `json:"provider"`
+	Model        string             `json:"model"`
+	Feed         string             `json:"feed,omitempty"`
+	Language     string             `json:"language,omitempty"`
+	Tags         []string           `json:"tags,omitempty"`
+	Prompt       string             `json:"prompt"`
+	Generated    time.Time          `json:"generated"`
+	Summary      string             `json:"summary"`
+	Notes        string             `json:"notes,omitempty"`
+	InputTokens  int64              `json:"input_tokens,omitempty"`
+	OutputTokens int64              `json:"output_tokens,omitempty"`
+	CostUSD      float64            `json:"cost_usd,omitempty"`
+}
+
+// renderJSON writes the summary, in Markdown, with its properties as JSON
+func renderJSON(job *models.Job, data OutputData) (string, error) {
+	out, err := json.MarshalIndent(jsonSummary{
+		Title:        data.Heading,
+		URL:          job.URL,
+		Source:       summarySource(job),
+		Type:         job.ContentType,
+		Provider:     data.Provider,
+		Model:        data.Model,
+		Feed:         job.Feed,
+		Language:     job.Language,
+		Tags:         job.Tags,
+		Prompt:       data.Prompt,
+		Generated:    data.Generated,
+		Summary:      job.Summary,
+		Notes:        job.Notes,
+		InputTokens:  job.InputTokens,
+		OutputTokens: job.OutputTokens,
+		CostUSD:      job.CostUSD,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+const htmlStyle = `body{font-family:system-ui,sans-serif;line-height:1.6;max-width:46rem;margin:2rem auto;padding:0 1rem;color:#222}` +
+	`.meta{color:#666;font-size:.9rem}pre{background:#f5f5f5;padding:.75rem;overflow-x:auto}` +
+	`blockquote{border-left:3px solid #ccc;margin-left:0;padding-left:1rem;color:#555}`
+
+// renderHTML writes the summary as a standalone HTML page
+func renderHTML(job *models.Job, data OutputData) string {
+	var b strings.Builder
+	lang := cmp.Or(job.SummaryLanguage, job.Language, "en")
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", html.EscapeString(lang))
+	fmt.Fprintf(&b, "<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>%s</title>\n", html.EscapeString(data.Heading))
+	fmt.Fprintf(&b, "<style>%s</style>\n</head>\n<body>\n<article>\n<h1>%s</h1>\n", htmlStyle, html.EscapeString(data.Heading))
+
+	source := html.EscapeString(summarySource(job))
+	if job.URL != "" && !job.IsDirectText {
+		source = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(job.URL), source)
+	}
+	fmt.Fprintf(&b, "<p class=\"meta\">%s · %s · %s/%s · %s</p>\n", source, html.EscapeString(string(job.ContentType)),
+		html.EscapeString(data.Provider), html.EscapeString(data.Model), data.Generated.Format(time.RFC3339))
+
+	b.WriteString(markdownToHTML(job.Summary))
+	if job.Notes != "" {
+		b.WriteString("<h2>My notes</h2>\n")
+		b.WriteString(markdownToHTML(job.Notes))
+	}
+	b.WriteString("</article>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// renderPlainText writes the summary as plain text without Markdown syntax
+func renderPlainText(job *models.Job, data OutputData) string {
+	var b strings.Builder
+	b.WriteString(data.Heading + "\n")
+	b.WriteString(strings.Repeat("=", len([]rune(data.Heading))) + "\n\n")
+	fmt.Fprintf(&b, "Source: %s\nType: %s\nModel: %s/%s\nGenerated: %s\n\n",
+		summarySource(job), job.ContentType, data.Provider, data.Model, data.Generated.Format(time.RFC3339))
+	b.WriteString(markdownToText(job.Summary) + "\n")
+	if job.Notes != "" {
+		b.WriteString("\nMY NOTES\n\n" + markdownToText(job.Notes) + "\n")
+	}
+	return b.String()
+}
+
+// summarySource describes where the content of job came from
+func summarySource(job *models.Job) string {
+	switch {
+	case job.IsDirectText:
+		return "direct text"
+	case job.ContentType == models.ContentTypeMedia:
+		return filepath.Base(job.FilePath)
+	}
+	return job.URL
+}
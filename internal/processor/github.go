@@ -0,0 +1,410 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRepoTree bounds the file tree listed for a repository
+	maxRepoTree = 200
+	// maxRepoTreeDepth is the deepest directory level listed
+	maxRepoTreeDepth = 2
+	// maxReleaseNotes bounds the text kept from each release
+	maxReleaseNotes = 2000
+)
+
+// parseRepoURL returns the owner and name of the GitHub repository at u.
+// Only links to the repository itself match, so links to files are still
+// read as web pages.
+func parseRepoURL(u *url.URL) (owner, repo string, ok bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if host != "github.com" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 && !(len(parts) >= 3 && parts[2] == "tree") {
+		return "", "", false
+	}
+	owner, repo = parts[0], strings.TrimSuffix(parts[1], ".git")
+	if owner == "" || repo == "" {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+// GitHubExtractor describes a repository from its metadata, README, file
+// tree, and recent releases, fetched through the GitHub API
+type GitHubExtractor struct {
+	client *http.Client
+	token  string
+}
+
+// NewGitHubExtractor authenticates with token when set, which raises the
+// rate limit and gives access to private repositories
+func NewGitHubExtractor(token string) *GitHubExtractor {
+	return &GitHubExtractor{
+		client: &http.Client{Timeout: 30 * time.Second},
+		token:  token,
+	}
+}
+
+type githubRepo struct {
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	Homepage      string    `json:"homepage"`
+	Language      string    `json:"language"`
+	Topics        []string  `json:"topics"`
+	Stars         int       `json:"stargazers_count"`
+	Forks         int       `json:"forks_count"`
+	OpenIssues    int       `json:"open_issues_count"`
+	Archived      bool      `json:"archived"`
+	Fork          bool      `json:"fork"`
+	DefaultBranch string    `json:"default_branch"`
+	CreatedAt     time.Time `json:"created_at"`
+	PushedAt      time.Time `json:"pushed_at"`
+	License       *struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
+}
+
+type githubRelease struct {
+	Name        string    `json:"name"`
+	TagName     string    `json:"tag_name"`
+	PublishedAt time.Time `json:"published_at"`
+	Body        string    `json:"body"`
+	Prerelease  bool      `json:"prerelease"`
+}
+
+func (e *GitHubExtractor) Extract(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	owner, name, ok := parseRepoURL(u)
+	if !ok {
+		return "", fmt.Errorf("not a GitHub repository URL: %s", rawURL)
+	}
+	base := "/repos/" + owner + "/" + name
+
+	var repo githubRepo
+	if err := e.getJSON(ctx, base, &repo); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	writeRepoFacts(&b, repo)
+
+	// The rest is optional: a repository may have no README or releases
+	readme, err := e.get(ctx, base+"/readme", "application/vnd.github.raw")
+	if err != nil {
+		slog.Debug("No README", "repo", repo.FullName, "error", err)
+	} else {
+		fmt.Fprintf(&b, "\n## README\n\n%s\n", strings.TrimSpace(string(readme)))
+	}
+
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := e.getJSON(ctx, base+"/git/trees/"+url.PathEscape(repo.DefaultBranch)+"?recursive=1", &tree); err != nil {
+		slog.Debug("No file tree", "repo", repo.FullName, "error", err)
+	} else {
+		b.WriteString("\n## Files\n\n")
+		listed := 0
+		for _, entry := range tree.Tree {
+			if strings.Count(entry.Path, "/") >= maxRepoTreeDepth {
+				continue
+			}
+			if listed == maxRepoTree {
+				b.WriteString("...\n")
+				break
+			}
+			if entry.Type == "tree" {
+				entry.Path += "/"
+			}
+			b.WriteString(entry.Path + "\n")
+			listed++
+		}
+	}
+
+	var releases []githubRelease
+	if err := e.getJSON(ctx, base+"/releases?per_page=5", &releases); err != nil {
+		slog.Debug("No releases", "repo", repo.FullName, "error", err)
+	} else if len(releases) > 0 {
+		b.WriteString("\n## Recent releases\n")
+		for _, r := range releases {
+			title := r.TagName
+			if r.Name != "" && r.Name != r.TagName {
+				title += " - " + r.Name
+			}
+			if r.Prerelease {
+				title += " (pre-release)"
+			}
+			notes := strings.TrimSpace(r.Body)
+			if len(notes) > maxReleaseNotes {
+				notes = notes[:maxReleaseNotes] + "..."
+			}
+			fmt.Fprintf(&b, "\n### %s, %s\n\n%s\n", title, r.PublishedAt.Format("2006-01-02"), notes)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// writeRepoFacts writes the repository metadata that tells how mature and
+// active a project is
+func writeRepoFacts(b *strings.Builder, repo githubRepo) {
+	fmt.Fprintf(b, "# %s\n\n", repo.FullName)
+	if repo.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", repo.Description)
+	}
+	fmt.Fprintf(b, "- Stars: %d, forks: %d, open issues and pull requests: %d\n", repo.Stars, repo.Forks, repo.OpenIssues)
+	fmt.Fprintf(b, "- Created: %s, last push: %s\n", repo.CreatedAt.Format("2006-01-02"), repo.PushedAt.Format("2006-01-02"))
+	if repo.Language != "" {
+		fmt.Fprintf(b, "- Language: %s\n", repo.Language)
+	}
+	if repo.License != nil && repo.License.SPDXID != "" {
+		fmt.Fprintf(b, "- License: %s\n", repo.License.SPDXID)
+	}
+	if len(repo.Topics) > 0 {
+		fmt.Fprintf(b, "- Topics: %s\n", strings.Join(repo.Topics, ", "))
+	}
+	if repo.Homepage != "" {
+		fmt.Fprintf(b, "- Homepage: %s\n", repo.Homepage)
+	}
+	if repo.Archived {
+		b.WriteString("- The repository is archived (read-only)\n")
+	}
+	if repo.Fork {
+		b.WriteString("- The repository is a fork\n")
+	}
+}
+
+func (e *GitHubExtractor) getJSON(ctx context.Context, path string, out any) error {
+	data, err := e.get(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (e *GitHubExtractor) get(ctx context.Context, path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// Private repositories are reported as missing without a token
+		return nil, errors.New("GitHub repository not found (private repositories need BRIEFLY_GITHUB_TOKEN)")
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return nil, errors.New("GitHub API rate limit reached (set BRIEFLY_GITHUB_TOKEN for a higher limit)")
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseIssueURL returns the repository and number of the GitHub issue or
+// pull request at u
+func parseIssueURL(u *url.URL) (owner, repo, number string, ok bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if host != "github.com" {
+		return "", "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || (parts[2] != "issues" && parts[2] != "pull") {
+		return "", "", "", false
+	}
+	if _, err := strconv.Atoi(parts[3]); err != nil {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[3], true
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubComment struct {
+	User      githubUser `json:"user"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	// Path is set on review comments, which are attached to a file
+	Path string `json:"path"`
+	// State is set on reviews: APPROVED, CHANGES_REQUESTED, COMMENTED
+	State string `json:"state"`
+}
+
+// ExtractIssue builds a briefing input from an issue or pull request: its
+// description and comment thread, plus for pull requests the diff stats,
+// reviews, and review comments
+func (e *GitHubExtractor) ExtractIssue(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	owner, name, number, ok := parseIssueURL(u)
+	if !ok {
+		return "", fmt.Errorf("not a GitHub issue or pull request URL: %s", rawURL)
+	}
+	base := "/repos/" + owner + "/" + name
+
+	var issue struct {
+		Title     string     `json:"title"`
+		User      githubUser `json:"user"`
+		State     string     `json:"state"`
+		Body      string     `json:"body"`
+		CreatedAt time.Time  `json:"created_at"`
+		Labels    []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	if err := e.getJSON(ctx, base+"/issues/"+number, &issue); err != nil {
+		return "", err
+	}
+
+	kind := "Issue"
+	if issue.PullRequest != nil {
+		kind = "Pull request"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s/%s#%s: %s\n\n", kind, owner, name, number, issue.Title)
+	fmt.Fprintf(&b, "- Opened by %s on %s, state: %s\n", issue.User.Login, issue.CreatedAt.Format("2006-01-02"), issue.State)
+	if len(issue.Labels) > 0 {
+		labels := make([]string, 0, len(issue.Labels))
+		for _, l := range issue.Labels {
+			labels = append(labels, l.Name)
+		}
+		fmt.Fprintf(&b, "- Labels: %s\n", strings.Join(labels, ", "))
+	}
+
+	if issue.PullRequest != nil {
+		if err := e.writePullRequest(ctx, &b, base+"/pulls/"+number); err != nil {
+			return "", err
+		}
+	}
+
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		fmt.Fprintf(&b, "\n## Description\n\n%s\n", body)
+	}
+
+	var comments []githubComment
+	if err := e.getJSON(ctx, base+"/issues/"+number+"/comments?per_page=100", &comments); err != nil {
+		return "", err
+	}
+	if issue.PullRequest != nil {
+		// Reviews and review comments are separate threads on pull requests
+		var reviews, reviewComments []githubComment
+		if err := e.getJSON(ctx, base+"/pulls/"+number+"/reviews?per_page=100", &reviews); err != nil {
+			return "", err
+		}
+		if err := e.getJSON(ctx, base+"/pulls/"+number+"/comments?per_page=100", &reviewComments); err != nil {
+			return "", err
+		}
+		comments = append(comments, reviews...)
+		comments = append(comments, reviewComments...)
+		slices.SortFunc(comments, func(a, c githubComment) int { return a.CreatedAt.Compare(c.CreatedAt) })
+	}
+	writeComments(&b, comments)
+
+	return b.String(), nil
+}
+
+// writePullRequest writes the branches, merge state, and diff stats of a
+// pull request
+func (e *GitHubExtractor) writePullRequest(ctx context.Context, b *strings.Builder, path string) error {
+	var pr struct {
+		Merged       bool `json:"merged"`
+		Draft        bool `json:"draft"`
+		Commits      int  `json:"commits"`
+		Additions    int  `json:"additions"`
+		Deletions    int  `json:"deletions"`
+		ChangedFiles int  `json:"changed_files"`
+		Base         struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Head struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	}
+	if err := e.getJSON(ctx, path, &pr); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "- Merges %s into %s", pr.Head.Ref, pr.Base.Ref)
+	switch {
+	case pr.Merged:
+		b.WriteString(" (merged)")
+	case pr.Draft:
+		b.WriteString(" (draft)")
+	}
+	fmt.Fprintf(b, "\n- %d commits, %d files changed, +%d -%d\n", pr.Commits, pr.ChangedFiles, pr.Additions, pr.Deletions)
+
+	var files []struct {
+		Filename  string `json:"filename"`
+		Status    string `json:"status"`
+		Additions int    `json:"additions"`
+		Deletions int    `json:"deletions"`
+	}
+	if err := e.getJSON(ctx, path+"/files?per_page=100", &files); err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		b.WriteString("\n## Changed files\n\n")
+		for _, f := range files {
+			fmt.Fprintf(b, "- %s (%s, +%d -%d)\n", f.Filename, f.Status, f.Additions, f.Deletions)
+		}
+	}
+	return nil
+}
+
+func writeComments(b *strings.Builder, comments []githubComment) {
+	var written int
+	for _, c := range comments {
+		body := strings.TrimSpace(c.Body)
+		// Approvals without a message still matter for the review state
+		if body == "" && (c.State == "" || c.State == "COMMENTED") {
+			continue
+		}
+		if written == 0 {
+			b.WriteString("\n## Discussion\n")
+		}
+		written++
+
+		header := fmt.Sprintf("%s on %s", c.User.Login, c.CreatedAt.Format("2006-01-02"))
+		switch {
+		case c.State != "":
+			header += fmt.Sprintf(" (review: %s)", strings.ToLower(strings.ReplaceAll(c.State, "_", " ")))
+		case c.Path != "":
+			header += fmt.Sprintf(" (on %s)", c.Path)
+		}
+		fmt.Fprintf(b, "\n%s:\n%s\n", header, body)
+	}
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// listItem matches a bullet or numbered list item with its indent
+	listItem = regexp.MustCompile(`^(\s*)([-*+]|(\d+)[.)])\s+(.*)$`)
+	// boldSpan, italicSpan, and linkSpan match inline Markdown
+	boldSpan   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicSpan = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	linkSpan   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// listLevel is an open list while rendering HTML
+type listLevel struct {
+	tag    string
+	indent int
+}
+
+// markdownToHTML renders the Markdown subset summaries use: headings,
+// paragraphs, nested lists, block quotes, rules, code blocks, and inline
+// emphasis, code, and links
+func markdownToHTML(md string) string {
+	var b strings.Builder
+	var paragraph []string
+	var lists []listLevel
+	inCode, blank := false, false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			fmt.Fprintf(&b, "<p>%s</p>\n", inlineHTML(strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+	closeLists := func(indent int) {
+		for len(lists) > 0 && lists[len(lists)-1].indent >= indent {
+			fmt.Fprintf(&b, "</li>\n</%s>\n", lists[len(lists)-1].tag)
+			lists = lists[:len(lists)-1]
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		afterBlank := blank
+		blank = trimmed == "" && !inCode
+		if fenceLine.MatchString(line) {
+			flush()
+			closeLists(0)
+			if inCode {
+				b.WriteString("</code></pre>\n")
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		if m := listItem.FindStringSubmatch(line); m != nil {
+			flush()
+			indent, tag := len(m[1]), "ul"
+			if m[3] != "" {
+				tag = "ol"
+			}
+			for len(lists) > 0 && indent < lists[len(lists)-1].indent {
+				closeLists(lists[len(lists)-1].indent)
+			}
+			switch top := len(lists) - 1; {
+			case top >= 0 && indent == lists[top].indent && tag == lists[top].tag:
+				b.WriteString("</li>\n")
+			case top >= 0 && indent == lists[top].indent:
+				closeLists(indent)
+				fallthrough
+			default:
+				if tag == "ol" && m[3] != "1" {
+					fmt.Fprintf(&b, "<ol start=\"%s\">\n", m[3])
+				} else {
+					fmt.Fprintf(&b, "<%s>\n", tag)
+				}
+				lists = append(lists, listLevel{tag: tag, indent: indent})
+			}
+			fmt.Fprintf(&b, "<li>%s", inlineHTML(m[4]))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case headingLine.MatchString(line):
+			flush()
+			closeLists(0)
+			m := headingLine.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, inlineHTML(strings.TrimSpace(m[2])), level)
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flush()
+			closeLists(0)
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			closeLists(0)
+			fmt.Fprintf(&b, "<blockquote><p>%s</p></blockquote>\n", inlineHTML(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+		case len(lists) > 0 && !afterBlank:
+			// A continuation line of the current list item
+			fmt.Fprintf(&b, " %s", inlineHTML(trimmed))
+		default:
+			closeLists(0)
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+	closeLists(0)
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	return b.String()
+}
+
+// inlineHTML escapes text and renders its code spans, emphasis, and links
+func inlineHTML(text string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "`") {
+		// Odd parts are inside backticks; an unclosed one stays literal
+		if i%2 == 1 && i < strings.Count(text, "`") {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		part = html.EscapeString(part)
+		part = linkSpan.ReplaceAllString(part, `<a href="$2">$1</a>`)
+		part = boldSpan.ReplaceAllString(part, "<strong>$1</strong>")
+		part = italicSpan.ReplaceAllString(part, "<em>$1</em>")
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// markdownToText removes the Markdown syntax of text, keeping its line
+// structure and list markers
+func markdownToText(md string) string {
+	var out []string
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		if fenceLine.MatchString(line) {
+			continue
+		}
+		if m := headingLine.FindStringSubmatch(line); m != nil {
+			line = strings.ToUpper(strings.TrimSpace(m[2]))
+		}
+		line = linkSpan.ReplaceAllString(line, "$1 ($2)")
+		line = boldSpan.ReplaceAllString(line, "$1")
+		line = italicSpan.ReplaceAllString(line, "$1")
+		out = append(out, strings.ReplaceAll(line, "`", ""))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/summarizer"
+)
+
+const (
+	// maxImageSize bounds each image attached for vision models
+	maxImageSize = 5 << 20
+	// minImageSize skips icons, avatars, and tracking pixels
+	minImageSize = 10 << 10
+)
+
+// imgSrc matches the source of <img> elements in the extracted article
+var imgSrc = regexp.MustCompile(`(?i)<img[^>]+src="([^"]+)"`)
+
+// visionMIMETypes are the image formats accepted by vision-capable providers
+var visionMIMETypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// articleImages returns the URLs of the images in an article's HTML, lead
+// image first, without duplicates
+func articleImages(lead, content string) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	add := func(u string) {
+		u = strings.ReplaceAll(u, "&amp;", "&")
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") || seen[u] {
+			return
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+
+	if lead != "" {
+		add(lead)
+	}
+	for _, m := range imgSrc.FindAllStringSubmatch(content, -1) {
+		add(m[1])
+	}
+	return urls
+}
+
+// downloadImages fetches up to max of the given images, skipping those that
+// fail, are too small to matter, or are in a format models cannot read
+func (t *TextExtractor) downloadImages(ctx context.Context, urls []string, max int) []summarizer.Document {
+	var images []summarizer.Document
+	for _, u := range urls {
+		if len(images) >= max {
+			break
+		}
+		img, err := t.downloadImage(ctx, u)
+		if err != nil {
+			continue
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+func (t *TextExtractor) downloadImage(ctx context.Context, rawURL string) (summarizer.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return summarizer.Document{}, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return summarizer.Document{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return summarizer.Document{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	mimeType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if !visionMIMETypes[mimeType] {
+		return summarizer.Document{}, fmt.Errorf("unsupported image type %q", mimeType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return summarizer.Document{}, err
+	}
+	if len(data) < minImageSize || len(data) > maxImageSize {
+		return summarizer.Document{}, fmt.Errorf("image size %d out of range", len(data))
+	}
+
+	return summarizer.Document{Data: data, MIMEType: mimeType}, nil
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// layoutDirectives expand the strftime-like directives of an output layout
+var layoutDirectives = map[byte]func(t time.Time) string{
+	'Y': func(t time.Time) string { return t.Format("2006") },
+	'y': func(t time.Time) string { return t.Format("06") },
+	'm': func(t time.Time) string { return t.Format("01") },
+	'd': func(t time.Time) string { return t.Format("02") },
+	'H': func(t time.Time) string { return t.Format("15") },
+	'B': func(t time.Time) string { return t.Format("January") },
+	'b': func(t time.Time) string { return t.Format("Jan") },
+	'j': func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) },
+	'V': func(t time.Time) string { _, week := t.ISOWeek(); return fmt.Sprintf("%02d", week) },
+	'G': func(t time.Time) string { year, _ := t.ISOWeek(); return fmt.Sprint(year) },
+	'%': func(time.Time) string { return "%" },
+}
+
+// ValidateOutputLayout checks that layout, such as "%Y/%m", only uses known
+// directives and stays inside the output directory
+func ValidateOutputLayout(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	if filepath.IsAbs(layout) || strings.HasPrefix(layout, "/") {
+		return fmt.Errorf("output layout %q must be relative to the output directory", layout)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(layout), "/") {
+		if part == ".." {
+			return fmt.Errorf("output layout %q must stay inside the output directory", layout)
+		}
+		if localFilename(part) != part {
+			return fmt.Errorf("output layout %q has a folder name not allowed on this system", layout)
+		}
+	}
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			continue
+		}
+		if i+1 == len(layout) || layoutDirectives[layout[i+1]] == nil {
+			return fmt.Errorf("output layout %q has an unknown directive, expected %%Y, %%y, %%m, %%d, %%H, %%B, %%b, %%j, %%V, %%G, or %%%%", layout)
+		}
+		i++
+	}
+	return nil
+}
+
+// expandLayout returns the subdirectory layout names for a summary created
+// at t; unknown directives are kept as is
+func expandLayout(layout string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] == '%' && i+1 < len(layout) {
+			if expand := layoutDirectives[layout[i+1]]; expand != nil {
+				b.WriteString(expand(t))
+				i++
+				continue
+			}
+		}
+		b.WriteByte(layout[i])
+	}
+	return filepath.FromSlash(b.String())
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// wakeForExpiredLeases wakes the workers periodically so jobs whose lease
+// expired are picked up even when nothing new is queued
+func (p *Processor) wakeForExpiredLeases(ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.queue.Notify()
+		}
+	}
+}
+
+// keepLease renews the queue lease on job while it is processed, and
+// cancels the job if another worker has taken it over. The returned
+// function stops the renewal.
+func (p *Processor) keepLease(job *models.Job, cancel context.CancelFunc) func() {
+	ttl := p.queue.LeaseTTL()
+	if ttl <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := p.queue.Heartbeat(job)
+				if errors.Is(err, queue.ErrLeaseLost) {
+					jobLogger(job).Warn("Lease lost to another worker, abandoning job")
+					cancel()
+					return
+				}
+				if err != nil {
+					jobLogger(job).Warn("Failed to renew lease", "error", err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
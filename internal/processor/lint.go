@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// fenceLine matches the opening or closing line of a fenced code block
+	fenceLine = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	// headingLine matches an ATX heading
+	headingLine = regexp.MustCompile(`^ {0,3}(#{1,6})(\s+.*|$)`)
+	// wrappedResponse matches a whole response wrapped in a markdown fence
+	wrappedResponse = regexp.MustCompile("(?s)^```(?:markdown|md)?\\s*\\n(.*)\\n```\\s*$")
+)
+
+// summaryHeadingLevel is the shallowest heading level of the summary text,
+// which sits below the "# Title" heading of the summary file
+const summaryHeadingLevel = 2
+
+// lintMarkdown normalizes generated markdown so it renders cleanly in
+// Obsidian: it unwraps a response wrapped in a code fence, closes unbalanced
+// code fences, nests headings below the file title without skipped levels,
+// keeps "---" lines from turning the paragraph above into a heading, and
+// collapses runs of blank lines.
+func lintMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if m := wrappedResponse.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+		text = m[1]
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+
+	// The shallowest heading outside code blocks sets the shift
+	minLevel := 0
+	forEachProse(lines, func(i int) {
+		if m := headingLine.FindStringSubmatch(lines[i]); m != nil && (minLevel == 0 || len(m[1]) < minLevel) {
+			minLevel = len(m[1])
+		}
+	})
+
+	var out []string
+	prevLevel := summaryHeadingLevel - 1
+	blanks := 0
+	open := forEachLine(lines, func(i int, prose bool) {
+		line := strings.TrimRight(lines[i], " \t")
+		if !prose {
+			out = append(out, lines[i])
+			blanks = 0
+			return
+		}
+
+		if line == "" {
+			blanks++
+			if blanks > 1 {
+				return
+			}
+			out = append(out, line)
+			return
+		}
+
+		if m := headingLine.FindStringSubmatch(line); m != nil {
+			level := len(m[1]) - minLevel + summaryHeadingLevel
+			level = min(level, prevLevel+1, 6)
+			prevLevel = level
+			line = strings.Repeat("#", level) + m[2]
+		}
+		if isThematicBreak(line) && len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+		out = append(out, line)
+		blanks = 0
+	})
+	if open != "" {
+		out = append(out, open)
+	}
+	return strings.Join(out, "\n")
+}
+
+// forEachLine calls fn for every line, telling whether it is prose or part
+// of a fenced code block, and returns the fence that closes a block left
+// open at the end, if any
+func forEachLine(lines []string, fn func(i int, prose bool)) string {
+	var fence string
+	for i, line := range lines {
+		m := fenceLine.FindStringSubmatch(line)
+		switch {
+		case fence == "" && m != nil:
+			fence = m[1]
+			fn(i, false)
+		case fence != "":
+			if m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(m[2]) == "" {
+				fence = ""
+			}
+			fn(i, false)
+		default:
+			fn(i, true)
+		}
+	}
+	return fence
+}
+
+// forEachProse calls fn for every line outside fenced code blocks
+func forEachProse(lines []string, fn func(i int)) {
+	forEachLine(lines, func(i int, prose bool) {
+		if prose {
+			fn(i)
+		}
+	})
+}
+
+func isThematicBreak(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 3 && strings.Trim(line, "-") == ""
+}
+
+// checkFrontMatter reports a YAML front matter block at the start of a
+// summary file that does not parse, since Obsidian and Dataview ignore it
+func checkFrontMatter(content string) error {
+	if !strings.HasPrefix(content, "---\n") {
+		return nil
+	}
+	block, _, found := strings.Cut(content[len("---\n"):], "\n---")
+	if !found {
+		return fmt.Errorf("front matter is not closed by a \"---\" line")
+	}
+	var props map[string]any
+	if err := yaml.Unmarshal([]byte(block), &props); err != nil {
+		return fmt.Errorf("invalid front matter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// ProcessFile transcribes a local audio or video file, such as a meeting
+// recording or a voice memo, letting Whisper detect its language unless one
+// is declared
+func (y *YouTubeProcessor) ProcessFile(ctx context.Context, path string) (string, error) {
+	workDir, err := os.MkdirTemp(y.tempDir, tempDirPattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Transcription writes next to its input, which must not be the watch
+	// directory, so the file is linked (or copied) into the work directory
+	input := filepath.Join(workDir, "input"+filepath.Ext(path))
+	if err := linkOrCopy(path, input); err != nil {
+		return "", fmt.Errorf("failed to prepare media file: %w", err)
+	}
+
+	whisperModel := lighterWhisperModel(y.whisperModel, lightPipelineFrom(ctx))
+	transcript, err := y.transcribeWithin(ctx, input, y.transcriptionLanguage(ctx), whisperModel)
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe: %w", err)
+	}
+	if transcript == "" {
+		return "", fmt.Errorf("%w: no speech in the recording", ErrNoContent)
+	}
+	return transcript, nil
+}
+
+func linkOrCopy(src, dst string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(abs, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// processedDir keeps media files once summarized: unlike input files, they
+// are the user's only copy of the recording
+func (p *Processor) processedDir() string {
+	return filepath.Join(p.cfg.WatchDir, "processed")
+}
+
+// keepMediaFile moves a summarized media file out of the watch directory
+func (p *Processor) keepMediaFile(job *models.Job) {
+	dest := filepath.Join(p.processedDir(), filepath.Base(job.FilePath))
+	if err := os.MkdirAll(p.processedDir(), 0755); err != nil {
+		jobLogger(job).Warn("Failed to move media file", "error", err)
+		return
+	}
+	if err := os.Rename(job.FilePath, dest); err != nil {
+		jobLogger(job).Warn("Failed to move media file", "error", err)
+		return
+	}
+	slog.Debug("Moved media file", "path", dest)
+}
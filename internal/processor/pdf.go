@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPDFSize bounds the documents downloaded for extraction
+	maxPDFSize = 50 << 20
+	// minPDFText is the shortest text layer considered real; scanned PDFs
+	// often carry only page numbers or stray OCR fragments
+	minPDFText = 200
+)
+
+// isPDFURL reports whether u points to a PDF document
+func isPDFURL(u *url.URL) bool {
+	if strings.ToLower(path.Ext(u.Path)) == ".pdf" {
+		return true
+	}
+	host := strings.ToLower(u.Host)
+	return (host == "arxiv.org" || host == "www.arxiv.org") && strings.HasPrefix(u.Path, "/pdf/")
+}
+
+// PDFExtractor downloads PDF documents and extracts their text with
+// pdftotext (poppler-utils).
+type PDFExtractor struct {
+	client  *http.Client
+	tempDir string
+}
+
+func NewPDFExtractor(tempDir string) *PDFExtractor {
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	return &PDFExtractor{
+		client:  &http.Client{Timeout: 2 * time.Minute},
+		tempDir: tempDir,
+	}
+}
+
+// Extract returns the text of the PDF at rawURL together with the raw
+// document. The text is empty when the PDF has no usable text layer, in which
+// case the document can be handed to a multimodal model instead.
+func (e *PDFExtractor) Extract(ctx context.Context, rawURL string) (string, []byte, error) {
+	data, err := e.download(ctx, rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download PDF: %w", err)
+	}
+
+	text, layout, err := e.pdfToText(ctx, data)
+	if err != nil {
+		if !errors.Is(err, exec.ErrNotFound) {
+			return "", nil, fmt.Errorf("failed to extract PDF text: %w", err)
+		}
+		slog.Warn("pdftotext not found, sending the PDF to the model as is")
+	}
+
+	if len(strings.TrimSpace(text)) < minPDFText {
+		return "", data, nil
+	}
+	return text + pdfStructure(text, layout), data, nil
+}
+
+func (e *PDFExtractor) download(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPDFSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPDFSize {
+		return nil, fmt.Errorf("PDF is larger than %d MB", maxPDFSize>>20)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return nil, fmt.Errorf("response is not a PDF document")
+	}
+	return data, nil
+}
+
+// pdfToText returns the document text in reading order, which keeps
+// multi-column pages readable, and in physical layout, which keeps table
+// columns aligned
+func (e *PDFExtractor) pdfToText(ctx context.Context, data []byte) (string, string, error) {
+	workDir, err := os.MkdirTemp(e.tempDir, tempDirPattern)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	pdfPath := filepath.Join(workDir, "document.pdf")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		return "", "", err
+	}
+
+	text, err := runPDFToText(ctx, pdfPath)
+	if err != nil {
+		return "", "", err
+	}
+	layout, err := runPDFToText(ctx, pdfPath, "-layout")
+	if err != nil {
+		return "", "", err
+	}
+	return text, layout, nil
+}
+
+func runPDFToText(ctx context.Context, pdfPath string, extra ...string) (string, error) {
+	args := append(extra, "-enc", "UTF-8", pdfPath, "-")
+	cmd := exec.CommandContext(ctx, "pdftotext", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// minTableRows is the fewest aligned lines treated as a table
+	minTableRows = 3
+	// minTableColumns is the fewest columns of a table row; two-column page
+	// layouts produce a single wide gap and must not look like tables
+	minTableColumns = 3
+	// maxTables bounds the tables appended to the content
+	maxTables = 20
+	// maxCaptionLines bounds how far a caption is followed past its first line
+	maxCaptionLines = 4
+)
+
+var (
+	// columnGap separates the cells of a table row in layout output
+	columnGap = regexp.MustCompile(`\s{2,}`)
+	// captionStart matches the first line of figure and table captions
+	captionStart = regexp.MustCompile(`^(Figure|Fig\.|Table|TABLE)\s+[0-9IVX]+[.:]`)
+	digit        = regexp.MustCompile(`\d`)
+)
+
+// pdfStructure returns the tables and figure captions found in a PDF as
+// marked sections to append to its text, or an empty string when there are
+// none. Plain pdftotext output interleaves table cells with the body text, so
+// the summarizer gets them again with their rows intact.
+func pdfStructure(text, layout string) string {
+	tables := pdfTables(layout)
+	captions := pdfCaptions(text)
+	if len(tables) == 0 && len(captions) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(tables) > 0 {
+		b.WriteString("\n\n=== TABLES (extracted from the PDF, cells separated by |) ===\n")
+		for i, table := range tables {
+			fmt.Fprintf(&b, "\n[TABLE %d]\n%s\n[/TABLE %d]\n", i+1, table, i+1)
+		}
+	}
+	if len(captions) > 0 {
+		b.WriteString("\n\n=== FIGURE AND TABLE CAPTIONS ===\n")
+		for _, caption := range captions {
+			fmt.Fprintf(&b, "\n[CAPTION] %s\n", caption)
+		}
+	}
+	return b.String()
+}
+
+// pdfTables finds runs of lines whose cells are aligned in columns
+func pdfTables(layout string) []string {
+	var tables []string
+	var rows []string
+
+	flush := func() {
+		if len(rows) >= minTableRows && numericRows(rows)*2 >= len(rows) && len(tables) < maxTables {
+			tables = append(tables, strings.Join(rows, "\n"))
+		}
+		rows = nil
+	}
+
+	for _, line := range strings.Split(layout, "\n") {
+		cells := columnGap.Split(strings.TrimSpace(line), -1)
+		if len(cells) < minTableColumns {
+			flush()
+			continue
+		}
+		rows = append(rows, strings.Join(cells, " | "))
+	}
+	flush()
+
+	return tables
+}
+
+func numericRows(rows []string) int {
+	n := 0
+	for _, row := range rows {
+		if digit.MatchString(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// pdfCaptions collects figure and table captions from the reading-order
+// text, following each one to the end of its paragraph
+func pdfCaptions(text string) []string {
+	var captions []string
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !captionStart.MatchString(line) {
+			continue
+		}
+
+		caption := []string{line}
+		for j := i + 1; j < len(lines) && len(caption) < maxCaptionLines; j++ {
+			next := strings.TrimSpace(lines[j])
+			if next == "" || captionStart.MatchString(next) {
+				break
+			}
+			caption = append(caption, next)
+			i = j
+		}
+		captions = append(captions, strings.Join(caption, " "))
+	}
+	return captions
+}
@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/events"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// isPlaylistURL reports whether rawURL is a YouTube playlist page. Videos
+// opened from a playlist ("watch?v=...&list=...") are single videos.
+func isPlaylistURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	return (host == "youtube.com" || host == "music.youtube.com") &&
+		u.Path == "/playlist" && u.Query().Get("list") != ""
+}
+
+// PlaylistEntries lists the video URLs of a playlist, up to max, without
+// downloading anything
+func (y *YouTubeProcessor) PlaylistEntries(ctx context.Context, rawURL string, max int) ([]string, error) {
+	args := []string{"--flat-playlist", "--no-warnings", "--print", "url"}
+	if max > 0 {
+		args = append(args, "--playlist-end", fmt.Sprint(max))
+	}
+	cmd := y.ytdlp(ctx, append(args, rawURL)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp failed: %w, stderr: %s", err, stderr.String())
+	}
+	var entries []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" && line != "NA" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// expandPlaylist replaces a playlist job with one job per video, sharing
+// its settings and named "<name>-N" like the entries of a reading list
+func (p *Processor) expandPlaylist(ctx context.Context, job *models.Job) {
+	entries, err := p.ytProc.PlaylistEntries(ctx, job.URL, p.cfg.PlaylistMax)
+	if err == nil && len(entries) == 0 {
+		err = withCode(models.ErrorExtractionEmpty, fmt.Errorf("%w: the playlist is empty", ErrNoContent))
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to list playlist: %w", err)
+		if errorCode(err) == "" {
+			err = withCode(models.ErrorDownloadFailed, err)
+		}
+		if p.shouldRetry(job, err) {
+			p.retryJob(job, err)
+		} else {
+			p.failJob(job, err)
+		}
+		return
+	}
+
+	for i, entry := range entries {
+		video := models.NewJob("", entry, job.CustomPrompt)
+		video.Filename = fmt.Sprintf("%s-%d", job.Filename, i+1)
+		if job.OutputName != "" {
+			video.OutputName = fmt.Sprintf("%s-%d", job.OutputName, i+1)
+		}
+		video.Notes = job.Notes
+		video.Feed = job.Feed
+		video.Persona = job.Persona
+		video.Priority = job.Priority
+		video.Provider = job.Provider
+		video.Model = job.Model
+		if err := p.queue.Enqueue(video); err != nil {
+			jobLogger(job).Error("Failed to enqueue playlist video", "url", entry, "error", err)
+		}
+	}
+
+	jobLogger(job).Info("Expanded playlist", "videos", len(entries), "max", p.cfg.PlaylistMax)
+	p.events.Record(events.TypeCompleted, job.ID, job.Filename, fmt.Sprintf("playlist expanded into %d jobs", len(entries)))
+	p.completeJob(job)
+}
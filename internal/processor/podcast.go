@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// audioExtensions are direct links to audio files
+var audioExtensions = map[string]bool{
+	".mp3":  true,
+	".m4a":  true,
+	".aac":  true,
+	".ogg":  true,
+	".opus": true,
+	".wav":  true,
+	".flac": true,
+}
+
+// podcastHosts are podcast directories and players whose episode pages
+// yt-dlp can resolve to audio
+var podcastHosts = []string{
+	"podcasts.apple.com",
+	"overcast.fm",
+	"pca.st",
+	"pocketcasts.com",
+	"castbox.fm",
+	"podbean.com",
+	"buzzsprout.com",
+	"simplecast.com",
+	"megaphone.fm",
+	"libsyn.com",
+	"anchor.fm",
+	"podcasters.spotify.com",
+}
+
+// isPodcastURL reports whether u points to an audio file, podcast feed, or
+// podcast episode page
+func isPodcastURL(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	ext := strings.ToLower(path.Ext(u.Path))
+
+	if audioExtensions[ext] || isFeedURL(u) || isAudioTrackURL(u) {
+		return true
+	}
+	for _, h := range podcastHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// soundCloudPages are SoundCloud profile pages, "/<user>/<page>", that look
+// like track URLs
+var soundCloudPages = map[string]bool{
+	"sets": true, "tracks": true, "albums": true, "likes": true,
+	"reposts": true, "followers": true, "following": true, "popular-tracks": true,
+}
+
+// isAudioTrackURL reports whether u is a single SoundCloud or Bandcamp
+// track, such as an interview or a commented mix. Playlists, albums, and
+// profiles are not, since only one recording is transcribed per job.
+func isAudioTrackURL(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case host == "on.soundcloud.com":
+		// Share links redirect to a track
+		return len(parts) == 1 && parts[0] != ""
+	case host == "soundcloud.com" || host == "m.soundcloud.com":
+		return len(parts) == 2 && !soundCloudPages[parts[1]]
+	case strings.HasSuffix(host, ".bandcamp.com"):
+		return len(parts) == 2 && parts[0] == "track"
+	}
+	return false
+}
+
+func isFeedURL(u *url.URL) bool {
+	p := strings.ToLower(u.Path)
+	return strings.HasSuffix(p, ".rss") || strings.HasSuffix(p, "/rss") ||
+		strings.HasPrefix(strings.ToLower(u.Host), "feeds.")
+}
+
+// PodcastProcessor transcribes podcast episodes. Feed URLs are resolved to
+// their latest episode; audio is downloaded and transcribed by the same
+// yt-dlp + Whisper pipeline used for videos.
+type PodcastProcessor struct {
+	client *http.Client
+	audio  *YouTubeProcessor
+}
+
+func NewPodcastProcessor(audio *YouTubeProcessor) *PodcastProcessor {
+	return &PodcastProcessor{
+		client: &http.Client{Timeout: 30 * time.Second},
+		audio:  audio,
+	}
+}
+
+func (p *PodcastProcessor) Process(ctx context.Context, rawURL string) (string, error) {
+	audioURL, err := p.resolve(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	return p.audio.Process(ctx, audioURL)
+}
+
+type rssFeed struct {
+	Channel struct {
+		Items []struct {
+			Title     string `xml:"title"`
+			Enclosure struct {
+				URL string `xml:"url,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// resolve returns the audio URL of the latest episode when rawURL is a feed,
+// or rawURL itself otherwise
+func (p *PodcastProcessor) resolve(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !isFeedURL(u) {
+		return rawURL, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch podcast feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("podcast feed returned status %d", resp.StatusCode)
+	}
+
+	var feed rssFeed
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 20<<20)).Decode(&feed); err != nil {
+		return "", fmt.Errorf("failed to parse podcast feed: %w", err)
+	}
+
+	for _, item := range feed.Channel.Items {
+		if item.Enclosure.URL != "" {
+			return item.Enclosure.URL, nil
+		}
+	}
+	return "", fmt.Errorf("podcast feed has no episodes with audio")
+}
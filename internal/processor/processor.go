@@ -1,19 +1,30 @@
 package processor
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	readability "github.com/go-shiori/go-readability"
+
+	"github.com/clobrano/briefly/internal/budget"
 	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/events"
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/language"
 	"github.com/clobrano/briefly/internal/models"
 	"github.com/clobrano/briefly/internal/notifier"
 	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/redact"
+	"github.com/clobrano/briefly/internal/search"
 	"github.com/clobrano/briefly/internal/summarizer"
 )
 
@@ -21,8 +32,13 @@ import (
 var ErrOutputExists = errors.New("output file already exists")
 
 const (
-	maxRetries  = 3
-	baseBackoff = 5 * time.Second
+	// Rate limited jobs wait longer each time, up to maxRateLimitWaits
+	// times before the error counts as a retry
+	maxRateLimitWaits = 10
+	rateLimitBackoff  = 30 * time.Second
+
+	// indexTimeout bounds embedding a completed summary for search
+	indexTimeout = time.Minute
 )
 
 type Processor struct {
@@ -30,25 +46,148 @@ type Processor struct {
 	queue      *queue.Queue
 	textProc   *TextExtractor
 	ytProc     *YouTubeProcessor
+	podProc    *PodcastProcessor
+	pdfProc    *PDFExtractor
+	threadProc *ThreadExtractor
+	redditProc *RedditExtractor
+	githubProc *GitHubExtractor
 	summarizer summarizer.Summarizer
 	notifier   *notifier.Notifier
+	budget     *budget.Tracker
+	dedup      *dedupIndex
+	cache      *summaryCache
+	events     *events.Log
+	history    *history.Store
+	index      *search.Index
+	retry      retryPolicy
+	timeouts   stageTimeouts
 	done       chan struct{}
+
+	// catalogMu serializes rewrites of the summary index
+	catalogMu sync.Mutex
+
+	// keepalive is called every watchdogInterval while the processor runs
+	watchdogInterval time.Duration
+	keepalive        func()
+
+	// budgetPausedUntil holds the reset time of providers whose budget is
+	// exhausted
+	budgetMu          sync.Mutex
+	budgetPausedUntil map[string]time.Time
+
+	outputTemplate *template.Template
+	personas       summarizer.Personas
+
+	// extractors replace the built-in extraction of a content type
+	extractors map[models.ContentType]Extractor
+
+	factory     SummarizerFactory
+	summarizers map[string]summarizer.Summarizer
+	sumMu       sync.Mutex
+}
+
+// Extractor returns the text of the content at a URL
+type Extractor interface {
+	Extract(ctx context.Context, rawURL string) (string, error)
 }
 
+// SummarizerFactory builds summarizers for jobs that request a provider or
+// model other than the configured default
+type SummarizerFactory func(provider, model string) (summarizer.Summarizer, error)
+
 func New(cfg *config.Config, q *queue.Queue, sum summarizer.Summarizer, ntfy *notifier.Notifier) *Processor {
+	textProc := NewTextExtractor()
+	textProc.SetMaxImages(cfg.VisionImages)
+	ytProc := NewYouTubeProcessor(cfg.WhisperModel, cfg.TempDir)
+	ytProc.SetCaptions(cfg.YouTubeCaptions)
+	ytProc.SetChapters(cfg.YouTubeChapters)
+	ytProc.SetSponsorBlock(cfg.SponsorBlock)
+	ytProc.SetLanguage(cfg.TranscriptionLanguage)
+	ytProc.SetDownloadOptions(cfg.YtDlpCookies, cfg.Proxy, cfg.YtDlpArgs)
+	timeouts := newStageTimeouts(cfg)
+	ytProc.SetTimeouts(timeouts.download, timeouts.transcription)
+	if cfg.Transcriber == "api" {
+		ytProc.SetTranscriber(NewAPITranscriber(cfg.TranscriberURL, cfg.TranscriberKey, cfg.TranscriberModel))
+	}
 	return &Processor{
 		cfg:        cfg,
 		queue:      q,
-		textProc:   NewTextExtractor(),
-		ytProc:     NewYouTubeProcessor(cfg.WhisperModel),
+		textProc:   textProc,
+		ytProc:     ytProc,
+		podProc:    NewPodcastProcessor(ytProc),
+		pdfProc:    NewPDFExtractor(cfg.TempDir),
+		threadProc: NewThreadExtractor(cfg.XBearerToken, cfg.NitterInstances),
+		redditProc: NewRedditExtractor(cfg.RedditComments),
+		githubProc: NewGitHubExtractor(cfg.GitHubToken),
 		summarizer: sum,
 		notifier:   ntfy,
+		dedup:      newDedupIndex(filepath.Join(cfg.OutputDir, ".dedup.json")),
+		cache:      newSummaryCache(filepath.Join(cfg.OutputDir, ".cache"), time.Duration(cfg.SummaryCacheDays)*24*time.Hour),
+		retry:      newRetryPolicy(cfg),
+		timeouts:   timeouts,
 		done:       make(chan struct{}),
 	}
 }
 
+// SetSummarizerFactory enables per-job provider and model overrides
+func (p *Processor) SetSummarizerFactory(f SummarizerFactory) {
+	p.factory = f
+}
+
+// SetEvents records job lifecycle events for the dashboard and status API
+func (p *Processor) SetEvents(l *events.Log) {
+	p.events = l
+}
+
+// SetHistory records the final state of every job
+func (p *Processor) SetHistory(h *history.Store) {
+	p.history = h
+}
+
+// SetIndex adds the summary of every completed job to a semantic search
+// index
+func (p *Processor) SetIndex(x *search.Index) {
+	p.index = x
+}
+
+// SetExtractor replaces the built-in extraction of contentType, for example
+// with a fake in tests
+func (p *Processor) SetExtractor(contentType models.ContentType, e Extractor) {
+	if p.extractors == nil {
+		p.extractors = make(map[models.ContentType]Extractor)
+	}
+	p.extractors[contentType] = e
+}
+
+// SetPersonas sets the persona presets jobs and the configuration can select
+func (p *Processor) SetPersonas(personas summarizer.Personas) {
+	p.personas = personas
+}
+
+// SetBudget enables spending caps; jobs stay queued while the budget is exhausted
+func (p *Processor) SetBudget(b *budget.Tracker) {
+	p.budget = b
+}
+
+// Start launches the configured number of workers. Each worker dequeues
+// jobs independently, so a long transcription does not block other jobs.
 func (p *Processor) Start() {
-	go p.run()
+	workers := p.cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	p.cache.Sweep()
+	// Summaries deleted while stopped leave the index on start
+	go p.updateCatalog()
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	if ttl := p.queue.LeaseTTL(); ttl > 0 {
+		go p.wakeForExpiredLeases(ttl)
+	}
+	if p.watchdogInterval > 0 && p.keepalive != nil {
+		go p.watchdog()
+	}
 }
 
 func (p *Processor) Stop() {
@@ -68,11 +207,14 @@ func (p *Processor) run() {
 
 func (p *Processor) processQueue() {
 	for {
-		job := p.queue.Dequeue()
+		job := p.dequeue()
 		if job == nil {
 			return
 		}
 
+		// Wake another worker in case more jobs are pending
+		p.queue.Notify()
+
 		select {
 		case <-p.done:
 			return
@@ -82,56 +224,212 @@ func (p *Processor) processQueue() {
 	}
 }
 
+// ProcessNext processes the next due job synchronously, and reports whether
+// there was one; jobs waiting for a retry backoff are not due yet. It is
+// meant for tests and tools driving the processor without starting workers.
+func (p *Processor) ProcessNext() bool {
+	job := p.dequeue()
+	if job == nil {
+		return false
+	}
+	p.processJob(job)
+	return true
+}
+
+// budgetPause is why and until when a provider budget is exhausted
+type budgetPause struct {
+	reason  string
+	resetAt time.Time
+}
+
+// dequeue claims the next job whose provider has budget left; jobs of
+// exhausted providers stay pending, behind the jobs of the others
+func (p *Processor) dequeue() *models.Job {
+	paused := map[string]budgetPause{}
+	job := p.queue.Dequeue(func(job *models.Job) bool {
+		provider, _ := p.resolveModel(job)
+		if _, ok := paused[provider]; ok {
+			return false
+		}
+		exhausted, reason, resetAt := p.budget.Exhausted(provider)
+		if exhausted {
+			paused[provider] = budgetPause{reason: reason, resetAt: resetAt}
+		}
+		return !exhausted
+	})
+	for provider, pause := range paused {
+		p.budgetExhausted(provider, pause)
+	}
+	return job
+}
+
+// budgetExhausted notifies once per pause that the provider budget is used
+// up, and wakes the queue when it resets
+func (p *Processor) budgetExhausted(provider string, pause budgetPause) {
+	p.budgetMu.Lock()
+	firstPause := !p.budgetPausedUntil[provider].Equal(pause.resetAt)
+	if p.budgetPausedUntil == nil {
+		p.budgetPausedUntil = map[string]time.Time{}
+	}
+	p.budgetPausedUntil[provider] = pause.resetAt
+	p.budgetMu.Unlock()
+
+	if firstPause {
+		slog.Warn("Budget exhausted, queueing jobs until reset",
+			"provider", provider, "reason", pause.reason, "pending", p.queue.PendingCount(), "reset_at", pause.resetAt)
+
+		if p.notifier != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := p.notifier.SendBudgetExhausted(ctx, provider, pause.reason, pause.resetAt); err != nil {
+				slog.Warn("Failed to send budget notification", "error", err)
+			}
+		}
+
+		go func() {
+			time.Sleep(time.Until(pause.resetAt))
+			p.queue.Notify()
+		}()
+	}
+}
+
 func (p *Processor) processJob(job *models.Job) {
-	log.Printf("Processing job %s: %s", job.Filename, job.URL)
+	jobLogger(job).Info("Processing job")
+	p.events.Record(events.TypeStarted, job.ID, job.Filename, job.Source())
+	if job.StartedAt.IsZero() {
+		job.StartedAt = time.Now()
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	// The stages of the job are bounded by their own timeouts
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	defer p.keepLease(job, cancel)()
 
-	// Detect content type first
-	job.ContentType = DetectContentType(job.URL)
-	if job.ContentType == models.ContentTypeUnknown {
-		p.failJob(job, fmt.Errorf("unknown content type for URL: %s", job.URL))
-		return
+	// dedupKey records the summary for later submissions of the same input
+	var dedupKey string
+	if job.IsDirectText {
+		if job.ContentHash == "" {
+			job.ContentHash = models.HashText(job.Text)
+		}
+		job.ContentType = models.ContentTypeDirectText
+		// Identical text submitted before with the same settings is skipped
+		// while its summary exists
+		job.Provider, job.Model = p.resolveModel(job)
+		dedupKey = textDedupKey(job)
+		if path, ok := p.dedup.Lookup(dedupKey); ok {
+			jobLogger(job).Info("Skipping job: identical text already summarized", "output", path)
+			job.OutputPath = path
+			p.skipJob(ctx, job)
+			return
+		}
+	} else if job.ContentType == models.ContentTypeMedia {
+		// Media files are transcribed from the watch directory, no URL needed
+		if p.mediaDisabled(job.ContentType) {
+			p.failJob(job, withCode(models.ErrorUnsupported, errMediaDisabled(job.ContentType)))
+			return
+		}
+	} else {
+		// Detect content type first
+		job.ContentType = p.detectContentType(ctx, job.URL)
+		if job.ContentType == models.ContentTypeUnknown {
+			p.failJob(job, withCode(models.ErrorUnsupported, fmt.Errorf("unknown content type for URL: %s", job.URL)))
+			return
+		}
+		if p.mediaDisabled(job.ContentType) {
+			p.failJob(job, withCode(models.ErrorUnsupported, errMediaDisabled(job.ContentType)))
+			return
+		}
+		if job.ContentType == models.ContentTypeYouTube && isPlaylistURL(job.URL) {
+			listCtx, cancelList := stageContext(ctx, p.timeouts.extraction)
+			defer cancelList()
+			p.expandPlaylist(listCtx, job)
+			return
+		}
+		// The key names the model resolved now, not the one that serves the
+		// job, which may be a fallback
+		job.Provider, job.Model = p.resolveModel(job)
+		dedupKey = urlDedupKey(job)
+		// The same URL submitted before is skipped while its summary exists
+		if p.cfg.URLDedup {
+			if path, ok := p.dedup.Lookup(dedupKey); ok {
+				jobLogger(job).Info("Skipping job: URL already summarized", "output", path)
+				job.OutputPath = path
+				p.skipJob(ctx, job)
+				return
+			}
+		}
 	}
 
 	// Check if output already exists (skip duplicate processing)
 	exists, err := p.outputExists(job)
 	if err != nil {
-		log.Printf("Error checking output file for job %s: %v", job.Filename, err)
-		p.failJob(job, err)
+		jobLogger(job).Error("Failed to check output file", "error", err)
+		p.failJob(job, withCode(models.ErrorOutputFailed, err))
 		return
 	}
 	if exists {
-		log.Printf("Skipping job %s: output file already exists", job.Filename)
-		if p.notifier != nil {
-			if err := p.notifier.SendSkipped(ctx, job); err != nil {
-				log.Printf("Warning: failed to send skipped notification for job %s: %v", job.Filename, err)
-			}
-		}
-		p.completeJob(job)
+		jobLogger(job).Info("Skipping job: output file already exists")
+		p.skipJob(ctx, job)
 		return
 	}
 
 	// Send start notification only on first attempt
 	if p.notifier != nil && job.Retries == 0 {
 		if err := p.notifier.SendStart(ctx, job); err != nil {
-			log.Printf("Warning: failed to send start notification for job %s: %v", job.Filename, err)
+			jobLogger(job).Warn("Failed to send start notification", "error", err)
 		}
 	}
 
 	// Extract content
+	p.stage(job, "extracting %s content", job.ContentType)
 	var content string
+	var document *summarizer.Document
+	var images []summarizer.Document
 
-	switch job.ContentType {
-	case models.ContentTypeYouTube:
-		content, err = p.ytProc.Process(ctx, job.URL)
-	case models.ContentTypeText:
-		content, err = p.textProc.Extract(ctx, job.URL)
+	_, overridden := p.extractors[job.ContentType]
+	extractCtx, cancelExtract := stageContext(ctx, p.timeouts.extractionFor(job.ContentType))
+	defer cancelExtract()
+	// Transcription uses the language declared for the job and records the
+	// one it detects
+	stagedCtx := withLanguage(p.withStages(extractCtx, job), job.Language, func(lang string) {
+		job.Language = lang
+	})
+	stagedCtx = withLightPipeline(stagedCtx, job.Escalation)
+	var article *readability.Article
+	textCtx := extractCtx
+	if p.cfg.ArchiveArticles {
+		textCtx = withArchive(extractCtx, func(a readability.Article) { article = &a })
+	}
+	switch {
+	case job.ContentType == models.ContentTypeDirectText:
+		content = job.Text
+	case overridden:
+		content, err = p.extract(extractCtx, job.ContentType, job.URL)
+	case job.ContentType == models.ContentTypeMedia:
+		content, err = p.ytProc.ProcessFile(stagedCtx, job.FilePath)
+	case job.ContentType == models.ContentTypeText:
+		content, images, err = p.textProc.ExtractWithImages(textCtx, job.URL)
+	case job.ContentType == models.ContentTypePDF:
+		var data []byte
+		content, data, err = p.pdfProc.Extract(extractCtx, job.URL)
+		if err == nil && content == "" {
+			// No text layer: let a multimodal model read the PDF itself
+			jobLogger(job).Info("PDF has no text layer, attaching the document")
+			document = &summarizer.Document{Data: data, MIMEType: "application/pdf"}
+			content = attachedDocumentContent
+		}
+	default:
+		content, err = p.extract(stagedCtx, job.ContentType, job.URL)
 	}
 
 	if err != nil {
-		if p.shouldRetry(job) {
+		if isTimeout(extractCtx, err) && isTranscribed(job.ContentType) {
+			// Repeating the same configuration would likely time out again
+			job.Escalation++
+			err = fmt.Errorf("timed out, retrying with a lighter transcription pipeline: %w", err)
+		}
+		err = extractionError(extractCtx, err)
+		if p.shouldRetry(job, err) {
 			p.retryJob(job, err)
 			return
 		}
@@ -140,11 +438,140 @@ func (p *Processor) processJob(job *models.Job) {
 	}
 
 	job.Content = content
+	if job.Language == "" && document == nil {
+		job.Language = language.Detect(content)
+	}
 
 	// Summarize
-	summary, err := p.summarizer.Summarize(ctx, content, job.CustomPrompt, job.ContentType)
+	job.Provider, job.Model = p.resolveModel(job)
+	// The configured provider was checked at startup, per-job ones are not
+	if job.Provider != p.cfg.LLMProvider {
+		if err := config.CheckProvider(job.Provider); err != nil {
+			p.failJob(job, withCode(models.ErrorInvalidInput, err))
+			return
+		}
+	}
+	sum, err := p.summarizerFor(job.Provider, job.Model)
+	if err != nil {
+		p.failJob(job, withCode(models.ErrorProviderError, err))
+		return
+	}
+
+	persona, err := p.personaFor(job)
+	if err != nil {
+		p.failJob(job, withCode(models.ErrorInvalidInput, err))
+		return
+	}
+	style, err := summarizer.ParseStyle(cmp.Or(job.Style, p.cfg.SummaryStyle))
+	if err != nil {
+		p.failJob(job, withCode(models.ErrorInvalidInput, err))
+		return
+	}
+	if _, err := p.formatFor(job); err != nil {
+		p.failJob(job, withCode(models.ErrorInvalidInput, err))
+		return
+	}
+
+	p.stage(job, "summarizing with %s/%s", job.Provider, job.Model)
+	usage := &summarizer.Usage{}
+	sumCtx, cancelSum := stageContext(ctx, p.timeouts.summarization)
+	defer cancelSum()
+	sumCtx = summarizer.WithUsage(sumCtx, usage)
+	depth := p.depthFor(content)
+	if document != nil && (p.cfg.SummaryDepth == "" || summarizer.Depth(p.cfg.SummaryDepth) == summarizer.DepthAuto) {
+		// The length of an attached document is unknown
+		depth = summarizer.DepthStandard
+	}
+	promptOpts := summarizer.PromptOptions{
+		SourceLanguage: job.Language,
+		LanguagePolicy: summarizer.LanguagePolicy(p.cfg.LanguagePolicy),
+		TargetLanguage: p.cfg.SummaryLanguage,
+		Depth:          depth,
+		Persona:        persona,
+		Style:          style,
+	}
+	if job.SummaryLanguage != "" {
+		promptOpts.TargetLanguage = job.SummaryLanguage
+		if promptOpts.LanguagePolicy != summarizer.LanguageBilingual {
+			promptOpts.LanguagePolicy = summarizer.LanguageTranslate
+		}
+	}
+	sumCtx = summarizer.WithPromptOptions(sumCtx, promptOpts)
+	if document != nil {
+		sumCtx = summarizer.WithDocument(sumCtx, *document)
+	}
+	if len(images) > 0 {
+		sumCtx = summarizer.WithImages(sumCtx, images)
+	}
+	prompted, redactions := p.redact(job, content, document != nil)
+
+	// Attachments are not part of the key, so their summaries are not cached
+	var cacheKey string
+	if document == nil && len(images) == 0 && !job.NoCache {
+		cacheKey = summaryCacheKey(job, prompted, promptOpts)
+	}
+	cached, hit := p.cache.Get(cacheKey)
+	var summary string
+	if hit {
+		jobLogger(job).Info("Using cached summary", "provider", cached.Provider, "model", cached.Model)
+		summary = cached.Summary
+		job.ServedProvider, job.ServedModel = cached.Provider, cached.Model
+	} else {
+		// Provider and Model stay the requested ones, so retries go through
+		// the whole fallback chain again and keep the dedup keys
+		job.ServedProvider, job.ServedModel = job.Provider, job.Model
+		summary, err = sum.Summarize(sumCtx, prompted, job.CustomPrompt, job.ContentType)
+		if provider, model, ok := strings.Cut(usage.ServedBy, "/"); ok {
+			job.ServedProvider, job.ServedModel = provider, model
+		}
+	}
+
+	// Name untitled inputs after their content
+	if err == nil && p.cfg.GenerateTitles && needsTitle(job) {
+		if hit && cached.Title != "" {
+			p.titleJob(job, cached.Title)
+		} else {
+			p.generateTitle(sumCtx, sum, job, prompted)
+		}
+	}
+	if err == nil && p.cfg.GenerateTags {
+		if hit && len(cached.Tags) > 0 {
+			job.Tags = cached.Tags
+		} else {
+			p.generateTags(sumCtx, sum, job, summary)
+		}
+	}
+	if err == nil && !hit && cacheKey != "" {
+		// Cached before saving, so a retry after a save failure finds it
+		entry := cachedSummary{Summary: summary, Title: job.Title, Tags: job.Tags, Provider: job.ServedProvider, Model: job.ServedModel}
+		if err := p.cache.Put(cacheKey, entry); err != nil {
+			jobLogger(job).Warn("Failed to cache summary", "error", err)
+		}
+	}
+
+	// The answers depend on the questions of the job, so they are not cached
+	var answers string
+	if err == nil && len(job.Questions) > 0 {
+		p.stage(job, "answering %d questions", len(job.Questions))
+		if answers, err = summarizer.AnswerQuestions(sumCtx, sum, prompted, job.Questions, job.ContentType); err != nil {
+			err = fmt.Errorf("failed to answer questions: %w", err)
+		}
+	}
+
+	input, output := usage.Totals()
+	if err := p.budget.Record(job.ServedProvider, job.ServedModel, input, output); err != nil {
+		jobLogger(job).Warn("Failed to record budget usage", "error", err)
+	}
+	job.InputTokens += input
+	job.OutputTokens += output
+	job.CostUSD += summarizer.EstimateCost(job.ServedModel, input, output)
 	if err != nil {
-		if p.shouldRetry(job) {
+		err = providerError(sumCtx, err)
+		if errorCode(err) == models.ErrorRateLimited && job.RateLimited < maxRateLimitWaits {
+			p.waitRateLimit(job, err)
+			return
+		}
+		if p.shouldRetry(job, err) {
 			p.retryJob(job, err)
 			return
 		}
@@ -152,52 +579,346 @@ func (p *Processor) processJob(job *models.Job) {
 		return
 	}
 
-	job.Summary = summary
+	if answers != "" {
+		summary += "\n\n## Q&A\n\n" + answers
+	}
+	job.Summary = redactions.Restore(summary)
+	if job.ContentType == models.ContentTypeYouTube && p.cfg.YouTubeChapters {
+		job.Summary = linkTimestamps(job.Summary, job.URL)
+	}
+	if p.cfg.LintOutput {
+		job.Summary = lintMarkdown(job.Summary)
+	}
 
 	// Save summary
 	if err := p.saveSummary(job); err != nil {
 		// Race condition: another worker already created the output file
 		if errors.Is(err, ErrOutputExists) {
-			log.Printf("Skipping job %s: output file created by concurrent worker", job.Filename)
-			if p.notifier != nil {
-				if notifyErr := p.notifier.SendSkipped(ctx, job); notifyErr != nil {
-					log.Printf("Warning: failed to send skipped notification for job %s: %v", job.Filename, notifyErr)
-				}
-			}
-			p.completeJob(job)
+			jobLogger(job).Info("Skipping job: output file created by concurrent worker")
+			p.skipJob(ctx, job)
 			return
 		}
-		log.Printf("Error: failed to save summary for job %s: %v", job.Filename, err)
+		jobLogger(job).Error("Failed to save summary", "error", err)
 		job.Error = fmt.Sprintf("failed to save summary: %v", err)
-		p.failJob(job, fmt.Errorf("failed to save summary: %w", err))
+		p.failJob(job, withCode(models.ErrorOutputFailed, fmt.Errorf("failed to save summary: %w", err)))
 		return
 	}
 
+	if article != nil {
+		if err := saveArchive(job, *article); err != nil {
+			jobLogger(job).Warn("Failed to archive article", "error", err)
+		}
+	}
+	if p.cfg.SaveTranscript {
+		if err := p.saveTranscript(job); err != nil {
+			jobLogger(job).Warn("Failed to save transcript", "error", err)
+		}
+	}
+
+	if dedupKey != "" {
+		if err := p.dedup.Add(dedupKey, p.getOutputPath(job)); err != nil {
+			jobLogger(job).Warn("Failed to record the input for deduplication", "error", err)
+		}
+	}
+
 	// Notify success
 	if p.notifier != nil {
 		if err := p.notifier.SendSuccess(ctx, job); err != nil {
-			log.Printf("Warning: failed to send notification for job %s: %v", job.Filename, err)
+			jobLogger(job).Warn("Failed to send success notification", "error", err)
 		}
 	}
 
 	// Complete job
+	p.events.Record(events.TypeCompleted, job.ID, job.Filename, job.OutputPath)
 	p.completeJob(job)
 }
 
-func (p *Processor) shouldRetry(job *models.Job) bool {
-	return job.Retries < maxRetries
+// attachedDocumentContent stands in for the content of sources handed to the
+// model as a document
+const attachedDocumentContent = "(The content is the attached PDF document.)"
+
+// redact replaces personal data and secrets in content before it is sent to
+// a cloud provider, keeping the redaction map next to the summaries.
+// Attached documents and images cannot be redacted.
+func (p *Processor) redact(job *models.Job, content string, attached bool) (string, redact.Map) {
+	if !p.cfg.Redact || p.localOnly(job) || attached {
+		return content, nil
+	}
+
+	redacted, m := redact.Text(content)
+	if len(m) == 0 {
+		return content, nil
+	}
+	path := filepath.Join(p.cfg.OutputDir, ".redactions", job.ID+".json")
+	if err := m.Save(path); err != nil {
+		jobLogger(job).Warn("Failed to save redaction map", "error", err)
+	}
+	jobLogger(job).Info("Redacted values before summarization", "count", len(m))
+	return redacted, m
+}
+
+// EmbeddingText returns the text of a summary to embed for search. Like
+// content sent for summarization, it is redacted before it leaves the host
+// for a cloud embeddings provider, since summaries have the redacted values
+// restored.
+func EmbeddingText(cfg *config.Config, text string) string {
+	if !cfg.Redact || cfg.EmbeddingsProvider == "ollama" {
+		return text
+	}
+	redacted, _ := redact.Text(text)
+	return redacted
+}
+
+// localOnly reports whether the job is summarized on the host whichever
+// provider serves it, the fallback providers included
+func (p *Processor) localOnly(job *models.Job) bool {
+	if job.Provider != "ollama" {
+		return false
+	}
+	for _, entry := range strings.Split(p.cfg.LLMFallback, ",") {
+		provider, _, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		if provider != "" && provider != "ollama" {
+			return false
+		}
+	}
+	return true
+}
+
+// isTranscribed reports whether contentType goes through audio transcription
+func isTranscribed(contentType models.ContentType) bool {
+	return contentType == models.ContentTypeYouTube || contentType == models.ContentTypeVideo ||
+		contentType == models.ContentTypePodcast || contentType == models.ContentTypeMedia
+}
+
+// stage records an intermediate processing step of job on the job itself,
+// so the queue file and API show it, and in the activity log
+func (p *Processor) stage(job *models.Job, format string, args ...any) {
+	job.Stage = fmt.Sprintf(format, args...)
+	job.UpdatedAt = time.Now()
+	p.queue.Update(job)
+	p.events.Record(events.TypeStage, job.ID, job.Filename, job.Stage)
+
+	if p.notifier != nil && p.cfg.NotifyProgress {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := p.notifier.SendProgress(ctx, job); err != nil {
+			jobLogger(job).Warn("Failed to send progress notification", "error", err)
+		}
+	}
+}
+
+// withStages reports the steps of long extractions as stages of job
+func (p *Processor) withStages(ctx context.Context, job *models.Job) context.Context {
+	return withProgress(ctx, func(stage string) {
+		p.stage(job, "%s", stage)
+	})
+}
+
+// ExtractContent detects the content type of rawURL and returns the text
+// that would be sent to the summarizer, without touching the queue.
+func (p *Processor) ExtractContent(ctx context.Context, rawURL string) (models.ContentType, string, error) {
+	contentType := p.detectContentType(ctx, rawURL)
+	if contentType == models.ContentTypeUnknown {
+		return contentType, "", fmt.Errorf("unknown content type for URL: %s", rawURL)
+	}
+
+	if p.mediaDisabled(contentType) {
+		return contentType, "", errMediaDisabled(contentType)
+	}
+
+	content, err := p.extract(ctx, contentType, rawURL)
+	return contentType, content, err
+}
+
+// detectContentType is DetectContentType that also asks yt-dlp about
+// article URLs, when enabled, so videos on sites without a known URL
+// pattern are transcribed rather than read as an empty page
+func (p *Processor) detectContentType(ctx context.Context, rawURL string) models.ContentType {
+	contentType := DetectContentType(rawURL)
+	if contentType == models.ContentTypeText && p.cfg.VideoProbe && !p.cfg.ArticleOnly && p.ytProc.IsVideo(ctx, rawURL) {
+		slog.Debug("yt-dlp found a video", "url", rawURL)
+		return models.ContentTypeVideo
+	}
+	return contentType
+}
+
+// mediaDisabled reports whether contentType needs the video and audio
+// pipeline while it is turned off
+func (p *Processor) mediaDisabled(contentType models.ContentType) bool {
+	return p.cfg.ArticleOnly && isTranscribed(contentType)
+}
+
+func errMediaDisabled(contentType models.ContentType) error {
+	return fmt.Errorf("%s content is not supported in article-only mode (BRIEFLY_ARTICLE_ONLY)", contentType)
+}
+
+func (p *Processor) extract(ctx context.Context, contentType models.ContentType, rawURL string) (string, error) {
+	if e, ok := p.extractors[contentType]; ok {
+		return e.Extract(ctx, rawURL)
+	}
+
+	switch contentType {
+	case models.ContentTypeYouTube, models.ContentTypeVideo:
+		return p.ytProc.Process(ctx, rawURL)
+	case models.ContentTypePodcast:
+		return p.podProc.Process(ctx, rawURL)
+	case models.ContentTypeText:
+		return p.textProc.Extract(ctx, rawURL)
+	case models.ContentTypePDF:
+		text, _, err := p.pdfProc.Extract(ctx, rawURL)
+		if err == nil && text == "" {
+			err = fmt.Errorf("%w: PDF has no text layer", ErrNoContent)
+		}
+		return text, err
+	case models.ContentTypeThread:
+		return p.threadProc.Extract(ctx, rawURL)
+	case models.ContentTypeReddit:
+		return p.redditProc.Extract(ctx, rawURL)
+	case models.ContentTypeRepository:
+		return p.githubProc.Extract(ctx, rawURL)
+	case models.ContentTypeIssue:
+		return p.githubProc.ExtractIssue(ctx, rawURL)
+	}
+	return "", fmt.Errorf("unsupported content type: %s", contentType)
+}
+
+func (p *Processor) generateTitle(ctx context.Context, sum summarizer.Summarizer, job *models.Job, content string) {
+	title, err := summarizer.GenerateTitle(ctx, sum, content)
+	if err != nil {
+		jobLogger(job).Warn("Failed to generate title", "error", err)
+		return
+	}
+
+	p.titleJob(job, title)
+}
+
+// generateTags tags job with the topics of its summary, which is shorter
+// than the content and already states what matters
+func (p *Processor) generateTags(ctx context.Context, sum summarizer.Summarizer, job *models.Job, summary string) {
+	tags, err := summarizer.GenerateTags(ctx, sum, summary)
+	if err != nil {
+		jobLogger(job).Warn("Failed to generate tags", "error", err)
+		return
+	}
+	job.Tags = tags
+}
+
+// titleJob names job and its summary file after title
+func (p *Processor) titleJob(job *models.Job, title string) {
+	name := sanitizeFilename(title)
+	if name == "" {
+		return
+	}
+	job.Title = title
+	// The summary keeps the name of the input, or of the job, when the title
+	// cannot be checked against existing summaries
+	unique, err := p.uniqueOutputName(job, name)
+	if err != nil {
+		jobLogger(job).Warn("Failed to name summary after its title", "error", err)
+		return
+	}
+	job.OutputName = unique
+	jobLogger(job).Info("Job titled", "title", title)
+}
+
+// depthFor applies the configured depth policy to content
+func (p *Processor) depthFor(content string) summarizer.Depth {
+	depth := summarizer.Depth(p.cfg.SummaryDepth)
+	if depth != summarizer.DepthAuto && depth != "" {
+		return depth
+	}
+
+	thresholds, err := summarizer.ParseDepthThresholds(p.cfg.DepthThresholds)
+	if err != nil {
+		slog.Warn("Invalid depth thresholds, using defaults", "error", err)
+		thresholds = summarizer.DefaultDepthThresholds
+	}
+	return summarizer.DepthFor(len(strings.Fields(content)), thresholds)
+}
+
+// personaFor returns the instruction of the job's persona, or of the
+// configured default persona
+func (p *Processor) personaFor(job *models.Job) (string, error) {
+	name := job.Persona
+	if name == "" {
+		name = p.cfg.Persona
+	}
+	if name == "" {
+		return "", nil
+	}
+	personas := p.personas
+	if personas == nil {
+		personas = summarizer.DefaultPersonas
+	}
+	return personas.Instruction(name)
+}
+
+// resolveModel returns the provider and model a job should be summarized with
+func (p *Processor) resolveModel(job *models.Job) (string, string) {
+	provider := job.Provider
+	if provider == "" {
+		provider = p.cfg.LLMProvider
+	}
+	model := job.Model
+	if model == "" {
+		if provider == p.cfg.LLMProvider {
+			model = p.cfg.LLMModel
+		} else {
+			model = config.DefaultModel(provider)
+		}
+	}
+	return provider, model
+}
+
+func (p *Processor) summarizerFor(provider, model string) (summarizer.Summarizer, error) {
+	if (provider == p.cfg.LLMProvider && model == p.cfg.LLMModel) || p.factory == nil {
+		return p.summarizer, nil
+	}
+
+	p.sumMu.Lock()
+	defer p.sumMu.Unlock()
+
+	key := provider + "/" + model
+	if sum, ok := p.summarizers[key]; ok {
+		return sum, nil
+	}
+
+	sum, err := p.factory(provider, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s summarizer: %w", key, err)
+	}
+	if p.summarizers == nil {
+		p.summarizers = make(map[string]summarizer.Summarizer)
+	}
+	p.summarizers[key] = sum
+	return sum, nil
+}
+
+// shouldRetry reports whether the retry policy allows retrying job after
+// err
+func (p *Processor) shouldRetry(job *models.Job, err error) bool {
+	return p.retry.allows(job.Retries, errorCode(err))
 }
 
 func (p *Processor) retryJob(job *models.Job, err error) {
 	job.Retries++
 	job.Status = models.JobStatusPending
+	job.Stage = ""
 	job.Error = err.Error()
+	job.ErrorCode = errorCode(err)
 	job.UpdatedAt = time.Now()
 
-	backoff := time.Duration(job.Retries) * baseBackoff
-	log.Printf("Job %s failed (attempt %d/%d): %v. Retrying in %v",
-		job.Filename, job.Retries, maxRetries, err, backoff)
+	backoff := p.retry.backoff(job.Retries)
+	job.NotBefore = job.UpdatedAt.Add(backoff)
+	attempts := fmt.Sprint(job.Retries)
+	if limit := p.retry.limit(job.ErrorCode); limit != retryUnlimited {
+		attempts += fmt.Sprintf("/%d", limit)
+	}
+	jobLogger(job).Warn("Job failed, retrying",
+		"attempt", attempts, "backoff", backoff, "error_code", job.ErrorCode, "error", err)
 
+	p.events.Record(events.TypeRetry, job.ID, job.Filename,
+		fmt.Sprintf("attempt %s failed: %v", attempts, err))
 	p.queue.Update(job)
 
 	// Schedule retry
@@ -207,33 +928,136 @@ func (p *Processor) retryJob(job *models.Job, err error) {
 	}()
 }
 
+// waitRateLimit puts a job the provider rate limited back in the queue,
+// due after a backoff, without spending one of its retries
+func (p *Processor) waitRateLimit(job *models.Job, err error) {
+	job.RateLimited++
+	job.Status = models.JobStatusPending
+	job.Error = err.Error()
+	job.ErrorCode = errorCode(err)
+	job.UpdatedAt = time.Now()
+
+	backoff := time.Duration(job.RateLimited) * rateLimitBackoff
+	job.NotBefore = job.UpdatedAt.Add(backoff)
+	jobLogger(job).Warn("Provider rate limit reached, waiting",
+		"wait", job.RateLimited, "max_waits", maxRateLimitWaits, "backoff", backoff, "error", err)
+
+	p.events.Record(events.TypeRetry, job.ID, job.Filename,
+		fmt.Sprintf("rate limited, waiting %s: %v", backoff, err))
+	p.queue.Update(job)
+
+	go func() {
+		time.Sleep(backoff)
+		p.queue.Notify()
+	}()
+}
+
 func (p *Processor) failJob(job *models.Job, err error) {
 	job.Status = models.JobStatusFailed
 	job.Error = err.Error()
+	job.ErrorCode = errorCode(err)
 	job.UpdatedAt = time.Now()
 
-	log.Printf("Job %s failed permanently: %v", job.Filename, err)
+	jobLogger(job).Error("Job failed permanently", "error_code", job.ErrorCode, "error", err)
+	p.events.Record(events.TypeFailed, job.ID, job.Filename, fmt.Sprintf("%s: %v", job.ErrorCode, err))
+	p.writeReceipt(job, receiptFailed)
+	p.recordHistory(job)
 
 	// Notify failure
 	if p.notifier != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if notifyErr := p.notifier.SendFailure(ctx, job); notifyErr != nil {
-			log.Printf("Warning: failed to send failure notification for job %s: %v", job.Filename, notifyErr)
+			jobLogger(job).Warn("Failed to send failure notification", "error", notifyErr)
 		}
 	}
 
+	if p.cfg.DeadLetter {
+		p.deadLetter(job)
+		return
+	}
 	p.queue.Update(job)
 }
 
+// skipJob completes a job whose summary already exists
+func (p *Processor) skipJob(ctx context.Context, job *models.Job) {
+	p.events.Record(events.TypeSkipped, job.ID, job.Filename, "summary already exists")
+	if p.notifier != nil {
+		if err := p.notifier.SendSkipped(ctx, job); err != nil {
+			jobLogger(job).Warn("Failed to send skipped notification", "error", err)
+		}
+	}
+	p.completeJob(job)
+}
+
+func (p *Processor) recordHistory(job *models.Job) {
+	if err := p.history.Record(job); err != nil {
+		jobLogger(job).Warn("Failed to record job history", "error", err)
+	}
+}
+
+// indexSummary makes the summary of job findable by semantic search
+func (p *Processor) indexSummary(job *models.Job) {
+	if p.index == nil || job.Summary == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+	defer cancel()
+	doc := search.Document{
+		ID:          job.ID,
+		Title:       job.Title,
+		URL:         job.URL,
+		OutputPath:  job.OutputPath,
+		ContentType: job.ContentType,
+		Text:        EmbeddingText(p.cfg, strings.Join([]string{job.Title, strings.Join(job.Tags, ", "), job.Summary}, "\n\n")),
+	}
+	if err := p.index.Add(ctx, doc); err != nil {
+		jobLogger(job).Warn("Failed to index summary", "error", err)
+	}
+}
+
+// jobLogger returns a logger carrying the job's identifying fields
+func jobLogger(job *models.Job) *slog.Logger {
+	return slog.With("job_id", job.ID, "file", job.Filename, "url", job.URL, "content_type", job.ContentType)
+}
+
+// textDedupKey identifies a summary of direct text by its content and the
+// settings that change it, like urlDedupKey
+func textDedupKey(job *models.Job) string {
+	return "text:" + models.HashText(strings.Join(append([]string{job.ContentHash}, summarySettings(job)...), "\n"))
+}
+
+// urlDedupKey identifies a summary by its normalized URL and the settings
+// that change it, so asking again with another prompt, persona, or model
+// produces a new summary
+func urlDedupKey(job *models.Job) string {
+	return "url:" + models.HashText(strings.Join(append([]string{NormalizeURL(job.URL)}, summarySettings(job)...), "\n"))
+}
+
+// summarySettings are the job settings that change its summary
+func summarySettings(job *models.Job) []string {
+	return []string{
+		job.CustomPrompt, job.Persona, job.Provider, job.Model, job.SummaryLanguage, job.Style,
+		job.OutputFormat, strings.Join(job.Questions, "\n"),
+	}
+}
+
 func (p *Processor) completeJob(job *models.Job) {
 	job.Status = models.JobStatusCompleted
+	job.Stage = ""
 	job.UpdatedAt = time.Now()
 
-	log.Printf("Job %s completed successfully", job.Filename)
+	jobLogger(job).Info("Job completed", "output", job.OutputPath)
+	p.writeReceipt(job, receiptDone)
+	p.recordHistory(job)
+	p.indexSummary(job)
+	p.updateCatalog()
 
 	// Remove the input file
-	if job.FilePath != "" {
+	switch {
+	case job.ContentType == models.ContentTypeMedia:
+		p.keepMediaFile(job)
+	case job.FilePath != "":
 		os.Remove(job.FilePath)
 	}
 
@@ -243,16 +1067,42 @@ func (p *Processor) completeJob(job *models.Job) {
 func (p *Processor) getOutputPath(job *models.Job) string {
 	// Use input filename as base for output, fallback to job ID
 	var baseName string
-	if job.FilePath != "" {
+	if job.OutputName != "" {
+		baseName = job.OutputName
+	} else if job.FilePath != "" {
 		baseName = filepath.Base(job.FilePath)
 		ext := filepath.Ext(baseName)
 		baseName = strings.TrimSuffix(baseName, ext)
 	} else {
 		baseName = job.ID
 	}
+	if p.reservedName(job, baseName) {
+		baseName += " 2"
+	}
 
-	filename := fmt.Sprintf("%s.md", baseName)
-	return filepath.Join(p.cfg.OutputDir, filename)
+	filename := localFilename(baseName) + p.outputExtension(job)
+	return filepath.Join(p.outputDir(job), filename)
+}
+
+// outputDir returns the directory a job's summary is written to: a per-feed
+// subfolder when enabled, the output directory otherwise, followed by the
+// dated subfolders of the output layout
+func (p *Processor) outputDir(job *models.Job) string {
+	dir := p.cfg.OutputDir
+	if p.cfg.FeedSubfolders && job.Feed != "" {
+		if name := localFilename(sanitizeFilename(job.Feed)); name != "" {
+			dir = filepath.Join(dir, name)
+		}
+	}
+	if p.cfg.OutputLayout != "" {
+		// The creation time keeps retries of a job in the same folder
+		created := job.CreatedAt
+		if created.IsZero() {
+			created = time.Now()
+		}
+		dir = filepath.Join(dir, expandLayout(p.cfg.OutputLayout, created))
+	}
+	return dir
 }
 
 func (p *Processor) outputExists(job *models.Job) (bool, error) {
@@ -269,18 +1119,16 @@ func (p *Processor) outputExists(job *models.Job) (bool, error) {
 }
 
 func (p *Processor) saveSummary(job *models.Job) error {
-	if err := os.MkdirAll(p.cfg.OutputDir, 0755); err != nil {
+	if err := os.MkdirAll(p.outputDir(job), 0755); err != nil {
 		return err
 	}
 
 	path := p.getOutputPath(job)
 
-	content := fmt.Sprintf("# Summary\n\n**URL:** %s\n**Type:** %s\n**Generated:** %s\n\n---\n\n%s",
-		job.URL,
-		job.ContentType,
-		time.Now().Format(time.RFC3339),
-		job.Summary,
-	)
+	content, err := p.renderSummary(job)
+	if err != nil {
+		return err
+	}
 
 	// Use O_EXCL for atomic creation - fails if file already exists (race condition)
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
@@ -292,6 +1140,29 @@ func (p *Processor) saveSummary(job *models.Job) error {
 	}
 	defer f.Close()
 
-	_, err = f.WriteString(content)
-	return err
+	if _, err := f.WriteString(content); err != nil {
+		return err
+	}
+
+	job.OutputPath = path
+	return nil
+}
+
+// transcriptSuffix ends the name of the file holding the content a summary
+// was written from
+const transcriptSuffix = ".transcript.md"
+
+// saveTranscript writes the extracted content of job next to its summary,
+// replacing the one of a previous summary under the same name
+func (p *Processor) saveTranscript(job *models.Job) error {
+	if job.Content == "" || job.Content == attachedDocumentContent {
+		return nil
+	}
+	path := strings.TrimSuffix(job.OutputPath, filepath.Ext(job.OutputPath)) + transcriptSuffix
+	heading := "Transcript"
+	if job.Title != "" {
+		heading = job.Title + " (transcript)"
+	}
+	content := fmt.Sprintf("# %s\n\n**Source:** %s\n**Type:** %s\n\n---\n\n%s\n", heading, job.Source(), job.ContentType, job.Content)
+	return os.WriteFile(path, []byte(content), 0644)
 }
@@ -0,0 +1,18 @@
+package processor
+
+import "context"
+
+type progressKey struct{}
+
+// withProgress passes report down to the extractors, which call it when a
+// long step of the job, such as a download or a transcription, starts
+func withProgress(ctx context.Context, report func(stage string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// progress reports the stage of the job running with ctx, if any
+func progress(ctx context.Context, stage string) {
+	if report, ok := ctx.Value(progressKey{}).(func(string)); ok {
+		report(stage)
+	}
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+const (
+	receiptDone   = ".done"
+	receiptFailed = ".failed"
+)
+
+// writeReceipt leaves a "<name>.done" or "<name>.failed" file next to the
+// job's input file, so the submitting device sees the outcome after a sync
+func (p *Processor) writeReceipt(job *models.Job, ext string) {
+	if !p.cfg.Receipts || job.FilePath == "" {
+		return
+	}
+
+	// Receipts of dead-lettered jobs stay in the inbox
+	dir, name := filepath.Split(job.FilePath)
+	if filepath.Clean(dir) == p.failedDir() {
+		dir = p.cfg.WatchDir
+	}
+	base := filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name)))
+	var content string
+	switch ext {
+	case receiptDone:
+		// A retried job may have failed before
+		os.Remove(base + receiptFailed)
+		output := job.OutputPath
+		if output == "" {
+			output = "already summarized"
+		}
+		content = fmt.Sprintf("Completed: %s\nSource: %s\nSummary: %s\n",
+			time.Now().Format(time.RFC3339), job.Source(), output)
+	case receiptFailed:
+		content = fmt.Sprintf("Failed: %s\nSource: %s\nError: %s\n",
+			time.Now().Format(time.RFC3339), job.Source(), job.Error)
+	}
+
+	if err := os.WriteFile(base+ext, []byte(content), 0644); err != nil {
+		jobLogger(job).Warn("Failed to write receipt", "error", err)
+	}
+}
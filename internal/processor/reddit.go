@@ -0,0 +1,136 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redditPostPath matches the path of a Reddit post: "/r/<sub>/comments/<id>/..."
+var redditPostPath = regexp.MustCompile(`^(?:/r/[^/]+)?/comments/([a-z0-9]+)`)
+
+// parseRedditURL returns the ID of the Reddit post at u, if it is one
+func parseRedditURL(u *url.URL) (string, bool) {
+	host := strings.ToLower(u.Host)
+	if host == "redd.it" {
+		id := strings.Trim(u.Path, "/")
+		return id, id != "" && !strings.Contains(id, "/")
+	}
+	if host != "reddit.com" && !strings.HasSuffix(host, ".reddit.com") {
+		return "", false
+	}
+	m := redditPostPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// RedditExtractor fetches a Reddit post and its top comments from the JSON
+// version of the post page, which needs no API credentials
+type RedditExtractor struct {
+	client   *http.Client
+	comments int
+}
+
+// NewRedditExtractor includes up to comments top-level comments, 0 for the
+// post alone
+func NewRedditExtractor(comments int) *RedditExtractor {
+	return &RedditExtractor{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		comments: comments,
+	}
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Kind string     `json:"kind"`
+			Data redditItem `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditItem struct {
+	Title       string `json:"title"`
+	Subreddit   string `json:"subreddit_name_prefixed"`
+	Author      string `json:"author"`
+	Score       int    `json:"score"`
+	SelfText    string `json:"selftext"`
+	Body        string `json:"body"`
+	URL         string `json:"url"`
+	IsSelf      bool   `json:"is_self"`
+	Stickied    bool   `json:"stickied"`
+	NumComments int    `json:"num_comments"`
+}
+
+func (e *RedditExtractor) Extract(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	id, ok := parseRedditURL(u)
+	if !ok {
+		return "", fmt.Errorf("not a Reddit post URL: %s", rawURL)
+	}
+
+	query := url.Values{"sort": {"top"}, "depth": {"1"}, "raw_json": {"1"}}
+	if e.comments > 0 {
+		// Stickied comments and "more" placeholders take some of the slots
+		query.Set("limit", fmt.Sprint(e.comments+5))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.reddit.com/comments/"+id+".json?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	// Reddit throttles requests with generic user agents
+	req.Header.Set("User-Agent", "briefly/1.0 (summarizer)")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Reddit post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("reddit returned status %d", resp.StatusCode)
+	}
+
+	var listings []redditListing
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return "", fmt.Errorf("failed to decode Reddit post: %w", err)
+	}
+	if len(listings) == 0 || len(listings[0].Data.Children) == 0 {
+		return "", fmt.Errorf("%w: Reddit post not found", ErrNoContent)
+	}
+	post := listings[0].Data.Children[0].Data
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nPosted in %s by u/%s (score %d, %d comments)\n", post.Title, post.Subreddit, post.Author, post.Score, post.NumComments)
+	if !post.IsSelf && post.URL != "" {
+		fmt.Fprintf(&b, "Linked: %s\n", post.URL)
+	}
+	if text := strings.TrimSpace(post.SelfText); text != "" {
+		fmt.Fprintf(&b, "\n%s\n", text)
+	}
+
+	if e.comments > 0 && len(listings) > 1 {
+		b.WriteString("\n## Top comments\n")
+		n := 0
+		for _, child := range listings[1].Data.Children {
+			c := child.Data
+			if child.Kind != "t1" || c.Stickied || c.Author == "[deleted]" || c.Author == "AutoModerator" {
+				continue
+			}
+			fmt.Fprintf(&b, "\nu/%s (score %d):\n%s\n", c.Author, c.Score, strings.TrimSpace(c.Body))
+			if n++; n == e.comments {
+				break
+			}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// retryUnlimited is the retry count of an error class always retried
+const retryUnlimited = -1
+
+// retryPolicy says how often and how soon failed jobs are retried
+type retryPolicy struct {
+	maxRetries int
+	base       time.Duration
+	max        time.Duration
+	// jitter is the fraction by which a backoff randomly varies either way,
+	// so jobs failing together are not retried together
+	jitter float64
+
+	// classes overrides maxRetries for the errors of some codes
+	classes map[models.ErrorCode]int
+}
+
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	// The policy is checked on startup, see ParseRetryClasses
+	classes, _ := ParseRetryClasses(cfg.RetryPolicy)
+	return retryPolicy{
+		maxRetries: max(cfg.MaxRetries, 0),
+		base:       time.Duration(cfg.RetryBackoffSeconds) * time.Second,
+		max:        time.Duration(cfg.RetryMaxBackoffSeconds) * time.Second,
+		jitter:     float64(min(max(cfg.RetryJitterPercent, 0), 100)) / 100,
+		classes:    classes,
+	}
+}
+
+// ParseRetryClasses parses the retries of error classes, a comma-separated
+// list of code=N, code=never, or code=always, e.g.
+// "provider_rejected=never,timeout=always"
+func ParseRetryClasses(spec string) (map[models.ErrorCode]int, error) {
+	classes := make(map[models.ErrorCode]int)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(item, "=")
+		code, value = strings.TrimSpace(code), strings.TrimSpace(value)
+		if !ok || code == "" {
+			return nil, fmt.Errorf("invalid retry policy %q, expected code=N, code=never, or code=always", item)
+		}
+		switch value {
+		case "never":
+			classes[models.ErrorCode(code)] = 0
+		case "always":
+			classes[models.ErrorCode(code)] = retryUnlimited
+		default:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid retries %q for %s, expected a number, never, or always", value, code)
+			}
+			classes[models.ErrorCode(code)] = n
+		}
+	}
+	return classes, nil
+}
+
+// limit returns the retries allowed for errors of code, or retryUnlimited
+func (r retryPolicy) limit(code models.ErrorCode) int {
+	if n, ok := r.classes[code]; ok {
+		return n
+	}
+	return r.maxRetries
+}
+
+// allows reports whether a job failed retries times may be retried after
+// an error of code
+func (r retryPolicy) allows(retries int, code models.ErrorCode) bool {
+	n := r.limit(code)
+	return n == retryUnlimited || retries < n
+}
+
+// backoff returns the wait before the nth retry, doubling each time up to
+// the maximum
+func (r retryPolicy) backoff(n int) time.Duration {
+	d := r.base
+	for i := 1; i < n && (r.max <= 0 || d < r.max); i++ {
+		d *= 2
+	}
+	if r.max > 0 && d > r.max {
+		d = r.max
+	}
+	if r.jitter > 0 && d > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * r.jitter * float64(d))
+	}
+	return d
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blankLines collapses the runs of empty lines left by nested blocks
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// blockElements start a new line in the extracted text
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "blockquote": true,
+	"pre": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true,
+	"figure": true, "figcaption": true, "br": true, "hr": true,
+}
+
+// articleText renders the readable article as plain text, keeping its
+// headings as Markdown "#" lines so long articles can be summarized section
+// by section
+func articleText(node *html.Node) string {
+	var b strings.Builder
+	renderText(&b, node)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func renderText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		switch n.Data {
+		case "script", "style", "noscript":
+			return
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level := int(n.Data[1] - '0')
+			b.WriteString("\n\n" + strings.Repeat("#", level) + " ")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				renderText(b, c)
+			}
+			b.WriteString("\n\n")
+			return
+		case "li":
+			b.WriteString("\n- ")
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockElements[n.Data]
+	if block && n.Data != "li" {
+		b.WriteString("\n\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderText(b, c)
+	}
+	if block {
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sponsorBlockAPI returns the segments the SponsorBlock community marked in
+// a video
+const sponsorBlockAPI = "https://sponsor.ajay.app/api/skipSegments"
+
+// SetSponsorBlock removes the SponsorBlock segments of the comma-separated
+// categories (such as "sponsor,selfpromo") before transcription; empty
+// keeps videos whole
+func (y *YouTubeProcessor) SetSponsorBlock(categories string) {
+	y.sponsorBlock = strings.ReplaceAll(categories, " ", "")
+}
+
+// sponsorSegments returns the [start, end] seconds of the segments to drop
+// from the YouTube video at rawURL. Videos nobody submitted segments for
+// have none.
+func (y *YouTubeProcessor) sponsorSegments(ctx context.Context, rawURL string) ([][2]float64, error) {
+	id, ok := strings.CutPrefix(NormalizeURL(rawURL), "youtube:")
+	if !ok || y.sponsorBlock == "" {
+		return nil, nil
+	}
+	categories, err := json.Marshal(strings.Split(y.sponsorBlock, ","))
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"videoID": {id}, "categories": {string(categories)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sponsorBlockAPI+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SponsorBlock: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("SponsorBlock returned status %d", resp.StatusCode)
+	}
+
+	var segments []struct {
+		Segment [2]float64 `json:"segment"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&segments); err != nil {
+		return nil, fmt.Errorf("failed to decode SponsorBlock segments: %w", err)
+	}
+	ranges := make([][2]float64, len(segments))
+	for i, s := range segments {
+		ranges[i] = s.Segment
+	}
+	return ranges, nil
+}
+
+// dropSegments removes the captions starting within segments
+func dropSegments(cues []cue, segments [][2]float64) []cue {
+	if len(segments) == 0 {
+		return cues
+	}
+	kept := cues[:0:0]
+	for _, c := range cues {
+		skipped := false
+		for _, s := range segments {
+			if c.Start >= s[0] && c.Start < s[1] {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
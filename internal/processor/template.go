@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// OutputData is what output templates are executed with. The job fields
+// ({{.URL}}, {{.Title}}, {{.Summary}}, {{.Notes}}, {{.ContentType}},
+// {{.Provider}}, {{.Model}}, {{.Feed}}, ...) are available directly.
+type OutputData struct {
+	*models.Job
+
+	// Heading is the job title, or "Summary" for untitled jobs
+	Heading string
+	// Prompt is "custom" when the input set its own prompt, "default" otherwise
+	Prompt    string
+	Generated time.Time
+
+	// Provider and Model wrote the summary, shadowing the requested ones of
+	// the job, which a fallback may have replaced
+	Provider string
+	Model    string
+
+	// FrontMatter is an Obsidian-compatible YAML properties block,
+	// including its "---" delimiters
+	FrontMatter string
+}
+
+// frontMatter holds the summary properties written as YAML front matter
+type frontMatter struct {
+	Title    string   `yaml:"title"`
+	Source   string   `yaml:"source,omitempty"`
+	Type     string   `yaml:"type"`
+	Model    string   `yaml:"model"`
+	Feed     string   `yaml:"feed,omitempty"`
+	Language string   `yaml:"language,omitempty"`
+	Created  string   `yaml:"created"`
+	Tags     []string `yaml:"tags"`
+
+	InputTokens  int64   `yaml:"input_tokens,omitempty"`
+	OutputTokens int64   `yaml:"output_tokens,omitempty"`
+	CostUSD      float64 `yaml:"cost_usd,omitempty"`
+}
+
+// LoadOutputTemplate parses the summary file template given inline or as a
+// file path, returning nil when neither is set
+func LoadOutputTemplate(text, path string) (*template.Template, error) {
+	if text == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read output template: %w", err)
+		}
+		text = string(data)
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"date":  func(layout string, t time.Time) string { return t.Format(layout) },
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"yaml":  yamlScalar,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// SetOutputTemplate replaces the default summary file layout
+func (p *Processor) SetOutputTemplate(tmpl *template.Template) {
+	p.outputTemplate = tmpl
+}
+
+func newOutputData(job *models.Job) OutputData {
+	data := OutputData{
+		Job:       job,
+		Heading:   "Summary",
+		Prompt:    "default",
+		Generated: time.Now(),
+	}
+	data.Provider, data.Model = job.ServedBy()
+	if job.Title != "" {
+		data.Heading = job.Title
+	}
+	if job.CustomPrompt != "" {
+		data.Prompt = "custom"
+	}
+
+	props := frontMatter{
+		Title:    data.Heading,
+		Source:   job.URL,
+		Type:     string(job.ContentType),
+		Model:    data.Provider + "/" + data.Model,
+		Feed:     job.Feed,
+		Language: job.Language,
+		Created:  data.Generated.Format(time.RFC3339),
+		Tags:     []string{"briefly", strings.ReplaceAll(string(job.ContentType), "_", "-")},
+
+		InputTokens:  job.InputTokens,
+		OutputTokens: job.OutputTokens,
+		CostUSD:      math.Round(job.CostUSD*10000) / 10000,
+	}
+	for _, tag := range job.Tags {
+		if !slices.Contains(props.Tags, tag) {
+			props.Tags = append(props.Tags, tag)
+		}
+	}
+	if out, err := yaml.Marshal(props); err == nil {
+		data.FrontMatter = "---\n" + string(out) + "---\n"
+	}
+	return data
+}
+
+// renderSummary returns the content of job's summary file
+func (p *Processor) renderSummary(job *models.Job) (string, error) {
+	data := newOutputData(job)
+	format, err := p.formatFor(job)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case FormatJSON:
+		return renderJSON(job, data)
+	case FormatHTML:
+		return renderHTML(job, data), nil
+	case FormatText:
+		return renderPlainText(job, data), nil
+	}
+
+	if p.outputTemplate != nil {
+		var b strings.Builder
+		if err := p.outputTemplate.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("failed to render output template: %w", err)
+		}
+		if err := checkFrontMatter(b.String()); err != nil && p.cfg.LintOutput {
+			jobLogger(job).Warn("Output template produced broken front matter", "error", err)
+		}
+		return b.String(), nil
+	}
+
+	source := fmt.Sprintf("**URL:** %s", job.URL)
+	switch {
+	case job.IsDirectText:
+		source = "**Source:** direct text"
+	case job.ContentType == models.ContentTypeMedia:
+		source = fmt.Sprintf("**Source:** %s", filepath.Base(job.FilePath))
+	}
+	if job.Feed != "" {
+		source += fmt.Sprintf("\n**Feed:** %s", job.Feed)
+	}
+
+	var content string
+	if p.cfg.FrontMatter {
+		content = data.FrontMatter + "\n"
+	}
+	content += fmt.Sprintf("# %s\n\n%s\n**Type:** %s\n**Model:** %s/%s\n**Prompt:** %s\n**Generated:** %s\n\n---\n\n%s",
+		data.Heading,
+		source,
+		job.ContentType,
+		data.Provider,
+		data.Model,
+		data.Prompt,
+		data.Generated.Format(time.RFC3339),
+		job.Summary,
+	)
+
+	if job.Notes != "" {
+		content += fmt.Sprintf("\n\n## My notes\n\n%s\n", job.Notes)
+	}
+	return content, nil
+}
+
+// yamlScalar quotes a value for use in template front matter, so titles with
+// colons or quotes do not break it
+func yamlScalar(v any) string {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return `""`
+	}
+	return strings.TrimSpace(string(out))
+}
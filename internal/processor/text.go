@@ -7,10 +7,16 @@ import (
 	"time"
 
 	readability "github.com/go-shiori/go-readability"
+
+	"github.com/clobrano/briefly/internal/summarizer"
 )
 
 type TextExtractor struct {
 	client *http.Client
+
+	// maxImages is the number of article images kept for vision models,
+	// 0 disables image extraction
+	maxImages int
 }
 
 func NewTextExtractor() *TextExtractor {
@@ -21,15 +27,55 @@ func NewTextExtractor() *TextExtractor {
 	}
 }
 
+// SetMaxImages enables downloading up to n key images of each article
+func (t *TextExtractor) SetMaxImages(n int) {
+	t.maxImages = n
+}
+
 func (t *TextExtractor) Extract(ctx context.Context, url string) (string, error) {
 	article, err := readability.FromURL(url, 30*time.Second)
 	if err != nil {
 		return "", fmt.Errorf("failed to extract content: %w", err)
 	}
 
-	if article.TextContent == "" {
-		return "", fmt.Errorf("no text content extracted from URL")
+	text := readableText(article)
+	if text == "" {
+		return "", fmt.Errorf("%w from URL", ErrNoContent)
 	}
 
-	return article.TextContent, nil
+	archiveArticle(ctx, article)
+	return text, nil
+}
+
+// ExtractWithImages is Extract that also returns the article's key images,
+// when enabled, so charts and infographics can be read by a vision model
+func (t *TextExtractor) ExtractWithImages(ctx context.Context, url string) (string, []summarizer.Document, error) {
+	if t.maxImages <= 0 {
+		text, err := t.Extract(ctx, url)
+		return text, nil, err
+	}
+
+	article, err := readability.FromURL(url, 30*time.Second)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract content: %w", err)
+	}
+
+	images := t.downloadImages(ctx, articleImages(article.Image, article.Content), t.maxImages)
+	text := readableText(article)
+	if text == "" && len(images) == 0 {
+		return "", nil, fmt.Errorf("%w from URL", ErrNoContent)
+	}
+
+	archiveArticle(ctx, article)
+	return text, images, nil
+}
+
+// readableText returns the article text with its headings preserved
+func readableText(article readability.Article) string {
+	if article.Node != nil {
+		if text := articleText(article.Node); text != "" {
+			return text
+		}
+	}
+	return article.TextContent
 }
@@ -0,0 +1,278 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// statusPath matches the path of a tweet: "/<user>/status/<id>"
+var statusPath = regexp.MustCompile(`^/([A-Za-z0-9_]+)/status(?:es)?/(\d+)`)
+
+// threadHosts are Twitter/X hosts and the Nitter instances people share links from
+var threadHosts = map[string]bool{
+	"twitter.com":        true,
+	"mobile.twitter.com": true,
+	"x.com":              true,
+	"mobile.x.com":       true,
+	"nitter.net":         true,
+}
+
+// parseStatusURL returns the author and ID of the tweet at u, if it is one
+func parseStatusURL(u *url.URL) (user, id string, ok bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if !threadHosts[host] {
+		return "", "", false
+	}
+	m := statusPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// ThreadExtractor unrolls a Twitter/X thread into the text of its tweets,
+// through the X API when a bearer token is set, otherwise through Nitter
+// instances. Readability gets nothing from x.com pages, which require
+// JavaScript.
+type ThreadExtractor struct {
+	client    *http.Client
+	token     string
+	instances []string
+}
+
+// NewThreadExtractor uses the X API with token when set, otherwise the
+// comma-separated Nitter instances in order
+func NewThreadExtractor(token, instances string) *ThreadExtractor {
+	e := &ThreadExtractor{
+		client: &http.Client{Timeout: 30 * time.Second},
+		token:  token,
+	}
+	for _, instance := range strings.Split(instances, ",") {
+		if instance = strings.TrimSpace(instance); instance != "" {
+			e.instances = append(e.instances, instance)
+		}
+	}
+	return e
+}
+
+func (e *ThreadExtractor) Extract(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	user, id, ok := parseStatusURL(u)
+	if !ok {
+		return "", fmt.Errorf("not a tweet URL: %s", rawURL)
+	}
+
+	if e.token != "" {
+		return e.fromAPI(ctx, id)
+	}
+
+	var errs []error
+	for _, instance := range e.instances {
+		text, err := e.fromNitter(ctx, instance, user, id)
+		if err == nil {
+			return text, nil
+		}
+		slog.Debug("Nitter instance failed", "instance", instance, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", instance, err))
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no X API token or Nitter instance configured")
+	}
+	return "", fmt.Errorf("failed to unroll thread: %w", errors.Join(errs...))
+}
+
+type apiTweet struct {
+	ID             string    `json:"id"`
+	Text           string    `json:"text"`
+	AuthorID       string    `json:"author_id"`
+	ConversationID string    `json:"conversation_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	// NoteTweet holds the full text of tweets longer than 280 characters
+	NoteTweet *struct {
+		Text string `json:"text"`
+	} `json:"note_tweet"`
+}
+
+func (t apiTweet) fullText() string {
+	if t.NoteTweet != nil && t.NoteTweet.Text != "" {
+		return t.NoteTweet.Text
+	}
+	return t.Text
+}
+
+// fromAPI fetches the tweet and the replies its author posted in the same
+// conversation. The search endpoint only covers the last seven days, so
+// older threads come back as their first tweet.
+func (e *ThreadExtractor) fromAPI(ctx context.Context, id string) (string, error) {
+	const fields = "tweet.fields=conversation_id,author_id,created_at,note_tweet"
+
+	var root struct {
+		Data     apiTweet `json:"data"`
+		Includes struct {
+			Users []struct {
+				ID       string `json:"id"`
+				Name     string `json:"name"`
+				Username string `json:"username"`
+			} `json:"users"`
+		} `json:"includes"`
+	}
+	if err := e.getJSON(ctx, "https://api.x.com/2/tweets/"+id+"?expansions=author_id&user.fields=username&"+fields, &root); err != nil {
+		return "", err
+	}
+
+	author := root.Data.AuthorID
+	for _, u := range root.Includes.Users {
+		if u.ID == root.Data.AuthorID {
+			author = fmt.Sprintf("%s (@%s)", u.Name, u.Username)
+			query := url.QueryEscape(fmt.Sprintf("conversation_id:%s from:%s", root.Data.ConversationID, u.Username))
+			var replies struct {
+				Data []apiTweet `json:"data"`
+			}
+			if err := e.getJSON(ctx, "https://api.x.com/2/tweets/search/recent?max_results=100&query="+query+"&"+fields, &replies); err != nil {
+				slog.Warn("Failed to fetch the rest of the thread", "tweet", id, "error", err)
+			}
+			return formatThread(author, threadFromAPI(root.Data, replies.Data)), nil
+		}
+	}
+	return formatThread(author, []string{root.Data.fullText()}), nil
+}
+
+// threadFromAPI orders the tweets of a conversation, starting from root
+func threadFromAPI(root apiTweet, replies []apiTweet) []string {
+	tweets := append([]apiTweet{root}, replies...)
+	slices.SortFunc(tweets, func(a, b apiTweet) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	var texts []string
+	seen := make(map[string]bool)
+	for _, t := range tweets {
+		// The thread starts at the shared tweet, even if it is a reply itself
+		if t.CreatedAt.Before(root.CreatedAt) || seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		texts = append(texts, t.fullText())
+	}
+	return texts
+}
+
+func (e *ThreadExtractor) getJSON(ctx context.Context, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+e.token)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the X API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("X API returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// fromNitter reads the thread from a Nitter status page, where the tweets
+// the author chained below the shared one follow it in the main thread
+func (e *ThreadExtractor) fromNitter(ctx context.Context, instance, user, id string) (string, error) {
+	base := instance
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+"/"+user+"/status/"+id, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	scope := findClass(doc, "main-thread")
+	if scope == nil {
+		scope = doc
+	}
+	var tweets []string
+	author := "@" + user
+	for n := range scope.Descendants() {
+		switch {
+		case hasClass(n, "tweet-content"):
+			if text := strings.TrimSpace(nodeText(n)); text != "" {
+				tweets = append(tweets, text)
+			}
+		case hasClass(n, "fullname") && len(tweets) == 0:
+			author = fmt.Sprintf("%s (@%s)", strings.TrimSpace(nodeText(n)), user)
+		}
+	}
+	if len(tweets) == 0 {
+		return "", fmt.Errorf("%w on the page", ErrNoContent)
+	}
+	return formatThread(author, tweets), nil
+}
+
+func formatThread(author string, tweets []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Thread by %s\n", author)
+	for i, tweet := range tweets {
+		fmt.Fprintf(&b, "\n[%d/%d]\n%s\n", i+1, len(tweets), tweet)
+	}
+	return b.String()
+}
+
+func hasClass(n *html.Node, class string) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, attr := range n.Attr {
+		if attr.Key == "class" && slices.Contains(strings.Fields(attr.Val), class) {
+			return true
+		}
+	}
+	return false
+}
+
+func findClass(n *html.Node, class string) *html.Node {
+	for d := range n.Descendants() {
+		if hasClass(d, class) {
+			return d
+		}
+	}
+	return nil
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	for d := range n.Descendants() {
+		switch {
+		case d.Type == html.TextNode:
+			b.WriteString(d.Data)
+		case d.Type == html.ElementNode && d.Data == "br":
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
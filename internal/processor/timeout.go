@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clobrano/briefly/internal/config"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// stageTimeouts bound the stages of a job; zero leaves a stage unbounded
+type stageTimeouts struct {
+	download      time.Duration
+	transcription time.Duration
+	extraction    time.Duration
+	summarization time.Duration
+}
+
+func newStageTimeouts(cfg *config.Config) stageTimeouts {
+	return stageTimeouts{
+		download:      time.Duration(cfg.DownloadTimeoutMinutes) * time.Minute,
+		transcription: time.Duration(cfg.TranscriptionTimeoutMinutes) * time.Minute,
+		extraction:    time.Duration(cfg.ExtractionTimeoutMinutes) * time.Minute,
+		summarization: time.Duration(cfg.SummarizationTimeoutMinutes) * time.Minute,
+	}
+}
+
+// unboundedWorkDirAge is how long a work directory is kept when the stages
+// using it have no timeout
+const unboundedWorkDirAge = 24 * time.Hour
+
+// WorkDirMaxAge returns the longest a job uses its work directory, through
+// the download, transcription, and extraction of its content
+func WorkDirMaxAge(cfg *config.Config) time.Duration {
+	if age := newStageTimeouts(cfg).extractionFor(models.ContentTypeYouTube); age > 0 {
+		return age
+	}
+	return unboundedWorkDirAge
+}
+
+// extractionFor returns the timeout of extracting content of contentType.
+// Audio and video are downloaded and transcribed within their own
+// timeouts, so their extraction gets the time of those steps on top.
+func (t stageTimeouts) extractionFor(contentType models.ContentType) time.Duration {
+	if t.extraction <= 0 || !isTranscribed(contentType) {
+		return t.extraction
+	}
+	if t.download <= 0 || t.transcription <= 0 {
+		return 0
+	}
+	return t.extraction + t.download + t.transcription
+}
+
+// stageContext returns ctx bounded by timeout, when there is one
+func stageContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// withinStage runs step bounded by the timeout of stage. A step killed by
+// its deadline, such as an external program, often fails with an unrelated
+// error, which is then reported as a timeout.
+func withinStage(ctx context.Context, stage string, timeout time.Duration, step func(context.Context) error) error {
+	stageCtx, cancel := stageContext(ctx, timeout)
+	defer cancel()
+	err := step(stageCtx)
+	if err != nil && stageCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return fmt.Errorf("%s exceeded its %s timeout: %w: %w", stage, timeout, context.DeadlineExceeded, err)
+	}
+	return err
+}
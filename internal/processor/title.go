@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// genericName matches file names that say nothing about their content, such
+// as "note (3)", "Untitled", "New Document 2", or bare timestamps
+var genericName = regexp.MustCompile(`(?i)^(|new|note|notes|untitled|document|doc|text|file|link|url|share|shared|clip|clipboard|memo|briefly)?[ _.-]*(document|file|note|text)?[ _.-]*(\(?\d*\)?)?$|^[\d _.:-]+$`)
+
+// maxTitleLength bounds generated output file names
+const maxTitleLength = 80
+
+// needsTitle reports whether a job has no meaningful name of its own
+func needsTitle(job *models.Job) bool {
+	if job.OutputName != "" {
+		return false
+	}
+	if job.IsDirectText || job.FilePath == "" {
+		return true
+	}
+	return genericName.MatchString(strings.TrimSpace(job.Filename))
+}
+
+// sanitizeFilename turns a title into a safe, portable file name
+func sanitizeFilename(title string) string {
+	var b strings.Builder
+	lastSpace := false
+	for _, r := range title {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastSpace = false
+		case r == '-' || r == '_':
+			b.WriteRune(r)
+			lastSpace = false
+		case unicode.IsSpace(r) || r == '/' || r == ':' || r == '.':
+			if !lastSpace && b.Len() > 0 {
+				b.WriteRune(' ')
+				lastSpace = true
+			}
+		}
+	}
+
+	name := strings.TrimSpace(b.String())
+	if len(name) > maxTitleLength {
+		name = strings.TrimSpace(name[:maxTitleLength])
+		// Avoid cutting a multi-byte rune in half
+		name = strings.ToValidUTF8(name, "")
+	}
+	return name
+}
+
+// windowsDevice matches the device names Windows reserves, which no file
+// can be named after, whatever its extension
+var windowsDevice = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9¹²³]|lpt[0-9¹²³])$`)
+
+// windowsReserved lists the characters Windows does not allow in file names
+const windowsReserved = `<>:"/\|?*`
+
+// localFilename adapts the base name of a file to the operating system. On
+// Windows, reserved characters are replaced, trailing dots and spaces
+// trimmed, and device names suffixed; elsewhere name is kept as is.
+func localFilename(name string) string {
+	if runtime.GOOS != "windows" {
+		return name
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(windowsReserved, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+	if stem, _, _ := strings.Cut(name, "."); windowsDevice.MatchString(stem) {
+		name = stem + "_" + name[len(stem):]
+	}
+	return name
+}
+
+// uniqueOutputName returns name, or name with a numeric suffix when a
+// summary with that name already exists in the job's output directory, or
+// the name is reserved for the summary index. It fails when whether a
+// summary exists cannot be told, such as in an unreadable directory.
+func (p *Processor) uniqueOutputName(job *models.Job, name string) (string, error) {
+	dir := p.outputDir(job)
+	candidate := name
+	for i := 2; ; i++ {
+		_, err := os.Stat(filepath.Join(dir, localFilename(candidate)+p.outputExtension(job)))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err != nil && !p.reservedName(job, candidate) {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s %d", name, i)
+	}
+}
+
+// reservedName reports whether name would collide with the summary index,
+// index.md and index.json at the top of the output directory. The name is
+// reserved even while no index is written, so enabling it later does not
+// clash with existing summaries.
+func (p *Processor) reservedName(job *models.Job, name string) bool {
+	return p.outputDir(job) == p.cfg.OutputDir && strings.EqualFold(localFilename(name), "index")
+}
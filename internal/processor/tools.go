@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ToolInfo describes an external program used by the video pipeline
+type ToolInfo struct {
+	Name    string
+	Path    string
+	Version string
+	// Err is set when the program is missing or its version is unknown
+	Err error
+}
+
+// Found reports whether the program is installed
+func (t ToolInfo) Found() bool {
+	return t.Path != ""
+}
+
+// DetectTools looks up yt-dlp, whisper, and ffmpeg and asks each for its version
+func DetectTools(ctx context.Context) []ToolInfo {
+	return []ToolInfo{
+		detectTool(ctx, "yt-dlp", ytDlpVersion),
+		detectTool(ctx, "whisper", whisperVersion),
+		detectTool(ctx, "ffmpeg", ffmpegVersion),
+	}
+}
+
+func detectTool(ctx context.Context, name string, version func(ctx context.Context, path string) (string, error)) ToolInfo {
+	info := ToolInfo{Name: name}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		info.Err = fmt.Errorf("%s not found in PATH", name)
+		return info
+	}
+	info.Path = path
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	info.Version, info.Err = version(ctx, path)
+	return info
+}
+
+func ytDlpVersion(ctx context.Context, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("yt-dlp --version failed: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// whisperVersion reads the openai-whisper package version, since the whisper
+// command has no version flag
+func whisperVersion(ctx context.Context, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, "python3", "-c",
+		"import importlib.metadata as m; print(m.version('openai-whisper'))").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read openai-whisper version: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func ffmpegVersion(ctx context.Context, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, path, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg -version failed: %w", err)
+	}
+	// "ffmpeg version 6.1.1-3ubuntu5 Copyright ..."
+	line, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected ffmpeg version output: %q", line)
+	}
+	return fields[2], nil
+}
+
+// YtDlpReleaseDate parses the release date out of a yt-dlp version, which
+// is the date of the release ("2024.08.06", or "2024.08.06.232818" for
+// nightly builds)
+func YtDlpReleaseDate(version string) (time.Time, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 3 {
+		return time.Time{}, fmt.Errorf("unrecognized yt-dlp version %q", version)
+	}
+	return time.Parse("2006.01.02", strings.Join(parts[:3], "."))
+}
+
+// YtDlpOutdated reports whether the yt-dlp release is older than maxAge,
+// returning its age. Unparseable versions are never reported as outdated.
+func YtDlpOutdated(version string, maxAge time.Duration) (bool, time.Duration) {
+	released, err := YtDlpReleaseDate(version)
+	if err != nil || maxAge <= 0 {
+		return false, 0
+	}
+	age := time.Since(released)
+	return age > maxAge, age
+}
@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/language"
+)
+
+// Transcriber turns an audio file into text. Transcribers that detect the
+// spoken language report it with reportLanguage.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioPath, lang string) (string, error)
+}
+
+type languageKey struct{}
+
+// languageHint is the language declared for a job, if any, and where the
+// language detected by transcription is reported
+type languageHint struct {
+	declared string
+	detected func(lang string)
+}
+
+// withLanguage passes the declared language of the content down to the
+// extractors, and detected up from them
+func withLanguage(ctx context.Context, declared string, detected func(lang string)) context.Context {
+	return context.WithValue(ctx, languageKey{}, languageHint{declared: declared, detected: detected})
+}
+
+// declaredLanguage returns the language declared for the job running with
+// ctx, if any
+func declaredLanguage(ctx context.Context) string {
+	hint, _ := ctx.Value(languageKey{}).(languageHint)
+	return hint.declared
+}
+
+// reportLanguage records the ISO 639-1 code of the language the content of
+// the job running with ctx turned out to be in
+func reportLanguage(ctx context.Context, lang string) {
+	if hint, ok := ctx.Value(languageKey{}).(languageHint); ok && hint.detected != nil && lang != "" {
+		hint.detected(lang)
+	}
+}
+
+const (
+	// DefaultTranscriberURL is the OpenAI endpoint; Groq's is
+	// https://api.groq.com/openai/v1
+	DefaultTranscriberURL = "https://api.openai.com/v1"
+
+	// maxUploadSize is the file size limit of the hosted Whisper APIs
+	maxUploadSize = 25 << 20
+
+	// uploadSegment is the length of the pieces longer recordings are split
+	// into; at 32 kbit/s mono an hour is about 14 MB
+	uploadSegment = time.Hour
+)
+
+// APITranscriber uploads audio to an OpenAI-compatible transcription
+// endpoint instead of running Whisper locally
+type APITranscriber struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+func NewAPITranscriber(baseURL, apiKey, model string) *APITranscriber {
+	if baseURL == "" {
+		baseURL = DefaultTranscriberURL
+	}
+	return &APITranscriber{
+		client:  &http.Client{Timeout: 10 * time.Minute},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+	}
+}
+
+func (a *APITranscriber) Transcribe(ctx context.Context, audioPath, lang string) (string, error) {
+	// Re-encode to low-bitrate mono speech audio to stay under the upload
+	// limit, splitting recordings that are still too long
+	segments, err := a.prepare(ctx, audioPath)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for i, segment := range segments {
+		text, detected, err := a.upload(ctx, segment, lang)
+		if err != nil {
+			return "", fmt.Errorf("segment %d/%d: %w", i+1, len(segments), err)
+		}
+		if i == 0 {
+			reportLanguage(ctx, language.Code(detected))
+		}
+		parts = append(parts, strings.TrimSpace(text))
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+func (a *APITranscriber) prepare(ctx context.Context, audioPath string) ([]string, error) {
+	workDir := filepath.Dir(audioPath)
+	pattern := filepath.Join(workDir, "upload-%03d.mp3")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-loglevel", "error", "-y",
+		"-i", audioPath,
+		"-ac", "1", "-ar", "16000", "-b:a", "32k",
+		"-f", "segment", "-segment_time", fmt.Sprint(int(uploadSegment.Seconds())),
+		pattern,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	segments, err := filepath.Glob(filepath.Join(workDir, "upload-*.mp3"))
+	if err != nil || len(segments) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no audio")
+	}
+	sort.Strings(segments)
+
+	for _, segment := range segments {
+		if info, err := os.Stat(segment); err == nil && info.Size() > maxUploadSize {
+			return nil, fmt.Errorf("audio segment %s exceeds the %d MB upload limit", filepath.Base(segment), maxUploadSize>>20)
+		}
+	}
+	if len(segments) > 1 {
+		slog.Info("Uploading audio in segments", "segments", len(segments))
+	}
+	return segments, nil
+}
+
+// upload transcribes the audio at path, returning its text and, when lang is
+// empty, the language the endpoint detected
+func (a *APITranscriber) upload(ctx context.Context, path, lang string) (string, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("model", a.model)
+	if lang != "" {
+		w.WriteField("response_format", "json")
+		w.WriteField("language", lang)
+	} else {
+		// Only the verbose format includes the detected language
+		w.WriteField("response_format", "verbose_json")
+	}
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("transcription API error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("transcription API error: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", "", fmt.Errorf("transcription API error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", "", fmt.Errorf("transcription API error: %w", err)
+	}
+	return result.Text, result.Language, nil
+}
@@ -0,0 +1,28 @@
+package processor
+
+import "time"
+
+// SetWatchdog calls keepalive every interval while the processor runs, so
+// a supervisor such as systemd restarts a daemon whose processor is stuck
+func (p *Processor) SetWatchdog(interval time.Duration, keepalive func()) {
+	p.watchdogInterval = interval
+	p.keepalive = keepalive
+}
+
+// watchdog sends keepalives for as long as the queue responds. Jobs run for
+// longer than any sensible watchdog timeout, so workers busy with one do
+// not hold keepalives back, but a deadlocked queue, which would stall every
+// worker, does.
+func (p *Processor) watchdog() {
+	ticker := time.NewTicker(p.watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.queue.Len()
+			p.keepalive()
+		}
+	}
+}
@@ -3,48 +3,216 @@ package processor
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/language"
 )
 
 type YouTubeProcessor struct {
 	whisperModel string
 	tempDir      string
+
+	// useCaptions reads existing subtitles or automatic captions before
+	// falling back to Whisper
+	useCaptions bool
+
+	// useChapters structures transcripts by chapter, with timestamps
+	useChapters bool
+
+	// sponsorBlock lists the SponsorBlock categories cut from videos
+	sponsorBlock string
+
+	// language is the ISO 639-1 code of the spoken language, detected
+	// when empty
+	language string
+
+	// ytdlpArgs are passed to every yt-dlp run, such as cookies for
+	// age-restricted or members-only videos
+	ytdlpArgs []string
+
+	// transcriber replaces the local Whisper run when set
+	transcriber Transcriber
+
+	// downloadTimeout and transcriptionTimeout bound each step, when set
+	downloadTimeout      time.Duration
+	transcriptionTimeout time.Duration
 }
 
-func NewYouTubeProcessor(whisperModel string) *YouTubeProcessor {
-	tempDir := os.TempDir()
+// tempDirPattern names the per-job work directories
+const tempDirPattern = "briefly-yt-*"
+
+// videoProbeTimeout bounds the yt-dlp check run on article URLs
+const videoProbeTimeout = 30 * time.Second
+
+func NewYouTubeProcessor(whisperModel, tempDir string) *YouTubeProcessor {
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
 	return &YouTubeProcessor{
 		whisperModel: whisperModel,
 		tempDir:      tempDir,
 	}
 }
 
+// SetCaptions enables fetching existing captions before transcribing
+func (y *YouTubeProcessor) SetCaptions(enabled bool) {
+	y.useCaptions = enabled
+}
+
+// SetDownloadOptions passes the cookies file and proxy, when set, and the
+// space-separated extra arguments to every yt-dlp run
+func (y *YouTubeProcessor) SetDownloadOptions(cookies, proxy, extraArgs string) {
+	y.ytdlpArgs = nil
+	if cookies != "" {
+		y.ytdlpArgs = append(y.ytdlpArgs, "--cookies", cookies)
+	}
+	if proxy != "" {
+		y.ytdlpArgs = append(y.ytdlpArgs, "--proxy", proxy)
+	}
+	y.ytdlpArgs = append(y.ytdlpArgs, strings.Fields(extraArgs)...)
+}
+
+// ytdlp prepares a yt-dlp run with the configured download options
+func (y *YouTubeProcessor) ytdlp(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "yt-dlp", append(slices.Clone(y.ytdlpArgs), args...)...)
+}
+
+// SetLanguage transcribes audio in lang rather than in the language the
+// platform declares or Whisper detects
+func (y *YouTubeProcessor) SetLanguage(lang string) {
+	y.language = strings.ToLower(lang)
+}
+
+// transcriptionLanguage returns the language declared for the job running
+// with ctx or configured, if any
+func (y *YouTubeProcessor) transcriptionLanguage(ctx context.Context) string {
+	if lang := declaredLanguage(ctx); lang != "" {
+		return lang
+	}
+	return y.language
+}
+
+// SetTimeouts bounds downloading and transcribing audio; zero leaves a step
+// unbounded
+func (y *YouTubeProcessor) SetTimeouts(download, transcription time.Duration) {
+	y.downloadTimeout = download
+	y.transcriptionTimeout = transcription
+}
+
+// SetTranscriber sends audio to t instead of the local Whisper installation
+func (y *YouTubeProcessor) SetTranscriber(t Transcriber) {
+	y.transcriber = t
+}
+
 func (y *YouTubeProcessor) Process(ctx context.Context, url string) (string, error) {
 	// Create temp directory for this job
-	workDir, err := os.MkdirTemp(y.tempDir, "briefly-yt-*")
+	workDir, err := os.MkdirTemp(y.tempDir, tempDirPattern)
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
 	defer os.RemoveAll(workDir)
 
+	lang := y.transcriptionLanguage(ctx)
+	if lang == "" {
+		lang = y.probeLanguage(ctx, url)
+	}
+
+	// Jobs that timed out before take the captions fast path and a
+	// smaller Whisper model
+	level := lightPipelineFrom(ctx)
+	whisperModel := lighterWhisperModel(y.whisperModel, level)
+	if level > 0 {
+		slog.Info("Using the lighter pipeline: captions first", "url", url, "whisper_model", whisperModel)
+	}
+
+	var chapters []chapter
+	if y.useChapters {
+		chapters = y.fetchChapters(ctx, url)
+	}
+
+	if y.useCaptions || level > 0 {
+		progress(ctx, "reading captions")
+		captionLang := lang
+		if captionLang == "" {
+			captionLang = "en"
+		}
+		cues, err := y.fetchCaptions(ctx, url, workDir, captionLang)
+		if err != nil {
+			slog.Warn("Failed to fetch captions", "url", url, "error", err)
+		} else if len(cues) > 0 {
+			segments, err := y.sponsorSegments(ctx, url)
+			if err != nil {
+				slog.Warn("Failed to fetch SponsorBlock segments", "url", url, "error", err)
+			}
+			cues = dropSegments(cues, segments)
+			slog.Info("Using captions", "url", url, "language", captionLang, "chapters", len(chapters))
+			reportLanguage(ctx, lang)
+			if y.useChapters {
+				return timedTranscript(chapters, cues), nil
+			}
+			return cueText(cues), nil
+		}
+	}
+
 	audioPath := filepath.Join(workDir, "audio.mp3")
 
 	// Download audio using yt-dlp
-	if err := y.downloadAudio(ctx, url, audioPath); err != nil {
+	progress(ctx, "downloading audio")
+	err = withinStage(ctx, "download", y.downloadTimeout, func(ctx context.Context) error {
+		return y.downloadAudio(ctx, url, audioPath)
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to download audio: %w", err)
 	}
 
-	// Transcribe using Whisper
-	transcript, err := y.transcribe(ctx, audioPath)
+	// Transcribe using Whisper in the language reported by the platform, or
+	// the one it detects
+	transcript, err := y.transcribeWithin(ctx, audioPath, lang, whisperModel)
 	if err != nil {
 		return "", fmt.Errorf("failed to transcribe: %w", err)
 	}
 
-	return transcript, nil
+	return withChapters(chapters, transcript), nil
+}
+
+// SweepTempDir removes work directories left behind by a crashed run: those
+// unchanged for longer than maxAge, the longest a job uses one, so the
+// directories of running jobs, of benchmarks or of another instance sharing
+// tempDir are kept. Directories that cannot be removed are reported
+// together once the others are swept.
+func SweepTempDir(tempDir string, maxAge time.Duration) (int, error) {
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+
+	matches, err := filepath.Glob(filepath.Join(tempDir, tempDirPattern))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var errs []error
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", path, err))
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
 }
 
 func (y *YouTubeProcessor) downloadAudio(ctx context.Context, url, outputPath string) error {
@@ -58,7 +226,12 @@ func (y *YouTubeProcessor) downloadAudio(ctx context.Context, url, outputPath st
 		url,
 	}
 
-	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	if y.sponsorBlock != "" {
+		// yt-dlp looks the segments up and cuts them out with ffmpeg
+		args = append([]string{"--sponsorblock-remove", y.sponsorBlock}, args...)
+	}
+
+	cmd := y.ytdlp(ctx, args...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -77,16 +250,51 @@ func (y *YouTubeProcessor) downloadAudio(ctx context.Context, url, outputPath st
 	return nil
 }
 
-func (y *YouTubeProcessor) transcribe(ctx context.Context, audioPath string) (string, error) {
+// probeLanguage asks yt-dlp for the video's declared language. It returns
+// an empty string when the platform does not report one, leaving Whisper to
+// detect it.
+func (y *YouTubeProcessor) probeLanguage(ctx context.Context, url string) string {
+	cmd := y.ytdlp(ctx, "--skip-download", "--no-playlist", "--no-warnings", "--print", "language", url)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	lang := strings.ToLower(strings.TrimSpace(string(out)))
+	lang, _, _ = strings.Cut(lang, "-")
+	if lang == "na" || lang == "none" {
+		return ""
+	}
+	return lang
+}
+
+// transcribeWithin transcribes audio bounded by the transcription timeout
+func (y *YouTubeProcessor) transcribeWithin(ctx context.Context, audioPath, lang, whisperModel string) (transcript string, err error) {
+	err = withinStage(ctx, "transcription", y.transcriptionTimeout, func(ctx context.Context) error {
+		transcript, err = y.transcribe(ctx, audioPath, lang, whisperModel)
+		return err
+	})
+	return transcript, err
+}
+
+func (y *YouTubeProcessor) transcribe(ctx context.Context, audioPath, lang, whisperModel string) (string, error) {
+	progress(ctx, "transcribing audio")
+	if y.transcriber != nil {
+		return y.transcriber.Transcribe(ctx, audioPath, lang)
+	}
+
 	workDir := filepath.Dir(audioPath)
-	outputBase := filepath.Join(workDir, "transcript")
 
+	// The JSON output also holds the language Whisper detected
 	args := []string{
 		audioPath,
-		"--model", y.whisperModel,
-		"--output_format", "txt",
+		"--model", whisperModel,
+		"--output_format", "json",
 		"--output_dir", workDir,
-		"--language", "en", // Default to English, could be made configurable
+	}
+	// Without a language, Whisper detects it from the first 30 seconds
+	if lang != "" {
+		args = append(args, "--language", lang)
 	}
 
 	// Use pre-downloaded models if available (container environment)
@@ -104,17 +312,49 @@ func (y *YouTubeProcessor) transcribe(ctx context.Context, audioPath string) (st
 		return "", fmt.Errorf("whisper failed: %w, stderr: %s", err, stderr.String())
 	}
 
-	// Read the transcript file
-	transcriptPath := outputBase + ".txt"
-
 	// Whisper names the output after the input file
 	audioBase := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
-	transcriptPath = filepath.Join(workDir, audioBase+".txt")
+	transcriptPath := filepath.Join(workDir, audioBase+".json")
 
-	transcript, err := os.ReadFile(transcriptPath)
+	data, err := os.ReadFile(transcriptPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read transcript: %w", err)
 	}
+	var result struct {
+		Language string `json:"language"`
+		Segments []struct {
+			Text string `json:"text"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if lang == "" {
+		slog.Info("Whisper detected the language", "language", result.Language)
+	}
+	reportLanguage(ctx, language.Code(result.Language))
 
-	return strings.TrimSpace(string(transcript)), nil
+	// One line per segment, as in Whisper's text output
+	lines := make([]string, 0, len(result.Segments))
+	for _, s := range result.Segments {
+		lines = append(lines, strings.TrimSpace(s.Text))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// IsVideo asks yt-dlp whether it can download a video from rawURL. Pages
+// only matched by its generic extractor are left to article extraction, so
+// articles with an embedded clip are still read as text.
+func (y *YouTubeProcessor) IsVideo(ctx context.Context, rawURL string) bool {
+	ctx, cancel := context.WithTimeout(ctx, videoProbeTimeout)
+	defer cancel()
+
+	cmd := y.ytdlp(ctx, "--simulate", "--no-playlist", "--no-warnings",
+		"--print", "%(extractor_key)s %(duration)s", rawURL)
+	out, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	extractor, duration, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	return extractor != "" && extractor != "Generic" && duration != "NA"
 }
@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// ReleaseBatch moves the held jobs of batch to pending and returns how many
+// were released
+func (q *Queue) ReleaseBatch(batch string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	released := 0
+	for _, job := range q.jobs {
+		if job.Batch == batch && job.Status == models.JobStatusHeld {
+			job.Status = models.JobStatusPending
+			job.UpdatedAt = time.Now()
+			released++
+		}
+	}
+	if released == 0 {
+		return 0, fmt.Errorf("%w: no held jobs in batch %s", ErrJobNotFound, batch)
+	}
+
+	q.Notify()
+	return released, q.persist()
+}
+
+// DropBatch removes the held jobs of batch and returns them
+func (q *Queue) DropBatch(batch string) ([]models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var dropped []models.Job
+	kept := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.Batch == batch && job.Status == models.JobStatusHeld {
+			dropped = append(dropped, *job)
+			continue
+		}
+		kept = append(kept, job)
+	}
+	q.jobs = kept
+	if len(dropped) == 0 {
+		return nil, fmt.Errorf("%w: no held jobs in batch %s", ErrJobNotFound, batch)
+	}
+	return dropped, q.persist()
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// deadLetterFile holds permanently failed jobs, next to the queue file
+const deadLetterFile = ".deadletter.json"
+
+// Bury moves a failed job from the queue to the dead-letter list, where it
+// stays until it is requeued with Retry or purged
+func (q *Queue) Bury(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.jobs {
+		if job.ID != jobID {
+			continue
+		}
+		if job.Status != models.JobStatusFailed {
+			return fmt.Errorf("job %s is %s, only failed jobs can be moved to the dead-letter list", jobID, job.Status)
+		}
+		q.dead = append(q.dead, job)
+		q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+		if err := q.persistDead(); err != nil {
+			return err
+		}
+		return q.persist()
+	}
+	return ErrJobNotFound
+}
+
+// DeadLetters returns a snapshot of the dead-letter list, oldest first
+func (q *Queue) DeadLetters() []models.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(q.dead))
+	for _, job := range q.dead {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// PurgeDeadLetters empties the dead-letter list and returns how many jobs
+// were removed
+func (q *Queue) PurgeDeadLetters() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := len(q.dead)
+	if removed == 0 {
+		return 0, nil
+	}
+	q.dead = nil
+	return removed, q.persistDead()
+}
+
+// requeueDead moves a buried job back to the queue as pending. Called with
+// q.mu held.
+func (q *Queue) requeueDead(jobID string) (bool, error) {
+	for i, job := range q.dead {
+		if job.ID != jobID {
+			continue
+		}
+		q.dead = append(q.dead[:i], q.dead[i+1:]...)
+		job.Status = models.JobStatusPending
+		job.Retries = 0
+		job.Error = ""
+		job.ErrorCode = ""
+		job.StartedAt = time.Time{}
+		job.UpdatedAt = time.Now()
+		q.jobs = append(q.jobs, job)
+
+		if err := q.persistDead(); err != nil {
+			return true, err
+		}
+		return true, q.persist()
+	}
+	return false, nil
+}
+
+func (q *Queue) deadPath() string {
+	if q.persistPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(q.persistPath), deadLetterFile)
+}
+
+func (q *Queue) persistDead() error {
+	path := q.deadPath()
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(q.dead, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func (q *Queue) loadDead() error {
+	path := q.deadPath()
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &q.dead)
+}
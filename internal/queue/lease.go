@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// ErrLeaseLost is returned by Heartbeat when another worker has taken over
+// the job after its lease expired
+var ErrLeaseLost = errors.New("job lease lost")
+
+// SetLease makes Dequeue lease jobs to owner for ttl. Jobs whose lease
+// expires without a Heartbeat, because their worker crashed or hung, are
+// handed out again. A zero ttl disables leasing.
+func (q *Queue) SetLease(owner string, ttl time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.leaseOwner = owner
+	q.leaseTTL = ttl
+}
+
+// LeaseTTL returns the configured lease duration
+func (q *Queue) LeaseTTL() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.leaseTTL
+}
+
+// Heartbeat extends the lease on a job returned by Dequeue
+func (q *Queue) Heartbeat(claimed *models.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.leaseTTL <= 0 {
+		return nil
+	}
+	for _, job := range q.jobs {
+		if job.ID != claimed.ID {
+			continue
+		}
+		if job.Status != models.JobStatusProcessing || job.LeaseOwner != claimed.LeaseOwner {
+			return ErrLeaseLost
+		}
+		job.LeaseExpires = time.Now().Add(q.leaseTTL)
+		return q.persist()
+	}
+	return ErrJobNotFound
+}
+
+// Recover returns to pending the jobs left processing by a previous run of
+// this instance, so they resume right away. Jobs leased to owner, named as
+// in SetLease, or never leased are recovered; those leased to other
+// replicas sharing the queue stay theirs until their lease expires.
+func (q *Queue) Recover(owner string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	recovered := 0
+	for _, job := range q.jobs {
+		if job.Status != models.JobStatusProcessing {
+			continue
+		}
+		if job.LeaseOwner != "" && !job.LeaseExpires.IsZero() && !strings.HasPrefix(job.LeaseOwner, owner+"#") {
+			continue
+		}
+		slog.Warn("Recovering job interrupted by a restart",
+			"job_id", job.ID, "file", job.Filename, "stage", job.Stage)
+		job.Status = models.JobStatusPending
+		job.Stage = ""
+		job.LeaseOwner = ""
+		job.LeaseExpires = time.Time{}
+		recovered++
+	}
+	if recovered == 0 {
+		return 0, nil
+	}
+
+	select {
+	case q.notification <- struct{}{}:
+	default:
+	}
+	return recovered, q.persist()
+}
+
+// claimable reports whether job can be handed to a worker: it is pending and
+// due, or its worker's lease has expired. Called with q.mu held.
+func (q *Queue) claimable(job *models.Job, now time.Time) bool {
+	if job.Status == models.JobStatusPending {
+		// Deferred jobs wait until they are due
+		return !now.Before(job.NotBefore)
+	}
+	if job.Status != models.JobStatusProcessing || q.leaseTTL <= 0 || job.LeaseExpires.IsZero() {
+		return false
+	}
+	return !now.Before(job.LeaseExpires)
+}
+
+// lease marks job as processing by this queue's owner. Each claim gets its
+// own lease name, so a worker whose job was reclaimed, even by the same
+// replica, cannot overwrite it. Called with q.mu held.
+func (q *Queue) lease(job *models.Job, now time.Time) {
+	if job.Status == models.JobStatusProcessing {
+		slog.Warn("Reclaiming job with expired lease",
+			"job_id", job.ID, "file", job.Filename, "lease_owner", job.LeaseOwner, "lease_expires", job.LeaseExpires)
+	}
+	job.Status = models.JobStatusProcessing
+	if q.leaseTTL > 0 {
+		q.leases++
+		job.LeaseOwner = fmt.Sprintf("%s#%d", q.leaseOwner, q.leases)
+		job.LeaseExpires = now.Add(q.leaseTTL)
+	}
+}
+
+// stale reports whether job is an outdated copy of stored whose lease was
+// taken over. Called with q.mu held.
+func stale(stored, job *models.Job) bool {
+	return stored.Status == models.JobStatusProcessing && stored.LeaseOwner != "" &&
+		stored.LeaseOwner != job.LeaseOwner
+}
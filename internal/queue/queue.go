@@ -2,17 +2,34 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/clobrano/briefly/internal/models"
 )
 
+// ErrJobNotFound is returned when no job has the requested ID
+var ErrJobNotFound = errors.New("job not found")
+
 type Queue struct {
 	mu           sync.Mutex
 	jobs         []*models.Job
 	persistPath  string
 	notification chan struct{}
+
+	// dead holds permanently failed jobs, see Bury
+	dead []*models.Job
+
+	// leaseOwner and leaseTTL enable work leasing, see SetLease
+	leaseOwner string
+	leaseTTL   time.Duration
+	leases     int
+
+	// triage groups new inputs into batches, see SetTriage
+	triage *triage
 }
 
 func New(persistPath string) (*Queue, error) {
@@ -25,15 +42,29 @@ func New(persistPath string) (*Queue, error) {
 	if err := q.load(); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	if err := q.loadDead(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, job := range q.jobs {
+		q.wakeAt(job.NotBefore)
+	}
 
 	return q, nil
 }
 
+// NewMemory returns a queue that is kept in memory only
+func NewMemory() *Queue {
+	q, _ := New("")
+	return q
+}
+
 func (q *Queue) Enqueue(job *models.Job) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	q.jobs = append(q.jobs, job)
+	stored := *job
+	q.jobs = append(q.jobs, &stored)
+	q.wakeAt(stored.NotBefore)
 
 	select {
 	case q.notification <- struct{}{}:
@@ -43,18 +74,29 @@ func (q *Queue) Enqueue(job *models.Job) error {
 	return q.persist()
 }
 
-func (q *Queue) Dequeue() *models.Job {
+// Dequeue claims the next job: the highest priority one, oldest first
+// among equal priorities. Jobs ready reports false for stay pending, such
+// as those of a provider out of budget; a nil ready accepts every job.
+func (q *Queue) Dequeue(ready func(*models.Job) bool) *models.Job {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	now := time.Now()
+	var next *models.Job
 	for _, job := range q.jobs {
-		if job.Status == models.JobStatusPending {
-			job.Status = models.JobStatusProcessing
-			q.persist()
-			return job
+		if q.claimable(job, now) && (next == nil || job.Priority > next.Priority) && (ready == nil || ready(job)) {
+			next = job
 		}
 	}
-	return nil
+	if next == nil {
+		return nil
+	}
+
+	q.lease(next, now)
+	q.persist()
+	// Callers own the returned copy and publish changes with Update
+	claimed := *next
+	return &claimed
 }
 
 func (q *Queue) Update(job *models.Job) error {
@@ -63,7 +105,15 @@ func (q *Queue) Update(job *models.Job) error {
 
 	for i, j := range q.jobs {
 		if j.ID == job.ID {
-			q.jobs[i] = job
+			if stale(j, job) {
+				return ErrLeaseLost
+			}
+			stored := *job
+			if stored.Status != models.JobStatusProcessing {
+				stored.LeaseOwner = ""
+				stored.LeaseExpires = time.Time{}
+			}
+			q.jobs[i] = &stored
 			return q.persist()
 		}
 	}
@@ -83,6 +133,92 @@ func (q *Queue) Remove(jobID string) error {
 	return nil
 }
 
+// Retry resets a failed job to pending with a fresh retry budget, moving it
+// back from the dead-letter list if needed
+func (q *Queue) Retry(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if found, err := q.requeueDead(jobID); found {
+		q.Notify()
+		return err
+	}
+
+	for _, job := range q.jobs {
+		if job.ID != jobID {
+			continue
+		}
+		if job.Status != models.JobStatusFailed {
+			return fmt.Errorf("job %s is %s, only failed jobs can be retried", jobID, job.Status)
+		}
+		job.Status = models.JobStatusPending
+		job.Retries = 0
+		job.Error = ""
+		job.ErrorCode = ""
+		job.StartedAt = time.Time{}
+		job.UpdatedAt = time.Now()
+
+		select {
+		case q.notification <- struct{}{}:
+		default:
+		}
+		return q.persist()
+	}
+	return ErrJobNotFound
+}
+
+// Purge removes completed and failed jobs and returns how many were removed
+func (q *Queue) Purge() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.Status != models.JobStatusCompleted && job.Status != models.JobStatusFailed {
+			kept = append(kept, job)
+		}
+	}
+	removed := len(q.jobs) - len(kept)
+	q.jobs = kept
+
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, q.persist()
+}
+
+// List returns a snapshot of all jobs in queue order
+func (q *Queue) List() []models.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// Get returns a snapshot of the job with the given ID
+func (q *Queue) Get(jobID string) (models.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.jobs {
+		if job.ID == jobID {
+			return *job, true
+		}
+	}
+	return models.Job{}, false
+}
+
+// wakeAt notifies waiting workers at t, when a deferred job becomes due
+func (q *Queue) wakeAt(t time.Time) {
+	if d := time.Until(t); d > 0 {
+		time.AfterFunc(d, q.Notify)
+	}
+}
+
 func (q *Queue) Wait() <-chan struct{} {
 	return q.notification
 }
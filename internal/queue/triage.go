@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// TriageOptions configures batch triage, see SetTriage
+type TriageOptions struct {
+	// Threshold is how many inputs arriving together make a batch
+	Threshold int
+	// Window is the quiet time after which a batch is complete
+	Window time.Duration
+	// Confirm holds batches until ReleaseBatch, through a control file or an
+	// API call, releases them
+	Confirm bool
+	// Estimate returns the expected processing time and cost of a job
+	Estimate func(job models.Job) (time.Duration, float64)
+	// Announce reports a batch of at least Threshold inputs, with its
+	// estimated time and cost, and whether it is held
+	Announce func(batch string, jobs []models.Job, eta time.Duration, cost float64, held bool)
+}
+
+// triage groups inputs arriving within a window of each other into batches
+type triage struct {
+	TriageOptions
+
+	mu    sync.Mutex
+	batch string
+	jobs  []models.Job
+	timer *time.Timer
+}
+
+// SetTriage enables batch triage of the jobs queued by EnqueueInput: when
+// at least opts.Threshold inputs arrive together, they are announced with
+// the estimated time and cost, and with opts.Confirm they wait for
+// confirmation before processing. Batches still held from a previous run,
+// whose window was lost with it, are closed again now.
+func (q *Queue) SetTriage(opts TriageOptions) {
+	if opts.Threshold > 0 {
+		if opts.Window <= 0 {
+			opts.Window = 10 * time.Second
+		}
+		q.triage = &triage{TriageOptions: opts}
+	}
+	q.resumeBatches()
+}
+
+// HoldsBatches reports whether large batches wait for confirmation
+func (q *Queue) HoldsBatches() bool {
+	return q.triage != nil && q.triage.Confirm
+}
+
+// EnqueueInput queues the job of a new input, such as a file dropped in the
+// watch directory or an item found by a poller, adding it to the current
+// triage batch
+func (q *Queue) EnqueueInput(job *models.Job) error {
+	t := q.triage
+	if t == nil {
+		return q.Enqueue(job)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.batch == "" {
+		t.batch = job.ID
+	}
+	job.Batch = t.batch
+	if t.Confirm {
+		job.Status = models.JobStatusHeld
+	}
+	if err := q.Enqueue(job); err != nil {
+		return err
+	}
+	t.jobs = append(t.jobs, *job)
+
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.Window, q.closeBatch)
+	} else {
+		t.timer.Reset(t.Window)
+	}
+	return nil
+}
+
+// closeBatch runs once no input arrived for the batch window
+func (q *Queue) closeBatch() {
+	t := q.triage
+	t.mu.Lock()
+	batch, jobs := t.batch, t.jobs
+	t.batch, t.jobs, t.timer = "", nil, nil
+	t.mu.Unlock()
+
+	q.settleBatch(batch, jobs)
+}
+
+// settleBatch announces large batches and releases small held ones right
+// away
+func (q *Queue) settleBatch(batch string, jobs []models.Job) {
+	t := q.triage
+	if len(jobs) < t.Threshold {
+		if t.Confirm {
+			if _, err := q.ReleaseBatch(batch); err != nil {
+				slog.Warn("Failed to release batch", "batch", batch, "error", err)
+			}
+		}
+		return
+	}
+
+	var eta time.Duration
+	var cost float64
+	if t.Estimate != nil {
+		for _, job := range jobs {
+			d, c := t.Estimate(job)
+			eta += d
+			cost += c
+		}
+	}
+	slog.Info("Batch of inputs queued", "batch", batch, "jobs", len(jobs),
+		"estimated_time", eta.Round(time.Second), "estimated_cost_usd", cost, "held", t.Confirm)
+	if t.Announce != nil {
+		t.Announce(batch, jobs, eta, cost, t.Confirm)
+	}
+}
+
+// resumeBatches settles the batches held when the previous run stopped:
+// large ones are announced again, since their notification may be lost,
+// and the others released, as are all of them once confirmation is off
+func (q *Queue) resumeBatches() {
+	var order []string
+	held := make(map[string][]models.Job)
+	for _, job := range q.List() {
+		if job.Status != models.JobStatusHeld {
+			continue
+		}
+		if _, ok := held[job.Batch]; !ok {
+			order = append(order, job.Batch)
+		}
+		held[job.Batch] = append(held[job.Batch], job)
+	}
+
+	for _, batch := range order {
+		if !q.HoldsBatches() {
+			if _, err := q.ReleaseBatch(batch); err != nil {
+				slog.Warn("Failed to release batch", "batch", batch, "error", err)
+			}
+			continue
+		}
+		slog.Info("Resuming batch held before a restart", "batch", batch, "jobs", len(held[batch]))
+		q.settleBatch(batch, held[batch])
+	}
+}
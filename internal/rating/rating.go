@@ -0,0 +1,415 @@
+package rating
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/sources"
+)
+
+// Rating is the user's verdict on one summary, recorded with the settings
+// that produced it
+type Rating struct {
+	Name        string    `json:"name"`
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	Prompt      string    `json:"prompt,omitempty"`
+	RatedAt     time.Time `json:"rated_at"`
+	Regenerated bool      `json:"regenerated,omitempty"`
+}
+
+// Store appends ratings to a JSON-lines file in the output directory
+type Store struct {
+	mu        sync.Mutex
+	outputDir string
+	path      string
+
+	// Optional automatic regeneration of low-rated summaries
+	queue         *queue.Queue
+	regenBelow    int
+	regenProvider string
+	regenModel    string
+}
+
+func New(outputDir string) *Store {
+	return &Store{
+		outputDir: outputDir,
+		path:      filepath.Join(outputDir, ".ratings.jsonl"),
+	}
+}
+
+// SetRegeneration re-enqueues summaries rated at or below threshold using
+// the given provider and model
+func (s *Store) SetRegeneration(q *queue.Queue, threshold int, provider, model string) {
+	s.queue = q
+	s.regenBelow = threshold
+	s.regenProvider = provider
+	s.regenModel = model
+}
+
+// HandleFile records the rating in a "<name>.rate" file. The first line holds
+// the 1-5 score, the remaining lines an optional comment.
+func (s *Store) HandleFile(path string) (*Rating, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	score, comment, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	score, inlineComment, _ := strings.Cut(strings.TrimSpace(score), " ")
+	n, err := strconv.Atoi(score)
+	if err != nil || n < 1 || n > 5 {
+		return nil, fmt.Errorf("rating must start with a number from 1 to 5, got %q", score)
+	}
+
+	base := filepath.Base(path)
+	r := &Rating{
+		Name:    strings.TrimSuffix(base, filepath.Ext(base)),
+		Rating:  n,
+		Comment: strings.TrimSpace(inlineComment + "\n" + comment),
+		RatedAt: time.Now(),
+	}
+
+	summaryPath, err := s.findSummary(r.Name)
+	if err != nil {
+		return nil, fmt.Errorf("no summary found for %s: %w", r.Name, err)
+	}
+	header, err := ParseSummaryHeader(summaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("no summary found for %s: %w", r.Name, err)
+	}
+
+	// The summary is requested again with the settings it was requested
+	// with, recorded by the output watcher, or else from its header
+	known, err := sources.Load(s.outputDir)
+	if err != nil {
+		slog.Warn("Ignoring unreadable summary sources", "dir", s.outputDir, "error", err)
+	}
+	src, ok := known[sources.Key(s.outputDir, summaryPath)]
+	if !ok {
+		src = sources.Source{URL: header["URL"], Feed: header["Feed"]}
+	}
+	r.URL = src.URL
+	r.ContentType = header["Type"]
+	r.Model = header["Model"]
+	r.Prompt = header["Prompt"]
+
+	if s.queue != nil && r.Rating <= s.regenBelow {
+		if err := s.regenerate(r, summaryPath, src); err != nil {
+			slog.Warn("Failed to regenerate summary", "summary", r.Name, "error", err)
+		} else {
+			r.Regenerated = true
+		}
+	}
+
+	if err := s.append(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// findSummary returns the summary named name in the output directory or its
+// subfolders, in any output format; the most recently written one when
+// several share the name
+func (s *Store) findSummary(name string) (string, error) {
+	var found string
+	var foundTime time.Time
+	err := filepath.WalkDir(s.outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := d.Name()
+		if d.IsDir() {
+			if path != s.outputDir && strings.HasPrefix(base, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sources.IsSummaryFile(base) || strings.TrimSuffix(base, filepath.Ext(base)) != name {
+			return nil
+		}
+		if filepath.Dir(path) == s.outputDir && (base == "index.md" || base == "index.json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if found == "" || info.ModTime().After(foundTime) {
+			found, foundTime = path, info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", os.ErrNotExist
+	}
+	return found, nil
+}
+
+// regenerate moves the low-rated summary aside and queues its source again
+// with the settings of src
+func (s *Store) regenerate(r *Rating, summaryPath string, src sources.Source) error {
+	if src.URL == "" {
+		return errors.New("summary has no URL to regenerate from")
+	}
+
+	previous, err := ratedPath(summaryPath, r.Rating)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(summaryPath, previous); err != nil {
+		return err
+	}
+
+	job := src.Job()
+	job.OutputName = r.Name
+	job.Provider = s.regenProvider
+	job.Model = s.regenModel
+	job.NoCache = true
+	if err := s.queue.Enqueue(job); err != nil {
+		return err
+	}
+
+	slog.Info("Regenerating low-rated summary", "summary", r.Name, "provider", s.regenProvider, "model", s.regenModel, "rating", r.Rating)
+	return nil
+}
+
+// ratedPath returns where the summary at path is kept once rated down,
+// next to it, numbered when an earlier rating kept one already
+func ratedPath(path string, rating int) (string, error) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	candidate := fmt.Sprintf("%s.rated-%d%s", stem, rating, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s.rated-%d-%d%s", stem, rating, i, ext)
+	}
+}
+
+func (s *Store) append(r *Rating) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// All returns every recorded rating
+func (s *Store) All() ([]Rating, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var ratings []Rating
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Rating
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, scanner.Err()
+}
+
+// ReportRow aggregates ratings sharing the same model and prompt
+type ReportRow struct {
+	Model   string
+	Prompt  string
+	Count   int
+	Average float64
+}
+
+// Report groups ratings by model and prompt, best average first
+func Report(ratings []Rating) []ReportRow {
+	type key struct{ model, prompt string }
+	sums := make(map[key][2]int)
+	for _, r := range ratings {
+		k := key{r.Model, r.Prompt}
+		v := sums[k]
+		v[0] += r.Rating
+		v[1]++
+		sums[k] = v
+	}
+
+	rows := make([]ReportRow, 0, len(sums))
+	for k, v := range sums {
+		rows = append(rows, ReportRow{
+			Model:   k.model,
+			Prompt:  k.prompt,
+			Count:   v[1],
+			Average: float64(v[0]) / float64(v[1]),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Average > rows[j].Average
+	})
+	return rows
+}
+
+// ParseSummaryHeader reads the "**Key:** value" lines at the top of a
+// summary, after its YAML front matter if any. The properties of the other
+// output formats are returned under the same keys: URL, Type, Model, Prompt,
+// and Feed, when the format records them.
+func ParseSummaryHeader(path string) (map[string]string, error) {
+	switch filepath.Ext(path) {
+	case ".json":
+		return parseJSONHeader(path)
+	case ".txt":
+		return parseTextHeader(path)
+	case ".html":
+		return parseHTMLHeader(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	first, inFrontMatter := true, false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first && line == "---" {
+			first, inFrontMatter = false, true
+			continue
+		}
+		first = false
+		if inFrontMatter {
+			inFrontMatter = line != "---"
+			continue
+		}
+		if line == "---" {
+			break
+		}
+		if !strings.HasPrefix(line, "**") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "**"), ":**")
+		if found {
+			header[key] = strings.TrimSpace(value)
+		}
+	}
+	return header, scanner.Err()
+}
+
+// parseJSONHeader reads the properties of a JSON summary
+func parseJSONHeader(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var summary struct {
+		URL      string `json:"url"`
+		Type     string `json:"type"`
+		Provider string `json:"provider"`
+		Model    string `json:"model"`
+		Prompt   string `json:"prompt"`
+		Feed     string `json:"feed"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	header := map[string]string{
+		"URL":    summary.URL,
+		"Type":   summary.Type,
+		"Prompt": summary.Prompt,
+		"Feed":   summary.Feed,
+	}
+	if summary.Model != "" {
+		header["Model"] = summary.Provider + "/" + summary.Model
+	}
+	return header, nil
+}
+
+// parseTextHeader reads the "Key: value" lines between the title and the
+// summary of a plain text summary
+func parseTextHeader(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	blanks := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			// The header follows the blank line after the title
+			if blanks++; blanks == 2 {
+				break
+			}
+			continue
+		}
+		if key, value, found := strings.Cut(line, ": "); found && blanks == 1 {
+			header[key] = strings.TrimSpace(value)
+		}
+	}
+	if source := header["Source"]; strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		header["URL"] = source
+	}
+	return header, scanner.Err()
+}
+
+// htmlMeta matches the "source · type · model · generated" line of an HTML
+// summary, whose source links to the URL
+var htmlMeta = regexp.MustCompile(`<p class="meta">(?:<a href="([^"]*)">)?.*? · ([^·<]*) · ([^·<]*) · `)
+
+// parseHTMLHeader reads the meta line under the title of an HTML summary
+func parseHTMLHeader(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string]string)
+	if m := htmlMeta.FindStringSubmatch(string(data)); m != nil {
+		header["URL"] = html.UnescapeString(m[1])
+		header["Type"] = html.UnescapeString(m[2])
+		header["Model"] = html.UnescapeString(m[3])
+	}
+	return header, nil
+}
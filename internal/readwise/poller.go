@@ -0,0 +1,207 @@
+package readwise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// Poller queues newly tagged documents on an interval. Its state is
+// persisted so documents are queued once across restarts.
+type Poller struct {
+	client    *Client
+	queue     *queue.Queue
+	history   *history.Store
+	tag       string
+	highlight bool
+	interval  time.Duration
+	done      chan struct{}
+
+	mu          sync.Mutex
+	state       state
+	persistPath string
+}
+
+type state struct {
+	// UpdatedAfter is the latest update time of the documents seen
+	UpdatedAfter time.Time `json:"updated_after,omitzero"`
+	// Queued holds the documents already queued, which show up again
+	// whenever they are updated in Reader
+	Queued map[string]bool `json:"queued,omitempty"`
+	// Pending maps the jobs not yet summarized to their document, to
+	// highlight it
+	Pending map[string]Document `json:"pending,omitempty"`
+}
+
+// NewPoller polls the documents tagged with tag; with highlight set,
+// summaries are saved back to their document as a highlight, once they show
+// up in the job history h
+func NewPoller(client *Client, q *queue.Queue, h *history.Store, tag string, highlight bool, interval time.Duration, persistPath string) *Poller {
+	p := &Poller{
+		client:      client,
+		queue:       q,
+		history:     h,
+		tag:         tag,
+		highlight:   highlight,
+		interval:    interval,
+		done:        make(chan struct{}),
+		persistPath: persistPath,
+	}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			slog.Warn("Failed to load Readwise state", "path", persistPath, "error", err)
+		}
+	}
+	if p.state.Queued == nil {
+		p.state.Queued = make(map[string]bool)
+	}
+	if p.state.Pending == nil {
+		p.state.Pending = make(map[string]Document)
+	}
+	return p
+}
+
+func (p *Poller) Start() {
+	go p.run()
+}
+
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if n, err := p.Poll(ctx); err != nil {
+			slog.Warn("Failed to poll Readwise Reader", "tag", p.tag, "error", err)
+		} else if n > 0 {
+			slog.Info("Queued Readwise Reader documents", "tag", p.tag, "jobs", n)
+		}
+		if err := p.highlightDone(ctx); err != nil {
+			slog.Warn("Failed to save summaries to Readwise", "error", err)
+		}
+		cancel()
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll queues the documents tagged since the last poll and returns their
+// number
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	docs, err := p.client.Tagged(ctx, p.tag, p.state.UpdatedAfter)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, doc := range docs {
+		if doc.UpdatedAt.After(p.state.UpdatedAfter) {
+			p.state.UpdatedAfter = doc.UpdatedAt
+		}
+		if p.state.Queued[doc.ID] {
+			continue
+		}
+
+		// The source URL is the original article, rather than its Reader page
+		source := doc.SourceURL
+		if source == "" {
+			source = doc.URL
+		}
+		job := models.NewJob("", source, "")
+		job.Title = doc.Title
+		job.Feed = "Readwise Reader"
+		if err := p.queue.EnqueueInput(job); err != nil {
+			return queued, err
+		}
+		p.state.Queued[doc.ID] = true
+		if p.highlight {
+			p.state.Pending[job.ID] = doc
+		}
+		queued++
+	}
+	return queued, p.persist()
+}
+
+// highlightDone saves the summaries of completed jobs to their document.
+// Completed jobs leave the queue, so their summary is found in history;
+// jobs gone from the queue without completing, because they failed or were
+// purged, are forgotten.
+func (p *Poller) highlightDone(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	var highlightErr error
+	for jobID, doc := range p.state.Pending {
+		if _, queued := p.queue.Get(jobID); queued {
+			continue
+		}
+
+		if path := p.summaryPath(jobID); path != "" {
+			summary, err := os.ReadFile(path)
+			if err != nil {
+				slog.Warn("Failed to read summary for Readwise", "job_id", jobID, "error", err)
+			} else if err := p.client.Highlight(ctx, doc, strings.TrimSpace(string(summary)), "Summary by Briefly"); err != nil {
+				// Documents highlighted so far are still saved, so they are
+				// not highlighted twice
+				highlightErr = err
+				break
+			}
+		}
+		delete(p.state.Pending, jobID)
+		changed = true
+	}
+	if changed {
+		if err := p.persist(); err != nil {
+			return err
+		}
+	}
+	return highlightErr
+}
+
+// summaryPath returns the summary of the job with jobID, or "" when the job
+// did not complete
+func (p *Poller) summaryPath(jobID string) string {
+	if p.history == nil {
+		return ""
+	}
+	entries, err := p.history.Query(history.Filter{ID: jobID, Status: models.JobStatusCompleted})
+	if err != nil {
+		slog.Warn("Failed to query history", "error", err)
+		return ""
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1].OutputPath
+}
+
+func (p *Poller) persist() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.persistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to persist Readwise state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// rule replaces matches of pattern with "[KIND_n]" placeholders
+type rule struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// rules are applied in order, so secrets are replaced before the looser
+// phone number pattern could match digits inside them
+var rules = []rule{
+	{"SECRET", regexp.MustCompile(`\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|glpat-[A-Za-z0-9_-]{20,})\b`)},
+	{"SECRET", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`)},
+	{"EMAIL", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{"PHONE", regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}(?:[\s.-]\d{2,8}){1,4}\b`)},
+}
+
+// Map records the original text behind each placeholder
+type Map map[string]string
+
+// Text replaces emails, phone numbers, and API keys in text with
+// placeholders such as [EMAIL_1], returning the redacted text and the map
+// to restore it. The same value always gets the same placeholder.
+func Text(text string) (string, Map) {
+	m := make(Map)
+	seen := make(map[string]string)
+	counts := make(map[string]int)
+
+	for _, r := range rules {
+		text = r.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if r.kind == "PHONE" && !isPhone(match) {
+				return match
+			}
+			if placeholder, ok := seen[match]; ok {
+				return placeholder
+			}
+			counts[r.kind]++
+			placeholder := fmt.Sprintf("[%s_%d]", r.kind, counts[r.kind])
+			seen[match] = placeholder
+			m[placeholder] = match
+			return placeholder
+		})
+	}
+	return text, m
+}
+
+// Restore puts the original values back in place of the placeholders,
+// except for secrets, which stay redacted
+func (m Map) Restore(text string) string {
+	for placeholder, original := range m {
+		if strings.HasPrefix(placeholder, "[SECRET_") {
+			continue
+		}
+		text = strings.ReplaceAll(text, placeholder, original)
+	}
+	return text
+}
+
+// Save writes the map to path, readable only by the owner. Secrets are left
+// out, as they are never restored and must not be stored in clear.
+func (m Map) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	kept := make(Map, len(m))
+	for placeholder, original := range m {
+		if !strings.HasPrefix(placeholder, "[SECRET_") {
+			kept[placeholder] = original
+		}
+	}
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// datePattern matches dates, which look like short phone numbers
+var datePattern = regexp.MustCompile(`^\d{4}[-./]\d{1,2}[-./]\d{1,2}$|^\d{1,2}[-./]\d{1,2}[-./]\d{2,4}$`)
+
+// isPhone filters out dates, versions, and short number groups
+func isPhone(s string) bool {
+	if datePattern.MatchString(s) {
+		return false
+	}
+	digits := countDigits(s)
+	return digits >= 9 || (strings.HasPrefix(s, "+") && digits >= 7)
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,258 @@
+// Package retention keeps the output directory from growing without bound,
+// compressing or deleting old summaries and pruning the job history.
+package retention
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+)
+
+// Actions applied to summaries older than the retention period
+const (
+	ActionCompress = "compress"
+	ActionDelete   = "delete"
+)
+
+// Policy says what the janitor cleans up; zero fields keep everything
+type Policy struct {
+	// MaxAge is the age after which summaries are compressed or deleted,
+	// according to Action
+	MaxAge time.Duration
+	Action string
+
+	// HistoryMaxAge is the age after which job history entries are pruned
+	HistoryMaxAge time.Duration
+
+	// MaxSize caps the bytes of summary files; the oldest are deleted first
+	MaxSize int64
+}
+
+// Enabled reports whether the policy cleans up anything
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.HistoryMaxAge > 0 || p.MaxSize > 0
+}
+
+// Janitor applies a retention policy to the output directory on an interval
+type Janitor struct {
+	outputDir string
+	policy    Policy
+	history   *history.Store
+	interval  time.Duration
+	done      chan struct{}
+
+	// removing is called before a summary is removed, so the output watcher
+	// does not take it for a request to regenerate it
+	removing func(path string)
+}
+
+func New(outputDir string, policy Policy, h *history.Store, interval time.Duration) *Janitor {
+	return &Janitor{
+		outputDir: outputDir,
+		policy:    policy,
+		history:   h,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+}
+
+// OnRemove registers f to be called with the path of each summary about to
+// be compressed or deleted
+func (j *Janitor) OnRemove(f func(path string)) {
+	j.removing = f
+}
+
+func (j *Janitor) Start() {
+	go j.run()
+}
+
+func (j *Janitor) Stop() {
+	close(j.done)
+}
+
+func (j *Janitor) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.Run(time.Now())
+
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run applies the policy once, as of now
+func (j *Janitor) Run(now time.Time) {
+	if j.policy.HistoryMaxAge > 0 && j.history != nil {
+		if n, err := j.history.Prune(now.Add(-j.policy.HistoryMaxAge)); err != nil {
+			slog.Warn("Failed to prune job history", "error", err)
+		} else if n > 0 {
+			slog.Info("Pruned job history", "entries", n)
+		}
+	}
+	if j.policy.MaxAge <= 0 && j.policy.MaxSize <= 0 {
+		return
+	}
+
+	files, err := j.summaryFiles()
+	if err != nil {
+		slog.Warn("Failed to list summaries for retention", "error", err)
+		return
+	}
+
+	if j.policy.MaxAge > 0 {
+		cutoff := now.Add(-j.policy.MaxAge)
+		kept := files[:0]
+		for _, f := range files {
+			if !f.modTime.Before(cutoff) || (j.policy.Action == ActionCompress && f.compressed()) {
+				kept = append(kept, f)
+				continue
+			}
+			if j.policy.Action == ActionCompress {
+				size, err := j.compress(f.path)
+				if err != nil {
+					slog.Warn("Failed to compress old summary", "path", f.path, "error", err)
+					kept = append(kept, f)
+					continue
+				}
+				slog.Info("Compressed old summary", "path", f.path)
+				kept = append(kept, file{path: f.path + ".gz", size: size, modTime: f.modTime})
+				continue
+			}
+			if err := j.remove(f.path); err != nil {
+				slog.Warn("Failed to delete old summary", "path", f.path, "error", err)
+				kept = append(kept, f)
+				continue
+			}
+			slog.Info("Deleted old summary", "path", f.path)
+		}
+		files = kept
+	}
+
+	if j.policy.MaxSize > 0 {
+		var total int64
+		for _, f := range files {
+			total += f.size
+		}
+		// files is sorted oldest first
+		for _, f := range files {
+			if total <= j.policy.MaxSize {
+				break
+			}
+			if err := j.remove(f.path); err != nil {
+				slog.Warn("Failed to delete summary over the size cap", "path", f.path, "error", err)
+				continue
+			}
+			total -= f.size
+			slog.Info("Deleted summary over the size cap", "path", f.path)
+		}
+	}
+}
+
+// file is a summary, or a file kept next to one, in the output directory
+type file struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (f file) compressed() bool {
+	return strings.HasSuffix(f.path, ".gz")
+}
+
+// summaryFiles lists the files of the output directory and its subfolders,
+// oldest first. Hidden files hold Briefly's own state and are left out, as
+// is the summary index.
+func (j *Janitor) summaryFiles() ([]file, error) {
+	var files []file
+	err := filepath.WalkDir(j.outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != j.outputDir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".tmp") || !d.Type().IsRegular() {
+			return nil
+		}
+		if filepath.Dir(path) == j.outputDir && (name == "index.md" || name == "index.json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	sort.SliceStable(files, func(a, b int) bool { return files[a].modTime.Before(files[b].modTime) })
+	return files, err
+}
+
+// compress replaces path with a gzipped copy keeping its modification
+// time, and returns the size of the copy
+func (j *Janitor) compress(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dest := path + ".gz"
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return 0, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	os.Chtimes(dest, info.ModTime(), info.ModTime())
+
+	if err := j.remove(path); err != nil {
+		os.Remove(dest)
+		return 0, err
+	}
+	compressed, err := os.Stat(dest)
+	if err != nil {
+		return 0, err
+	}
+	return compressed.Size(), nil
+}
+
+func (j *Janitor) remove(path string) error {
+	if j.removing != nil {
+		j.removing(path)
+	}
+	return os.Remove(path)
+}
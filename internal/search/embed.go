@@ -0,0 +1,129 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Embedder turns text into a vector whose distance to other vectors of the
+// same model reflects how related their meanings are
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+	// Model names the embedding model; vectors of different models cannot
+	// be compared
+	Model() string
+}
+
+// DefaultModel returns the embedding model used with provider when none is
+// configured
+func DefaultModel(provider string) string {
+	switch provider {
+	case "openai":
+		return "text-embedding-3-small"
+	case "gemini":
+		return "text-embedding-004"
+	case "ollama":
+		return "nomic-embed-text"
+	}
+	return ""
+}
+
+// OpenAIEmbedder calls the embeddings endpoint of the OpenAI API, or of a
+// compatible server such as Ollama for a local model
+type OpenAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+func NewOpenAIEmbedder(baseURL, apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		client:  &http.Client{Timeout: time.Minute},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+	}
+}
+
+func (o *OpenAIEmbedder) Model() string {
+	return o.model
+}
+
+func (o *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	body, err := json.Marshal(map[string]string{"model": o.model, "input": text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings API error: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings API error: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("embeddings API error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("embeddings API error: %w", err)
+	}
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embeddings API returned no embedding")
+	}
+	return result.Data[0].Embedding, nil
+}
+
+// GeminiEmbedder calls the Gemini embedding models
+type GeminiEmbedder struct {
+	client *genai.Client
+	model  string
+}
+
+func NewGeminiEmbedder(ctx context.Context, apiKey, model string) (*GeminiEmbedder, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: apiKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	return &GeminiEmbedder{client: client, model: model}, nil
+}
+
+func (g *GeminiEmbedder) Model() string {
+	return g.model
+}
+
+func (g *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := g.client.Models.EmbedContent(ctx, g.model, genai.Text(text), nil)
+	if err != nil {
+		return nil, fmt.Errorf("gemini embedding error: %w", err)
+	}
+	if len(resp.Embeddings) == 0 || len(resp.Embeddings[0].Values) == 0 {
+		return nil, fmt.Errorf("gemini returned no embedding")
+	}
+	return resp.Embeddings[0].Values, nil
+}
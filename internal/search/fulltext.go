@@ -0,0 +1,340 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// textIndexVersion is bumped when the stored terms change, so older text
+// indexes are rebuilt
+const textIndexVersion = 1
+
+// TextIndex is a full-text index of summary files kept in a JSON file. It
+// is refreshed from the files themselves, so edited, added, and deleted
+// summaries are picked up whoever wrote them.
+type TextIndex struct {
+	path string
+	docs map[string]*textDoc
+}
+
+// textDoc is an indexed summary file
+type textDoc struct {
+	ModTime time.Time          `json:"mod_time"`
+	Size    int64              `json:"size"`
+	Title   string             `json:"title,omitempty"`
+	URL     string             `json:"url,omitempty"`
+	Type    models.ContentType `json:"type,omitempty"`
+	Created time.Time          `json:"created"`
+	Tags    []string           `json:"tags,omitempty"`
+	// Terms counts the words of the file, front matter included
+	Terms map[string]int `json:"terms"`
+}
+
+// TextFilter narrows the summaries found; zero fields match everything
+type TextFilter struct {
+	Type  models.ContentType
+	Since time.Time
+	Tag   string
+}
+
+// TextResult is a summary containing every word of a query
+type TextResult struct {
+	Path    string             `json:"path"`
+	Title   string             `json:"title,omitempty"`
+	URL     string             `json:"url,omitempty"`
+	Type    models.ContentType `json:"type,omitempty"`
+	Created time.Time          `json:"created"`
+	Tags    []string           `json:"tags,omitempty"`
+	Score   float64            `json:"score"`
+	// Snippet is the first line of the summary with a word of the query
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// OpenTextIndex loads the full-text index at path; a missing or outdated
+// index is empty
+func OpenTextIndex(path string) (*TextIndex, error) {
+	x := &TextIndex{path: path, docs: make(map[string]*textDoc)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text index: %w", err)
+	}
+	var stored struct {
+		Version int                 `json:"version"`
+		Docs    map[string]*textDoc `json:"docs"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != textIndexVersion {
+		return x, nil
+	}
+	if stored.Docs != nil {
+		x.docs = stored.Docs
+	}
+	return x, nil
+}
+
+// Refresh makes the index match the summary files at paths: new and
+// modified files are read, missing ones dropped. It returns whether the
+// index changed.
+func (x *TextIndex) Refresh(paths []string) (bool, error) {
+	changed := false
+	current := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		current[path] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if doc, ok := x.docs[path]; ok && doc.ModTime.Equal(info.ModTime()) && doc.Size == info.Size() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return changed, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		x.docs[path] = parseTextDoc(string(data), info)
+		changed = true
+	}
+	for path := range x.docs {
+		if !current[path] {
+			delete(x.docs, path)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// Save writes the index to its file
+func (x *TextIndex) Save() error {
+	data, err := json.Marshal(struct {
+		Version int                 `json:"version"`
+		Docs    map[string]*textDoc `json:"docs"`
+	}{textIndexVersion, x.docs})
+	if err != nil {
+		return err
+	}
+	tmp := x.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write text index: %w", err)
+	}
+	if err := os.Rename(tmp, x.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write text index: %w", err)
+	}
+	return nil
+}
+
+// Find returns the summaries matching filter that contain every word of
+// query, a word also matching longer words it starts, most relevant first
+func (x *TextIndex) Find(query string, filter TextFilter, limit int) []TextResult {
+	words := terms(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	idf := make(map[string]float64, len(words))
+	for _, w := range words {
+		idf[w] = x.idf(w)
+	}
+
+	var results []TextResult
+	for path, doc := range x.docs {
+		if filter.Type != "" && doc.Type != filter.Type {
+			continue
+		}
+		if !filter.Since.IsZero() && doc.Created.Before(filter.Since) {
+			continue
+		}
+		if filter.Tag != "" && !slices.Contains(doc.Tags, strings.ToLower(filter.Tag)) {
+			continue
+		}
+
+		score := 0.0
+		for _, w := range words {
+			count := doc.count(w)
+			if count == 0 {
+				score = 0
+				break
+			}
+			// Rarer words weigh more, repeated ones with diminishing returns
+			score += (1 + math.Log(float64(count))) * idf[w]
+		}
+		if score == 0 {
+			continue
+		}
+		results = append(results, TextResult{
+			Path:    path,
+			Title:   doc.Title,
+			URL:     doc.URL,
+			Type:    doc.Type,
+			Created: doc.Created,
+			Tags:    doc.Tags,
+			Score:   score,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Created.After(results[j].Created)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Snippet = snippet(results[i].Path, words)
+	}
+	return results
+}
+
+// count returns the occurrences of the words of doc starting with w
+func (doc *textDoc) count(w string) int {
+	n := doc.Terms[w]
+	for term, c := range doc.Terms {
+		if term != w && strings.HasPrefix(term, w) {
+			n += c
+		}
+	}
+	return n
+}
+
+func (x *TextIndex) idf(w string) float64 {
+	matching := 0
+	for _, doc := range x.docs {
+		if doc.count(w) > 0 {
+			matching++
+		}
+	}
+	return math.Log(1 + float64(len(x.docs))/float64(max(matching, 1)))
+}
+
+// parseTextDoc indexes a summary file, reading its properties from the
+// front matter or, without one, from its header lines
+func parseTextDoc(text string, info os.FileInfo) *textDoc {
+	doc := &textDoc{ModTime: info.ModTime(), Size: info.Size(), Created: info.ModTime(), Terms: make(map[string]int)}
+	for _, t := range terms(text) {
+		doc.Terms[t]++
+	}
+
+	body := text
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if props, after, ok := strings.Cut(rest, "\n---\n"); ok {
+			body = after
+			var fm struct {
+				Title   string   `yaml:"title"`
+				Source  string   `yaml:"source"`
+				Type    string   `yaml:"type"`
+				Created string   `yaml:"created"`
+				Tags    []string `yaml:"tags"`
+			}
+			if yaml.Unmarshal([]byte(props), &fm) == nil {
+				doc.Title, doc.URL, doc.Type = fm.Title, fm.Source, models.ContentType(fm.Type)
+				for _, tag := range fm.Tags {
+					doc.Tags = append(doc.Tags, strings.ToLower(tag))
+				}
+				if t, err := time.Parse(time.RFC3339, fm.Created); err == nil {
+					doc.Created = t
+				}
+			}
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case doc.Title == "" && strings.HasPrefix(line, "# "):
+			doc.Title = strings.TrimSpace(line[2:])
+		case doc.URL == "" && strings.HasPrefix(line, "**URL:**"):
+			doc.URL = strings.TrimSpace(strings.TrimPrefix(line, "**URL:**"))
+		case doc.Type == "" && strings.HasPrefix(line, "**Type:**"):
+			doc.Type = models.ContentType(strings.TrimSpace(strings.TrimPrefix(line, "**Type:**")))
+		case strings.HasPrefix(line, "**Generated:**"):
+			if t, err := time.Parse(time.RFC3339, strings.TrimSpace(strings.TrimPrefix(line, "**Generated:**"))); err == nil {
+				doc.Created = t
+			}
+		case line == "---":
+			// The header ends at the first rule
+			return doc
+		}
+	}
+	return doc
+}
+
+// terms splits text into lower-case words of two characters or more
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) >= 2 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// maxSnippet bounds the length of result snippets
+const maxSnippet = 160
+
+// headerLines is the most lines a summary header, ended by a rule, spans
+const headerLines = 12
+
+// snippet returns the first line of the file at path below its header
+// containing one of words, shortened around it
+func snippet(path string, words []string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	text := string(data)
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if _, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			text = body
+		}
+	}
+	if header, body, ok := strings.Cut(text, "\n---\n"); ok && strings.Count(header, "\n") < headerLines {
+		text = body
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lower := strings.ToLower(line)
+		for _, w := range words {
+			i := strings.Index(lower, w)
+			if i < 0 {
+				continue
+			}
+			runes := []rune(line)
+			if len(runes) <= maxSnippet {
+				return line
+			}
+			at := len([]rune(lower[:i]))
+			start := max(0, min(at-maxSnippet/3, len(runes)-maxSnippet))
+			end := min(len(runes), start+maxSnippet)
+			s := string(runes[start:end])
+			if start > 0 {
+				s = "…" + s
+			}
+			if end < len(runes) {
+				s += "…"
+			}
+			return s
+		}
+	}
+	return ""
+}
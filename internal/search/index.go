@@ -0,0 +1,212 @@
+// Package search indexes summaries by the embedding of their text, to find
+// past summaries related to a query by meaning rather than by keyword.
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// maxEmbedText bounds the text embedded for a summary, below the input
+// limit of the usual embedding models
+const maxEmbedText = 8000
+
+// Document is a summary to index
+type Document struct {
+	ID          string
+	Title       string
+	URL         string
+	OutputPath  string
+	ContentType models.ContentType
+	// Text is what the document is found by, such as its title and summary
+	Text string
+}
+
+// entry is a line of the index file
+type entry struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title,omitempty"`
+	URL         string             `json:"url,omitempty"`
+	OutputPath  string             `json:"output_path"`
+	ContentType models.ContentType `json:"content_type,omitempty"`
+	Model       string             `json:"model"`
+	Vector      []float32          `json:"vector"`
+	Created     time.Time          `json:"created"`
+}
+
+// Result is a summary matching a query, Score being the cosine similarity
+// of their embeddings
+type Result struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title,omitempty"`
+	URL         string             `json:"url,omitempty"`
+	OutputPath  string             `json:"output_path"`
+	ContentType models.ContentType `json:"content_type,omitempty"`
+	Score       float64            `json:"score"`
+	Created     time.Time          `json:"created"`
+}
+
+// Index keeps the embeddings of summaries in a JSON-lines file. Entries
+// are appended; a summary indexed again replaces its previous entry.
+type Index struct {
+	mu       sync.Mutex
+	path     string
+	embedder Embedder
+}
+
+func New(path string, embedder Embedder) *Index {
+	return &Index{path: path, embedder: embedder}
+}
+
+// Add embeds doc and appends it to the index
+func (x *Index) Add(ctx context.Context, doc Document) error {
+	if x == nil {
+		return nil
+	}
+	text := doc.Text
+	if len(text) > maxEmbedText {
+		// Avoid cutting a multi-byte rune in half
+		text = strings.ToValidUTF8(text[:maxEmbedText], "")
+	}
+	vector, err := x.embedder.Embed(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry{
+		ID:          doc.ID,
+		Title:       doc.Title,
+		URL:         doc.URL,
+		OutputPath:  doc.OutputPath,
+		ContentType: doc.ContentType,
+		Model:       x.embedder.Model(),
+		Vector:      vector,
+		Created:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	f, err := os.OpenFile(x.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	return nil
+}
+
+// Indexed returns the output paths indexed with the current model
+func (x *Index) Indexed() (map[string]bool, error) {
+	entries, err := x.load()
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		paths[e.OutputPath] = true
+	}
+	return paths, nil
+}
+
+// Search returns the limit summaries closest in meaning to query, most
+// relevant first. Summaries whose file was deleted are left out.
+func (x *Index) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	entries, err := x.load()
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	vector, err := x.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(entries))
+	for _, e := range entries {
+		if len(e.Vector) != len(vector) {
+			continue
+		}
+		if _, err := os.Stat(e.OutputPath); err != nil {
+			continue
+		}
+		results = append(results, Result{
+			ID:          e.ID,
+			Title:       e.Title,
+			URL:         e.URL,
+			OutputPath:  e.OutputPath,
+			ContentType: e.ContentType,
+			Score:       cosine(vector, e.Vector),
+			Created:     e.Created,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// load reads the latest entry of each summary embedded with the current
+// model. A missing index is empty.
+func (x *Index) load() ([]entry, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	f, err := os.Open(x.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer f.Close()
+
+	latest := make(map[string]int)
+	var entries []entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e entry
+		// A line cut short by a crash is skipped
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Model != x.embedder.Model() {
+			continue
+		}
+		if i, ok := latest[e.OutputPath]; ok {
+			entries[i] = e
+			continue
+		}
+		latest[e.OutputPath] = len(entries)
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+	return entries, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
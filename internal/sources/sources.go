@@ -0,0 +1,123 @@
+// Package sources records how each summary was requested, in .sources.json
+// in the output directory, so that a deleted or low-rated summary can be
+// requested again the same way.
+package sources
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Source is how a summary was requested
+type Source struct {
+	URL             string    `json:"url"`
+	CustomPrompt    string    `json:"custom_prompt,omitempty"`
+	Notes           string    `json:"notes,omitempty"`
+	Feed            string    `json:"feed,omitempty"`
+	Persona         string    `json:"persona,omitempty"`
+	Style           string    `json:"style,omitempty"`
+	Language        string    `json:"language,omitempty"`
+	SummaryLanguage string    `json:"summary_language,omitempty"`
+	OutputFormat    string    `json:"output_format,omitempty"`
+	Questions       []string  `json:"questions,omitempty"`
+	CreatedAt       time.Time `json:"created_at,omitzero"`
+}
+
+// Of returns how job was requested
+func Of(job models.Job) Source {
+	return Source{
+		URL:             job.URL,
+		CustomPrompt:    job.CustomPrompt,
+		Notes:           job.Notes,
+		Feed:            job.Feed,
+		Persona:         job.Persona,
+		Style:           job.Style,
+		Language:        job.Language,
+		SummaryLanguage: job.SummaryLanguage,
+		OutputFormat:    job.OutputFormat,
+		Questions:       job.Questions,
+		CreatedAt:       job.CreatedAt,
+	}
+}
+
+// Job requests the summary again with the same settings. The original
+// creation time keeps it in the same folder of a dated output layout.
+func (s Source) Job() *models.Job {
+	job := models.NewJob("", s.URL, s.CustomPrompt)
+	job.Notes = s.Notes
+	job.Feed = s.Feed
+	job.Persona = s.Persona
+	job.Style = s.Style
+	job.Language = s.Language
+	job.SummaryLanguage = s.SummaryLanguage
+	job.OutputFormat = s.OutputFormat
+	job.Questions = s.Questions
+	if !s.CreatedAt.IsZero() {
+		job.CreatedAt = s.CreatedAt
+	}
+	return job
+}
+
+// Key identifies the summary at path by its path relative to outputDir,
+// without extension, whatever its format
+func Key(outputDir, path string) string {
+	rel, err := filepath.Rel(outputDir, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+}
+
+// summaryExtensions are the extensions of the output formats
+var summaryExtensions = map[string]bool{".md": true, ".json": true, ".html": true, ".txt": true}
+
+// IsSummaryFile reports whether name is a summary rather than a transcript,
+// an archived or rated-down copy, or Briefly's own state
+func IsSummaryFile(name string) bool {
+	return summaryExtensions[filepath.Ext(name)] && !strings.HasPrefix(name, ".") && !strings.Contains(name, ".rated-") &&
+		!strings.HasSuffix(name, ".transcript.md") && !strings.HasSuffix(name, ".archive.md")
+}
+
+func path(outputDir string) string {
+	return filepath.Join(outputDir, ".sources.json")
+}
+
+// Load returns the recorded sources by summary key; none are recorded
+// until the output watcher runs
+func Load(outputDir string) (map[string]Source, error) {
+	sources := make(map[string]Source)
+	data, err := os.ReadFile(path(outputDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sources, nil
+		}
+		return sources, err
+	}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return make(map[string]Source), err
+	}
+	return sources, nil
+}
+
+// Save replaces the recorded sources
+func Save(outputDir string, sources map[string]Source) error {
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return err
+	}
+	tmp := path(outputDir) + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err == nil {
+		err = os.Rename(tmp, path(outputDir))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+const chunkPrompt = `You are reading part %d of %d of a longer piece of content. ` +
+	`Write detailed notes on this part: the main points, arguments, names, figures, and quotes. ` +
+	`Do not write an introduction or conclusion, they will be produced later from all the notes.`
+
+const combinePreamble = `The content was too long to process at once, so it was split into parts and ` +
+	`summarized part by part. The notes below cover the whole content in order; base your answer on them.`
+
+// ChunkingSummarizer splits content larger than the chunk size, summarizes
+// each chunk, then summarizes the combined notes with the requested prompt
+type ChunkingSummarizer struct {
+	next      Summarizer
+	chunkSize int
+	overlap   int
+}
+
+func NewChunkingSummarizer(next Summarizer, chunkSize, overlap int) *ChunkingSummarizer {
+	if overlap < 0 || overlap >= chunkSize {
+		overlap = 0
+	}
+	return &ChunkingSummarizer{next: next, chunkSize: chunkSize, overlap: overlap}
+}
+
+func (c *ChunkingSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	if c.chunkSize <= 0 || len(content) <= c.chunkSize || promptOptionsFrom(ctx).Raw {
+		return c.next.Summarize(ctx, content, customPrompt, contentType)
+	}
+
+	chunks := SplitChunks(content, c.chunkSize, c.overlap)
+	slog.Info("Content too long, summarizing chunks", "chars", len(content), "chunks", len(chunks))
+
+	// Chunk notes are intermediate output, so they skip validation, and
+	// images are attached only to the final request
+	opts := promptOptionsFrom(ctx)
+	opts.Raw = true
+	chunkCtx := WithImages(WithPromptOptions(ctx, opts), nil)
+
+	notes := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		note, err := c.next.Summarize(chunkCtx, chunk, fmt.Sprintf(chunkPrompt, i+1, len(chunks)), contentType)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		notes = append(notes, fmt.Sprintf("## Part %d\n\n%s", i+1, strings.TrimSpace(note)))
+	}
+
+	combined := combinePreamble + "\n\n" + strings.Join(notes, "\n\n")
+	return c.next.Summarize(ctx, combined, customPrompt, contentType)
+}
+
+// SplitChunks cuts content into pieces of at most size bytes, preferring
+// paragraph and sentence boundaries, each starting overlap bytes before the
+// end of the previous one
+func SplitChunks(content string, size, overlap int) []string {
+	var chunks []string
+	for start := 0; start < len(content); {
+		end := start + size
+		if end >= len(content) {
+			chunks = append(chunks, content[start:])
+			break
+		}
+		end = boundary(content, start+size/2, end)
+		chunks = append(chunks, content[start:end])
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// boundary returns the best cut point in content[min:max]: the last paragraph
+// break, else the last sentence end, else the last space, else max
+func boundary(content string, min, max int) int {
+	window := content[min:max]
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		if i := strings.LastIndex(window, sep); i >= 0 {
+			return min + i + len(sep)
+		}
+	}
+	return max
+}
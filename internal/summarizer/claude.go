@@ -2,6 +2,7 @@ package summarizer
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -23,24 +24,35 @@ func NewClaudeSummarizer(apiKey, model string) (*ClaudeSummarizer, error) {
 }
 
 func (c *ClaudeSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
-	prompt := customPrompt
-	if prompt == "" {
-		prompt = GetDefaultPrompt(contentType)
-	}
+	fullPrompt := BuildPrompt(ctx, content, customPrompt, contentType)
 
-	fullPrompt := fmt.Sprintf("%s\n\n---\n\nContent to summarize:\n\n%s", prompt, content)
+	var blocks []anthropic.ContentBlockParamUnion
+	for _, img := range imagesFrom(ctx) {
+		blocks = append(blocks, anthropic.NewImageBlockBase64(img.MIMEType, base64.StdEncoding.EncodeToString(img.Data)))
+	}
+	blocks = append(blocks, anthropic.NewTextBlock(fullPrompt))
+	if doc, ok := documentFrom(ctx); ok {
+		if doc.MIMEType != "application/pdf" {
+			return "", ErrDocumentUnsupported
+		}
+		blocks = append([]anthropic.ContentBlockParamUnion{anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{
+			Data: base64.StdEncoding.EncodeToString(doc.Data),
+		})}, blocks...)
+	}
 
 	message, err := c.client.Messages.New(ctx, anthropic.MessageNewParams{
 		Model:     anthropic.Model(c.model),
 		MaxTokens: 4096,
 		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(fullPrompt)),
+			anthropic.NewUserMessage(blocks...),
 		},
 	})
 	if err != nil {
 		return "", fmt.Errorf("claude API error: %w", err)
 	}
 
+	recordUsage(ctx, message.Usage.InputTokens, message.Usage.OutputTokens)
+
 	if len(message.Content) == 0 {
 		return "", fmt.Errorf("empty response from Claude")
 	}
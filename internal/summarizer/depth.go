@@ -0,0 +1,74 @@
+package summarizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Depth controls how long and structured a summary should be
+type Depth string
+
+const (
+	DepthBrief    Depth = "brief"
+	DepthStandard Depth = "standard"
+	DepthDetailed Depth = "detailed"
+	DepthOutline  Depth = "outline"
+	DepthAuto     Depth = "auto"
+)
+
+// DefaultDepthThresholds are the word counts above which a source moves to
+// the standard, detailed, and outline depths
+var DefaultDepthThresholds = []int{800, 4000, 15000}
+
+var depthInstructions = map[Depth]string{
+	DepthBrief: "The source is short. Write the summary as a single concise paragraph " +
+		"instead of the numbered sections.",
+	DepthDetailed: "The source is long. Give each section enough detail, with several " +
+		"bullet points per key point.",
+	DepthOutline: "The source is very long (for example a lecture or a book chapter). In addition " +
+		"to the requested sections, include a multi-section outline following the structure of " +
+		"the content, with a heading and bullet points for each major part.",
+}
+
+// DepthFor picks a depth for a source of the given word count
+func DepthFor(words int, thresholds []int) Depth {
+	if len(thresholds) != 3 {
+		thresholds = DefaultDepthThresholds
+	}
+	switch {
+	case words < thresholds[0]:
+		return DepthBrief
+	case words < thresholds[1]:
+		return DepthStandard
+	case words < thresholds[2]:
+		return DepthDetailed
+	default:
+		return DepthOutline
+	}
+}
+
+// ParseDepthThresholds parses three comma-separated word counts
+func ParseDepthThresholds(spec string) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultDepthThresholds, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("depth thresholds need three word counts, got %q", spec)
+	}
+
+	thresholds := make([]int, 0, 3)
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid depth threshold %q: %w", part, err)
+		}
+		if len(thresholds) > 0 && n <= thresholds[len(thresholds)-1] {
+			return nil, fmt.Errorf("depth thresholds must be increasing, got %q", spec)
+		}
+		thresholds = append(thresholds, n)
+	}
+	return thresholds, nil
+}
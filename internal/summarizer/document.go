@@ -0,0 +1,43 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDocumentUnsupported is returned by providers that cannot read an
+// attached document, so a fallback chain moves on to one that can
+var ErrDocumentUnsupported = errors.New("provider cannot read attached documents")
+
+// Document is a file handed to the model as is, for sources whose text could
+// not be extracted (e.g. scanned PDFs)
+type Document struct {
+	Data     []byte
+	MIMEType string
+}
+
+type documentKey struct{}
+
+// WithDocument attaches doc to the summarization requests made with ctx
+func WithDocument(ctx context.Context, doc Document) context.Context {
+	return context.WithValue(ctx, documentKey{}, doc)
+}
+
+func documentFrom(ctx context.Context) (Document, bool) {
+	doc, ok := ctx.Value(documentKey{}).(Document)
+	return doc, ok && len(doc.Data) > 0
+}
+
+type imagesKey struct{}
+
+// WithImages attaches key images of the source to the summarization requests
+// made with ctx, for providers with vision support. Providers without it
+// summarize the text alone.
+func WithImages(ctx context.Context, images []Document) context.Context {
+	return context.WithValue(ctx, imagesKey{}, images)
+}
+
+func imagesFrom(ctx context.Context) []Document {
+	images, _ := ctx.Value(imagesKey{}).([]Document)
+	return images
+}
@@ -0,0 +1,49 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Candidate is one provider/model in a fallback chain
+type Candidate struct {
+	Provider   string
+	Model      string
+	Summarizer Summarizer
+}
+
+// FallbackSummarizer tries each candidate in order until one succeeds, so a
+// provider outage or rate limit does not fail the job
+type FallbackSummarizer struct {
+	candidates []Candidate
+}
+
+func NewFallbackSummarizer(candidates ...Candidate) *FallbackSummarizer {
+	return &FallbackSummarizer{candidates: candidates}
+}
+
+func (f *FallbackSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	var errs []error
+	for i, c := range f.candidates {
+		summary, err := c.Summarizer.Summarize(ctx, content, customPrompt, contentType)
+		if err == nil {
+			recordServedBy(ctx, c.Provider, c.Model)
+			return summary, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s/%s: %w", c.Provider, c.Model, err))
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(f.candidates)-1 {
+			next := f.candidates[i+1]
+			slog.Warn("Provider failed, falling back", "provider", c.Provider, "model", c.Model, "error", err,
+				"fallback_provider", next.Provider, "fallback_model", next.Model)
+		}
+	}
+	return "", errors.Join(errs...)
+}
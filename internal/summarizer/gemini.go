@@ -29,18 +29,31 @@ func NewGeminiSummarizer(ctx context.Context, apiKey, model string) (*GeminiSumm
 }
 
 func (g *GeminiSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
-	prompt := customPrompt
-	if prompt == "" {
-		prompt = GetDefaultPrompt(contentType)
-	}
+	fullPrompt := BuildPrompt(ctx, content, customPrompt, contentType)
 
-	fullPrompt := fmt.Sprintf("%s\n\n---\n\nContent to summarize:\n\n%s", prompt, content)
+	contents := genai.Text(fullPrompt)
+	attachments := imagesFrom(ctx)
+	if doc, ok := documentFrom(ctx); ok {
+		attachments = append(attachments, doc)
+	}
+	if len(attachments) > 0 {
+		var parts []*genai.Part
+		for _, a := range attachments {
+			parts = append(parts, genai.NewPartFromBytes(a.Data, a.MIMEType))
+		}
+		parts = append(parts, genai.NewPartFromText(fullPrompt))
+		contents = []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+	}
 
-	result, err := g.client.Models.GenerateContent(ctx, g.model, genai.Text(fullPrompt), nil)
+	result, err := g.client.Models.GenerateContent(ctx, g.model, contents, nil)
 	if err != nil {
 		return "", fmt.Errorf("gemini API error: %w", err)
 	}
 
+	if result.UsageMetadata != nil {
+		recordUsage(ctx, int64(result.UsageMetadata.PromptTokenCount), int64(result.UsageMetadata.CandidatesTokenCount))
+	}
+
 	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("empty response from Gemini")
 	}
@@ -0,0 +1,130 @@
+package summarizer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// rateWindow is the span over which request and token rates are measured
+const rateWindow = time.Minute
+
+// Limiter bounds the number of simultaneous LLM requests, and the requests
+// and tokens sent per minute, across every summarizer it wraps,
+// independently of how many workers are running.
+type Limiter struct {
+	slots chan struct{}
+
+	requestsPerMinute int
+	tokensPerMinute   int
+
+	mu   sync.Mutex
+	sent []sentRequest
+}
+
+// sentRequest is a request counted against the rate limits
+type sentRequest struct {
+	at     time.Time
+	tokens int
+}
+
+// NewLimiter returns a limiter allowing concurrent simultaneous requests,
+// requestsPerMinute requests and tokensPerMinute estimated input tokens per
+// minute. Limits that are not positive are not enforced, and nil (no limit)
+// is returned when none is.
+func NewLimiter(concurrent, requestsPerMinute, tokensPerMinute int) *Limiter {
+	if concurrent <= 0 && requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	l := &Limiter{requestsPerMinute: requestsPerMinute, tokensPerMinute: tokensPerMinute}
+	if concurrent > 0 {
+		l.slots = make(chan struct{}, concurrent)
+	}
+	return l
+}
+
+// waitRate blocks until a request of tokens fits within the rate limits
+// and counts it. A request larger than the token limit on its own is sent
+// once the window is empty.
+func (l *Limiter) waitRate(ctx context.Context, tokens int) error {
+	if l.requestsPerMinute <= 0 && l.tokensPerMinute <= 0 {
+		return nil
+	}
+	logged := false
+	for {
+		wait := l.reserve(time.Now(), tokens)
+		if wait <= 0 {
+			return nil
+		}
+		if !logged {
+			slog.Info("LLM rate limit reached, waiting", "wait", wait.Round(time.Second),
+				"requests_per_minute", l.requestsPerMinute, "tokens_per_minute", l.tokensPerMinute)
+			logged = true
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve counts the request and returns 0 when it fits at now, otherwise
+// how long until the oldest counted request leaves the window
+func (l *Limiter) reserve(now time.Time, tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := l.sent[:0]
+	used := 0
+	for _, r := range l.sent {
+		if now.Sub(r.at) < rateWindow {
+			kept = append(kept, r)
+			used += r.tokens
+		}
+	}
+	l.sent = kept
+
+	full := l.requestsPerMinute > 0 && len(l.sent) >= l.requestsPerMinute
+	tooMany := l.tokensPerMinute > 0 && len(l.sent) > 0 && used+tokens > l.tokensPerMinute
+	if full || tooMany {
+		return l.sent[0].at.Add(rateWindow).Sub(now)
+	}
+	l.sent = append(l.sent, sentRequest{at: now, tokens: tokens})
+	return 0
+}
+
+// Wrap returns s with each Summarize call holding one limiter slot
+func (l *Limiter) Wrap(s Summarizer) Summarizer {
+	if l == nil {
+		return s
+	}
+	return &limitedSummarizer{next: s, limiter: l}
+}
+
+type limitedSummarizer struct {
+	next    Summarizer
+	limiter *Limiter
+}
+
+func (ls *limitedSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	if ls.limiter.slots != nil {
+		select {
+		case ls.limiter.slots <- struct{}{}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		defer func() { <-ls.limiter.slots }()
+	}
+
+	if err := ls.limiter.waitRate(ctx, EstimateTokens(content)+EstimateTokens(customPrompt)+promptReserve); err != nil {
+		return "", err
+	}
+
+	return ls.next.Summarize(ctx, content, customPrompt, contentType)
+}
@@ -0,0 +1,118 @@
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// DefaultOpenAIBaseURL is the OpenAI API endpoint. Any OpenAI-compatible
+// server (Ollama, vLLM, LM Studio) can be used by overriding it.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+type OpenAISummarizer struct {
+	client  *http.Client
+	apiKey  string
+	baseURL string
+	model   string
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewOpenAISummarizer(apiKey, baseURL, model string) (*OpenAISummarizer, error) {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAISummarizer{
+		client:  &http.Client{Timeout: 10 * time.Minute},
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+	}, nil
+}
+
+func (o *OpenAISummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	if _, ok := documentFrom(ctx); ok {
+		return "", ErrDocumentUnsupported
+	}
+	// Images are not sent to OpenAI-compatible servers, many of which lack vision
+	ctx = WithImages(ctx, nil)
+
+	fullPrompt := BuildPrompt(ctx, content, customPrompt, contentType)
+
+	body, err := json.Marshal(openAIRequest{
+		Model:    o.model,
+		Messages: []openAIMessage{{Role: "user", Content: fullPrompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai API error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("openai API error: %w", err)
+	}
+
+	var result openAIResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("openai API error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode >= 400 {
+		message := strings.TrimSpace(string(data))
+		if result.Error != nil {
+			message = result.Error.Message
+		}
+		return "", fmt.Errorf("openai API error: status %d: %s", resp.StatusCode, message)
+	}
+
+	recordUsage(ctx, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("empty response from OpenAI")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,77 @@
+package summarizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultPersonas are the built-in audience presets, added to the prompt as
+// an extra instruction
+var DefaultPersonas = map[string]string{
+	"engineer": "Write for a software engineer: keep technical details, names of tools, " +
+		"APIs, and numbers, and point out trade-offs and limitations.",
+	"executive": "Write for a busy executive: lead with the bottom line, focus on impact, " +
+		"costs, risks, and decisions, and leave out technical detail.",
+	"student": "Write for a student learning the topic: explain the key concepts and " +
+		"terms briefly as they come up, and end with questions worth reviewing.",
+	"eli5": "Explain it simply, as to a curious twelve-year-old: plain words, short " +
+		"sentences, and a concrete analogy for the main idea.",
+	"researcher": "Write for a researcher: state the claims, methods, evidence, and " +
+		"sample sizes precisely, and note open questions and weaknesses.",
+}
+
+// Personas maps preset names to their instructions
+type Personas map[string]string
+
+// LoadPersonas returns the built-in presets, extended or overridden by the
+// "<name>.txt" and "<name>.md" files in dir when it is set
+func LoadPersonas(dir string) (Personas, error) {
+	personas := make(Personas, len(DefaultPersonas))
+	for name, text := range DefaultPersonas {
+		personas[name] = text
+	}
+	if dir == "" {
+		return personas, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona directory: %w", err)
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".txt" && ext != ".md") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read persona %s: %w", e.Name(), err)
+		}
+		if text := strings.TrimSpace(string(data)); text != "" {
+			personas[strings.ToLower(strings.TrimSuffix(e.Name(), ext))] = text
+		}
+	}
+	return personas, nil
+}
+
+// Instruction returns the instruction of the named preset
+func (p Personas) Instruction(name string) (string, error) {
+	text, ok := p[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("unknown persona %q, available: %s", name, strings.Join(p.Names(), ", "))
+	}
+	return text, nil
+}
+
+// Names returns the preset names in alphabetical order
+func (p Personas) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,144 @@
+package summarizer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/language"
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// LanguagePolicy chooses the language summaries are written in
+type LanguagePolicy string
+
+const (
+	// LanguageSource writes summaries in the language of the content
+	LanguageSource LanguagePolicy = "source"
+	// LanguageTranslate always writes summaries in the target language
+	LanguageTranslate LanguagePolicy = "translate"
+	// LanguageBilingual writes summaries in the language of the content,
+	// followed by a translation into the target language
+	LanguageBilingual LanguagePolicy = "bilingual"
+)
+
+// PromptOptions carries per-job adjustments to the summarization prompt
+type PromptOptions struct {
+	// SourceLanguage is the ISO 639-1 code of the content, if known
+	SourceLanguage string
+
+	// LanguagePolicy and TargetLanguage, an ISO 639-1 code, choose the
+	// summary language; the zero value follows the content
+	LanguagePolicy LanguagePolicy
+	TargetLanguage string
+
+	// Depth scales the summary with the source length
+	Depth Depth
+
+	// Persona is the audience instruction of the selected persona preset
+	Persona string
+
+	// Style replaces the default prompt with a summary format of its own,
+	// which also sets the length, so Depth is not applied
+	Style Style
+
+	// Raw marks auxiliary requests (titles, tags) that skip summary depth
+	// instructions and validation
+	Raw bool
+}
+
+type promptOptionsKey struct{}
+
+// WithPromptOptions attaches prompt adjustments to ctx
+func WithPromptOptions(ctx context.Context, opts PromptOptions) context.Context {
+	return context.WithValue(ctx, promptOptionsKey{}, opts)
+}
+
+func promptOptionsFrom(ctx context.Context) PromptOptions {
+	opts, _ := ctx.Value(promptOptionsKey{}).(PromptOptions)
+	return opts
+}
+
+// BuildPrompt assembles the full request sent to a provider: the custom or
+// default prompt, the per-job adjustments from ctx, and the content.
+func BuildPrompt(ctx context.Context, content, customPrompt string, contentType models.ContentType) string {
+	opts := promptOptionsFrom(ctx)
+	prompt := customPrompt
+	if prompt == "" {
+		prompt = basePrompt(opts, contentType)
+	}
+
+	var instructions []string
+	if instruction := languageInstruction(opts); instruction != "" {
+		instructions = append(instructions, instruction)
+	}
+
+	if instruction, ok := depthInstructions[opts.Depth]; ok && !opts.Raw && opts.Style == "" {
+		instructions = append(instructions, instruction)
+	}
+
+	if opts.Persona != "" && !opts.Raw {
+		instructions = append(instructions, opts.Persona)
+	}
+
+	if len(imagesFrom(ctx)) > 0 && !opts.Raw {
+		instructions = append(instructions,
+			"Key images of the page are attached. Include what their charts, infographics, or slides show where it matters.")
+	}
+
+	if len(instructions) > 0 {
+		prompt = fmt.Sprintf("%s\n\n%s", prompt, strings.Join(instructions, "\n"))
+	}
+
+	return fmt.Sprintf("%s\n\n---\n\nContent to summarize:\n\n%s", prompt, content)
+}
+
+// languageInstruction tells the model which language to write in under the
+// language policy. Auxiliary requests are written in a single language.
+func languageInstruction(opts PromptOptions) string {
+	source, target := opts.SourceLanguage, opts.TargetLanguage
+	if target == "" {
+		target = "en"
+	}
+
+	switch opts.LanguagePolicy {
+	case LanguageTranslate:
+		if source != "" && source != target {
+			return fmt.Sprintf("The content is in %s. Write the summary in %s, translating quotes and terms where needed.",
+				language.Name(source), language.Name(target))
+		}
+		return fmt.Sprintf("Write the summary in %s.", language.Name(target))
+	case LanguageBilingual:
+		if source == target || opts.Raw {
+			return fmt.Sprintf("Write the summary in %s.", language.Name(target))
+		}
+		if source != "" {
+			return fmt.Sprintf("The content is in %s. Write the summary in %s, then repeat it translated into %s under a \"## %s\" heading.",
+				language.Name(source), language.Name(source), language.Name(target), language.Name(target))
+		}
+		return fmt.Sprintf("Write the summary in the language of the content, then repeat it translated into %s under a \"## %s\" heading. "+
+			"If the content is already in %s, write the summary once.", language.Name(target), language.Name(target), language.Name(target))
+	default:
+		if source != "" {
+			return fmt.Sprintf("The content is in %s. Write the summary in %s.", language.Name(source), language.Name(source))
+		}
+		return "Write the summary in the same language as the content."
+	}
+}
+
+// outputLanguage returns the ISO 639-1 code the summary is written in, or
+// an empty string when it is unknown or mixed
+func (o PromptOptions) outputLanguage() string {
+	switch o.LanguagePolicy {
+	case LanguageTranslate:
+		return cmp.Or(o.TargetLanguage, "en")
+	case LanguageBilingual:
+		if o.SourceLanguage == cmp.Or(o.TargetLanguage, "en") {
+			return o.SourceLanguage
+		}
+		return ""
+	default:
+		return o.SourceLanguage
+	}
+}
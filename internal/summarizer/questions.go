@@ -0,0 +1,35 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+const questionsPrompt = `Answer each of the questions below using only the content that follows them, ` +
+	`pointing to the part of the content the answer comes from where possible. ` +
+	`If the content does not cover a question, say so plainly instead of guessing. ` +
+	`Write each question as a "### " heading followed by its answer, without an introduction.
+
+Questions:
+%s`
+
+// AnswerQuestions asks s to answer questions from content. Long content is
+// chunked like a summary, and the answers follow the language of the summary.
+func AnswerQuestions(ctx context.Context, s Summarizer, content string, questions []string, contentType models.ContentType) (string, error) {
+	var list strings.Builder
+	for i, q := range questions {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, q)
+	}
+
+	// The shape of the summary does not apply to the answers
+	opts := promptOptionsFrom(ctx)
+	opts.Depth, opts.Persona, opts.Style = "", "", ""
+	answers, err := s.Summarize(WithPromptOptions(ctx, opts), content, fmt.Sprintf(questionsPrompt, list.String()), contentType)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answers), nil
+}
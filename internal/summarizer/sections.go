@@ -0,0 +1,127 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+const sectionPrompt = `You are reading the section "%s" of a longer piece of content. ` +
+	`Summarize this section in a short paragraph or a few bullet points: its main points, names, figures, and quotes. ` +
+	`Do not write an introduction or conclusion.`
+
+const synthesisPreamble = `The content is long, so each of its sections was summarized separately. ` +
+	`The section summaries below cover the whole content in order; base your answer on them.`
+
+const (
+	// minSections is the fewest sections worth summarizing one by one
+	minSections = 3
+	// minSectionLength merges sections shorter than this into the previous one
+	minSectionLength = 500
+)
+
+// markdownHeading matches "#"-style heading lines
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+
+// Section is a heading of the content and the text below it
+type Section struct {
+	Heading string
+	Body    string
+}
+
+// SectionSummarizer summarizes long content that has its own headings one
+// section at a time, then writes a synthesis from the section summaries
+// followed by the summaries themselves
+type SectionSummarizer struct {
+	next      Summarizer
+	minLength int
+}
+
+func NewSectionSummarizer(next Summarizer, minLength int) *SectionSummarizer {
+	return &SectionSummarizer{next: next, minLength: minLength}
+}
+
+func (s *SectionSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	if s.minLength <= 0 || len(content) < s.minLength || promptOptionsFrom(ctx).Raw ||
+		(contentType != models.ContentTypeText && contentType != models.ContentTypeDirectText) {
+		return s.next.Summarize(ctx, content, customPrompt, contentType)
+	}
+
+	sections := SplitSections(content)
+	if len(sections) < minSections {
+		return s.next.Summarize(ctx, content, customPrompt, contentType)
+	}
+	slog.Info("Long content, summarizing sections", "chars", len(content), "sections", len(sections))
+
+	// Section summaries are intermediate output, so they skip validation
+	opts := promptOptionsFrom(ctx)
+	opts.Raw = true
+	sectionCtx := WithImages(WithPromptOptions(ctx, opts), nil)
+
+	notes := make([]string, 0, len(sections))
+	for i, section := range sections {
+		note, err := s.next.Summarize(sectionCtx, section.Body, fmt.Sprintf(sectionPrompt, section.Heading), contentType)
+		if err != nil {
+			return "", fmt.Errorf("section %d/%d: %w", i+1, len(sections), err)
+		}
+		notes = append(notes, fmt.Sprintf("### %s\n\n%s", section.Heading, strings.TrimSpace(note)))
+	}
+
+	summaries := strings.Join(notes, "\n\n")
+	synthesis, err := s.next.Summarize(ctx, synthesisPreamble+"\n\n"+summaries, customPrompt, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(synthesis) + "\n\n## Section by section\n\n" + summaries, nil
+}
+
+// SplitSections cuts content at its headings, using the shallowest heading
+// level that yields enough sections. Text before the first heading becomes
+// an "Introduction" section. It returns nil when the content has too few
+// headings.
+func SplitSections(content string) []Section {
+	lines := strings.Split(content, "\n")
+	for level := 1; level <= 4; level++ {
+		if sections := splitAtLevel(lines, level); len(sections) >= minSections {
+			return sections
+		}
+	}
+	return nil
+}
+
+func splitAtLevel(lines []string, level int) []Section {
+	var sections []Section
+	current := Section{Heading: "Introduction"}
+	var body []string
+
+	flush := func() {
+		current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		switch {
+		case current.Body == "":
+		case len(current.Body) < minSectionLength && len(sections) > 0:
+			last := &sections[len(sections)-1]
+			last.Body += "\n\n" + current.Heading + "\n\n" + current.Body
+		default:
+			sections = append(sections, current)
+		}
+		body = nil
+	}
+
+	for _, line := range lines {
+		m := markdownHeading.FindStringSubmatch(strings.TrimSpace(line))
+		if m != nil && len(m[1]) <= level {
+			flush()
+			current = Section{Heading: strings.TrimSpace(m[2])}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+
+	return sections
+}
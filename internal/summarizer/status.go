@@ -0,0 +1,32 @@
+package summarizer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/genai"
+)
+
+// statusPattern finds the HTTP status in errors of the OpenAI-compatible
+// client, which reports it as "status 400"
+var statusPattern = regexp.MustCompile(`\bstatus (\d{3})\b`)
+
+// StatusCode returns the HTTP status of a failed provider request, or 0
+// when err does not carry one
+func StatusCode(err error) int {
+	var claudeErr *anthropic.Error
+	if errors.As(err, &claudeErr) {
+		return claudeErr.StatusCode
+	}
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		return geminiErr.Code
+	}
+	if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// Style replaces the structure of the default prompts with another summary
+// format, keeping their description of the content
+type Style string
+
+const (
+	StyleTLDR      Style = "tldr"
+	StyleBullets   Style = "bullets"
+	StyleDetailed  Style = "detailed"
+	StyleELI5      Style = "eli5"
+	StyleExecutive Style = "executive"
+)
+
+var styleInstructions = map[Style]string{
+	StyleTLDR: `Write a TL;DR: two or three sentences stating the single most important takeaway first.
+Do not use headings or lists.`,
+	StyleBullets: `Write the summary as a flat list of 5 to 10 bullet points, one key point each, the most important first.
+Do not add headings, an introduction, or a conclusion.`,
+	StyleDetailed: `Write a detailed summary that includes:
+1. **Main Topic**: What the content is about, with its context
+2. **Key Points**: A subsection for each major point, with several bullet points of supporting details, examples, and numbers
+3. **Notable Quotes or Data**: Statements and figures worth keeping verbatim
+4. **Conclusion**: The conclusions, and the open questions the content leaves`,
+	StyleELI5: `Explain the content simply, as to a curious twelve-year-old: a short paragraph on what it is about,
+then its main ideas in plain words and short sentences, with a concrete analogy for the central one. Avoid jargon.`,
+	StyleExecutive: `Write an executive brief of at most 200 words:
+1. **Bottom Line**: One sentence with the conclusion that matters most
+2. **Why It Matters**: The impact, costs, and risks
+3. **Key Facts**: Bullet points with the supporting facts and numbers
+4. **Decisions**: The decisions or actions the content calls for, if any`,
+}
+
+// ParseStyle validates a style name; "tl;dr" is accepted for tldr and an
+// empty name keeps the default prompts
+func ParseStyle(name string) (Style, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil
+	}
+	style := Style(strings.ReplaceAll(name, ";", ""))
+	if _, ok := styleInstructions[style]; !ok {
+		return "", fmt.Errorf("unknown summary style %q, expected tldr, bullets, detailed, eli5, or executive", name)
+	}
+	return style, nil
+}
+
+// StylePrompt returns the prompt of style for contentType: the first
+// sentence of the default prompt, which describes the content, followed by
+// the format of the style
+func StylePrompt(style Style, contentType models.ContentType) string {
+	instruction, ok := styleInstructions[style]
+	if !ok {
+		return GetDefaultPrompt(contentType)
+	}
+	intro, _, _ := strings.Cut(GetDefaultPrompt(contentType), ". ")
+	return intro + ".\n\n" + instruction
+}
+
+// basePrompt is the prompt used without a custom one
+func basePrompt(opts PromptOptions, contentType models.ContentType) string {
+	if opts.Style != "" && !opts.Raw {
+		return StylePrompt(opts.Style, contentType)
+	}
+	return GetDefaultPrompt(contentType)
+}
@@ -17,6 +17,8 @@ const DefaultYouTubePrompt = `You are analyzing a YouTube video transcript. Plea
 3. **Important Details**: Any statistics, quotes, or specific examples mentioned
 4. **Conclusion**: What are the main takeaways?
 
+If the transcript is divided into chapters, organize the Key Points under one heading per chapter, starting with its timestamp. When the transcript has timestamps such as [12:34], cite the timestamp of the moment each key point comes from, written the same way.
+
 Keep the summary concise but informative. Use bullet points where appropriate.`
 
 const DefaultTextPrompt = `You are analyzing a web article. Please provide a comprehensive summary that includes:
@@ -28,12 +30,124 @@ const DefaultTextPrompt = `You are analyzing a web article. Please provide a com
 
 Keep the summary concise but informative. Use bullet points where appropriate.`
 
+const DefaultPodcastPrompt = `You are analyzing a podcast episode transcript. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the episode about, and who is speaking?
+2. **Key Points**: List the main arguments, ideas, or stories discussed
+3. **Important Details**: Any statistics, quotes, recommendations, or specific examples mentioned
+4. **Conclusion**: What are the main takeaways?
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultPDFPrompt = `You are analyzing a PDF document such as a paper, report, or whitepaper. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the document about, and what problem does it address?
+2. **Key Points**: List the main arguments, methods, or findings
+3. **Important Details**: Any data, results, figures, or specific examples mentioned
+4. **Conclusion**: What are the main takeaways?
+
+Tables and figure captions extracted from the document may follow the text, marked with [TABLE n] and [CAPTION]; use them for the key results.
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultDirectTextPrompt = `You are summarizing a user-provided document. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the document about?
+2. **Key Points**: List the main ideas, decisions, or information presented
+3. **Important Details**: Any figures, names, dates, or specific examples mentioned
+4. **Conclusion**: What are the main takeaways or next steps?
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+// DigestPrompt synthesizes a folder of notes, such as a week of meeting
+// notes, into one overview
+const DigestPrompt = `You are reading a collection of notes, one per file, each headed by its file name and date. Please write an overview document that synthesizes them:
+
+1. **Overview**: What do the notes cover, and over what period?
+2. **Themes**: The recurring topics, and how they developed across the notes
+3. **Decisions and Outcomes**: What was decided or concluded, and where
+4. **Open Items**: Action items, unanswered questions, and follow-ups still pending
+
+Refer to the source files by name where it helps. Keep the overview concise but informative. Use bullet points where appropriate.`
+
+const DefaultThreadPrompt = `You are analyzing a Twitter/X thread, given as numbered posts by the same author. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the thread about, and who wrote it?
+2. **Key Points**: List the main arguments, claims, or ideas, following the thread's line of reasoning
+3. **Important Details**: Any statistics, examples, or links mentioned
+4. **Conclusion**: What are the main takeaways?
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultRedditPrompt = `You are analyzing a Reddit post followed by its top comments. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the post about or asking?
+2. **Key Points**: The main points of the post
+3. **Discussion**: The prevailing views, answers, and disagreements in the comments, noting how widely each is shared
+4. **Conclusion**: What are the main takeaways?
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultRepositoryPrompt = `You are analyzing a software repository from its metadata, README, file tree, and recent release notes. Please provide a summary that includes:
+
+1. **Purpose**: What does the project do, and for whom?
+2. **Key Features**: The main capabilities and how it is used
+3. **Technology**: Languages, platforms, and notable dependencies or architecture visible in the files
+4. **Maturity**: How mature and active the project is, judging from its age, last activity, releases, popularity, license, and documentation
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultIssuePrompt = `You are analyzing a GitHub issue or pull request with its discussion. Please write a briefing that includes:
+
+1. **Summary**: What is the issue or change about, and why?
+2. **Current State**: Where it stands: open questions, review verdicts, whether it is merged, blocked, or waiting on someone
+3. **Key Points**: The main arguments, decisions, and concerns raised in the discussion, and who raised them
+4. **Scope**: For pull requests, the size of the change and the areas of the code it touches
+
+Keep the briefing concise but informative. Use bullet points where appropriate.`
+
+const DefaultMediaPrompt = `You are analyzing the transcript of a recording, such as a meeting, a talk, or a voice memo. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the recording about, and who is speaking?
+2. **Key Points**: The main ideas, discussions, and decisions
+3. **Action Items**: Tasks, owners, and deadlines mentioned, if any
+4. **Conclusion**: What are the main takeaways and next steps?
+
+The transcript is automatic and may contain recognition errors; do not repeat them. Keep the summary concise but informative. Use bullet points where appropriate.`
+
+const DefaultVideoPrompt = `You are analyzing a video transcript. Please provide a comprehensive summary that includes:
+
+1. **Main Topic**: What is the video about?
+2. **Key Points**: List the main arguments, ideas, or information presented
+3. **Important Details**: Any statistics, quotes, or specific examples mentioned
+4. **Conclusion**: What are the main takeaways?
+
+Keep the summary concise but informative. Use bullet points where appropriate.`
+
 func GetDefaultPrompt(contentType models.ContentType) string {
 	switch contentType {
 	case models.ContentTypeYouTube:
 		return DefaultYouTubePrompt
+	case models.ContentTypeVideo:
+		return DefaultVideoPrompt
 	case models.ContentTypeText:
 		return DefaultTextPrompt
+	case models.ContentTypePodcast:
+		return DefaultPodcastPrompt
+	case models.ContentTypePDF:
+		return DefaultPDFPrompt
+	case models.ContentTypeThread:
+		return DefaultThreadPrompt
+	case models.ContentTypeReddit:
+		return DefaultRedditPrompt
+	case models.ContentTypeRepository:
+		return DefaultRepositoryPrompt
+	case models.ContentTypeIssue:
+		return DefaultIssuePrompt
+	case models.ContentTypeMedia:
+		return DefaultMediaPrompt
+	case models.ContentTypeDirectText:
+		return DefaultDirectTextPrompt
 	default:
 		return DefaultTextPrompt
 	}
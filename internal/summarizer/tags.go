@@ -0,0 +1,77 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+const tagsPrompt = `List 3 to 8 topic tags for the summary below, from the most to the least specific, ` +
+	`such as the subject, the field, and the technologies, people, or places it is about. ` +
+	`Reply with a JSON array of lowercase strings only, for example ["kubernetes", "devops", "cost-optimization"].`
+
+// maxTags bounds the tags kept from a reply
+const maxTags = 8
+
+// GenerateTags asks s for the topic tags of summary, normalized for use as
+// Obsidian tags
+func GenerateTags(ctx context.Context, s Summarizer, summary string) ([]string, error) {
+	opts := promptOptionsFrom(ctx)
+	opts.Raw = true
+	reply, err := s.Summarize(WithPromptOptions(ctx, opts), summary, tagsPrompt, "")
+	if err != nil {
+		return nil, err
+	}
+	return parseTags(reply), nil
+}
+
+// parseTags reads the JSON array of a reply, tolerating code fences and
+// falling back to a comma-separated list
+func parseTags(reply string) []string {
+	var raw []string
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start || json.Unmarshal([]byte(reply[start:end+1]), &raw) != nil {
+		raw = strings.Split(reply, ",")
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, t := range raw {
+		tag := normalizeTag(t)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxTags {
+			break
+		}
+	}
+	return tags
+}
+
+// normalizeTag lowercases a tag and joins its words with dashes, since
+// Obsidian tags cannot contain spaces or punctuation
+func normalizeTag(tag string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(tag)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '/':
+			if dash && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	tag = strings.Trim(b.String(), "/")
+	// A tag needs a non-numeric character
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,30 @@
+package summarizer
+
+import (
+	"context"
+	"strings"
+)
+
+const titlePrompt = `Write a short, descriptive title (at most 8 words) for the content below. ` +
+	`Reply with the title only, without quotes or punctuation at the end.`
+
+// maxTitleContent bounds how much content is sent when generating a title
+const maxTitleContent = 8000
+
+// GenerateTitle asks s for a short title describing content
+func GenerateTitle(ctx context.Context, s Summarizer, content string) (string, error) {
+	if len(content) > maxTitleContent {
+		content = content[:maxTitleContent]
+	}
+
+	opts := promptOptionsFrom(ctx)
+	opts.Raw = true
+	title, err := s.Summarize(WithPromptOptions(ctx, opts), content, titlePrompt, "")
+	if err != nil {
+		return "", err
+	}
+
+	title = strings.TrimSpace(strings.SplitN(strings.TrimSpace(title), "\n", 2)[0])
+	title = strings.Trim(title, "\"'*#. ")
+	return title, nil
+}
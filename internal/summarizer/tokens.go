@@ -0,0 +1,134 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+const (
+	// promptReserve is the part of the context window kept for the
+	// instructions around the content and for the answer
+	promptReserve = 6000
+
+	// headShare is the part of truncated content kept from its beginning,
+	// the rest coming from its end
+	headShare = 0.7
+
+	// OverflowChunk and OverflowTruncate are the ways content larger than
+	// the context window is handled
+	OverflowChunk    = "chunk"
+	OverflowTruncate = "truncate"
+)
+
+// EstimateTokens approximates the number of tokens of text without a
+// provider tokenizer: about four characters per token for alphabetic
+// scripts, one per character for CJK scripts, whose words are not spaced
+func EstimateTokens(text string) int {
+	wide, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
+
+// ContextWindow returns the input tokens the model accepts, or 0 when it
+// is unknown. Local models depend on how the server loads them, so their
+// window must be configured.
+func ContextWindow(provider, model string) int {
+	model = strings.ToLower(model)
+	switch provider {
+	case "claude":
+		return 200000
+	case "gemini":
+		return 1000000
+	case "openai":
+		switch {
+		case strings.HasPrefix(model, "gpt-4.1"):
+			return 1000000
+		case strings.HasPrefix(model, "gpt-5"):
+			return 400000
+		case strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+			return 200000
+		case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4-turbo"):
+			return 128000
+		case strings.HasPrefix(model, "gpt-3.5"):
+			return 16000
+		}
+	}
+	return 0
+}
+
+// ContextGuard keeps the requests sent to a model within its context window,
+// rather than sending prompts the provider rejects. Larger content is split
+// into chunks or cut down to its beginning and end.
+type ContextGuard struct {
+	next      Summarizer
+	maxTokens int
+	overflow  string
+	overlap   int
+}
+
+// NewContextGuard returns next guarded by a window of maxTokens, or next
+// itself when maxTokens is not positive. overflow is OverflowChunk or
+// OverflowTruncate; chunks share overlap bytes.
+func NewContextGuard(next Summarizer, maxTokens int, overflow string, overlap int) Summarizer {
+	if maxTokens <= 0 {
+		return next
+	}
+	return &ContextGuard{next: next, maxTokens: maxTokens, overflow: overflow, overlap: overlap}
+}
+
+func (g *ContextGuard) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	budget := g.maxTokens - promptReserve - EstimateTokens(customPrompt)
+	if budget <= 0 {
+		budget = g.maxTokens / 2
+	}
+	tokens := EstimateTokens(content)
+	if tokens <= budget {
+		return g.next.Summarize(ctx, content, customPrompt, contentType)
+	}
+
+	// Bytes per token of this content, to size the pieces in bytes
+	maxBytes := int(float64(len(content)) * float64(budget) / float64(tokens))
+
+	// Chunk notes are already a piece of the content, so they are cut
+	if g.overflow == OverflowTruncate || promptOptionsFrom(ctx).Raw {
+		slog.Warn("Content exceeds the model context window, truncating",
+			"tokens", tokens, "max_tokens", budget)
+		return g.next.Summarize(ctx, TruncateMiddle(content, maxBytes), customPrompt, contentType)
+	}
+
+	// The chunks fit; the combined notes are checked again on their way back
+	slog.Info("Content exceeds the model context window, summarizing chunks",
+		"tokens", tokens, "max_tokens", budget)
+	return NewChunkingSummarizer(g, maxBytes, g.overlap).Summarize(ctx, content, customPrompt, contentType)
+}
+
+// TruncateMiddle shortens content to about max bytes, keeping its beginning
+// and its end, where introductions and conclusions usually are, and marking
+// the cut
+func TruncateMiddle(content string, max int) string {
+	if len(content) <= max {
+		return content
+	}
+	head := boundary(content, int(float64(max)*headShare)/2, int(float64(max)*headShare))
+	tailStart := len(content) - (max - head)
+	for tailStart < len(content) && !utf8.RuneStart(content[tailStart]) {
+		tailStart++
+	}
+	if i := strings.Index(content[tailStart:], "\n"); i >= 0 && i < (len(content)-tailStart)/2 {
+		tailStart += i + 1
+	}
+	omitted := utf8.RuneCountInString(content[head:tailStart])
+	return fmt.Sprintf("%s\n\n[... %d characters omitted ...]\n\n%s", content[:head], omitted, content[tailStart:])
+}
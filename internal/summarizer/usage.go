@@ -0,0 +1,98 @@
+package summarizer
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Usage accumulates the tokens consumed by one or more LLM calls
+type Usage struct {
+	mu           sync.Mutex
+	InputTokens  int64
+	OutputTokens int64
+	Calls        int
+
+	// ServedBy is the "provider/model" that produced the final answer when a
+	// fallback chain was involved
+	ServedBy string
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context whose LLM calls are accounted in u
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordUsage adds token counts to the Usage attached to ctx, if any
+func recordUsage(ctx context.Context, input, output int64) {
+	u, ok := ctx.Value(usageKey{}).(*Usage)
+	if !ok || u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.InputTokens += input
+	u.OutputTokens += output
+	u.Calls++
+}
+
+// recordServedBy notes which provider and model answered the request
+func recordServedBy(ctx context.Context, provider, model string) {
+	u, ok := ctx.Value(usageKey{}).(*Usage)
+	if !ok || u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ServedBy = provider + "/" + model
+}
+
+// Totals returns the accumulated token counts
+func (u *Usage) Totals() (input, output int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.InputTokens, u.OutputTokens
+}
+
+// modelPrice is the USD price per million input and output tokens
+type modelPrice struct {
+	input  float64
+	output float64
+}
+
+// modelPrices maps model name prefixes to approximate list prices.
+// Longer prefixes are matched first so specific models win over families.
+var modelPrices = map[string]modelPrice{
+	"claude-3-7-sonnet": {3, 15},
+	"claude-sonnet-4":   {3, 15},
+	"claude-3-5-haiku":  {0.8, 4},
+	"claude-haiku-4":    {1, 5},
+	"claude-opus-4-5":   {5, 25},
+	"claude-opus-4":     {15, 75},
+	"gemini-2.5-pro":    {1.25, 10},
+	"gemini-2.5-flash":  {0.3, 2.5},
+	"gemini-2.0-flash":  {0.1, 0.4},
+	"gpt-4o-mini":       {0.15, 0.6},
+	"gpt-4o":            {2.5, 10},
+	"gpt-4.1-nano":      {0.1, 0.4},
+	"gpt-4.1-mini":      {0.4, 1.6},
+	"gpt-4.1":           {2, 8},
+}
+
+// EstimateCost returns the approximate USD cost of the given token counts
+// for model, or 0 when the model's pricing is unknown.
+func EstimateCost(model string, input, output int64) float64 {
+	var best string
+	for prefix := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return 0
+	}
+	price := modelPrices[best]
+	return (float64(input)*price.input + float64(output)*price.output) / 1_000_000
+}
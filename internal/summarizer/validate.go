@@ -0,0 +1,141 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/clobrano/briefly/internal/models"
+)
+
+// ErrInvalidSummary is returned when a summary fails validation even after a repair attempt
+var ErrInvalidSummary = errors.New("invalid summary")
+
+// minSummaryLength is the shortest response accepted as a real summary
+const minSummaryLength = 40
+
+// refusalPrefixes are common openings of model refusals
+var refusalPrefixes = []string{
+	"i'm sorry",
+	"i am sorry",
+	"i can't",
+	"i cannot",
+	"i'm unable",
+	"i am unable",
+	"i'm not able",
+	"as an ai",
+	"sorry, but",
+}
+
+// defaultPromptSections are the headings most default prompts ask for
+var defaultPromptSections = []string{
+	"Main Topic",
+	"Key Points",
+	"Conclusion",
+}
+
+// contentPromptSections are the headings asked for by the default prompts
+// of the content types that do not use defaultPromptSections
+var contentPromptSections = map[models.ContentType][]string{
+	models.ContentTypeRepository: {"Purpose", "Key Features", "Maturity"},
+	models.ContentTypeIssue:      {"Summary", "Current State", "Key Points"},
+}
+
+// promptSections returns the headings the default prompt of contentType
+// asks for
+func promptSections(contentType models.ContentType) []string {
+	if sections, ok := contentPromptSections[contentType]; ok {
+		return sections
+	}
+	return defaultPromptSections
+}
+
+// ValidatingSummarizer checks every summary and re-prompts once with
+// corrective instructions when the response is empty, a refusal, or misses
+// the sections requested by the default prompt.
+type ValidatingSummarizer struct {
+	next Summarizer
+}
+
+func NewValidatingSummarizer(next Summarizer) *ValidatingSummarizer {
+	return &ValidatingSummarizer{next: next}
+}
+
+func (v *ValidatingSummarizer) Summarize(ctx context.Context, content, customPrompt string, contentType models.ContentType) (string, error) {
+	summary, err := v.next.Summarize(ctx, content, customPrompt, contentType)
+	if err != nil || promptOptionsFrom(ctx).Raw {
+		return summary, err
+	}
+
+	// The default sections are only checked for English output, since the
+	// model translates the headings otherwise, and brief or styled summaries
+	// skip them
+	opts := promptOptionsFrom(ctx)
+	lang := opts.outputLanguage()
+	var sections []string
+	if customPrompt == "" && opts.Style == "" &&
+		(lang == "en" || (lang == "" && opts.LanguagePolicy != LanguageBilingual)) &&
+		opts.Depth != DepthBrief {
+		sections = promptSections(contentType)
+	}
+
+	problem := Validate(summary, sections)
+	if problem == "" {
+		return summary, nil
+	}
+
+	slog.Warn("Summary failed validation, re-prompting once", "problem", problem)
+
+	prompt := customPrompt
+	if prompt == "" {
+		prompt = basePrompt(opts, contentType)
+	}
+	prompt = fmt.Sprintf("%s\n\nIMPORTANT: a previous attempt was rejected because %s. "+
+		"Summarize the content below directly, without apologies or disclaimers, "+
+		"and follow the requested structure exactly.", prompt, problem)
+
+	summary, err = v.next.Summarize(ctx, content, prompt, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	if problem := Validate(summary, sections); problem != "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSummary, problem)
+	}
+
+	return summary, nil
+}
+
+// Validate returns a short description of what is wrong with summary, or an
+// empty string when it looks acceptable. The headings in sections, those
+// requested by a default prompt, must be present.
+func Validate(summary string, sections []string) string {
+	trimmed := strings.TrimSpace(summary)
+	if trimmed == "" {
+		return "the response was empty"
+	}
+	if len(trimmed) < minSummaryLength {
+		return "the response was too short"
+	}
+
+	lower := strings.ToLower(trimmed)
+	for _, prefix := range refusalPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return "the response was a refusal"
+		}
+	}
+
+	var missing []string
+	for _, section := range sections {
+		if !strings.Contains(lower, strings.ToLower(section)) {
+			missing = append(missing, section)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("the response was missing sections: %s", strings.Join(missing, ", "))
+	}
+
+	return ""
+}
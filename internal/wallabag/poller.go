@@ -0,0 +1,198 @@
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clobrano/briefly/internal/history"
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+)
+
+// Poller queues new entries on an interval. Its state is persisted so
+// entries are queued once across restarts.
+type Poller struct {
+	client   *Client
+	queue    *queue.Queue
+	history  *history.Store
+	tag      string
+	annotate bool
+	interval time.Duration
+	done     chan struct{}
+
+	mu          sync.Mutex
+	state       state
+	persistPath string
+}
+
+type state struct {
+	// LastID is the newest entry seen; only entries after it are queued
+	LastID int `json:"last_id"`
+	// Pending maps the jobs not yet summarized to their entry, to annotate it
+	Pending map[string]int `json:"pending,omitempty"`
+}
+
+// NewPoller polls the entries saved with tag, or all entries when tag is
+// empty; with annotate set, summaries are added to their entry, once they
+// show up in the job history h
+func NewPoller(client *Client, q *queue.Queue, h *history.Store, tag string, annotate bool, interval time.Duration, persistPath string) *Poller {
+	p := &Poller{
+		client:      client,
+		queue:       q,
+		history:     h,
+		tag:         tag,
+		annotate:    annotate,
+		interval:    interval,
+		done:        make(chan struct{}),
+		persistPath: persistPath,
+	}
+	if data, err := os.ReadFile(persistPath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			slog.Warn("Failed to load Wallabag state", "path", persistPath, "error", err)
+		}
+	}
+	if p.state.Pending == nil {
+		p.state.Pending = make(map[string]int)
+	}
+	return p
+}
+
+func (p *Poller) Start() {
+	go p.run()
+}
+
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if n, err := p.Poll(ctx); err != nil {
+			slog.Warn("Failed to poll Wallabag", "error", err)
+		} else if n > 0 {
+			slog.Info("Queued Wallabag entries", "jobs", n)
+		}
+		if err := p.annotateDone(ctx); err != nil {
+			slog.Warn("Failed to annotate Wallabag entries", "error", err)
+		}
+		cancel()
+
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll queues the entries saved since the last poll and returns their
+// number. The first poll only records the newest entry, so the existing
+// reading list is not queued all at once.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.LastID == 0 {
+		latest, err := p.client.LatestID(ctx)
+		if err != nil {
+			return 0, err
+		}
+		p.state.LastID = latest
+		return 0, p.persist()
+	}
+
+	entries, err := p.client.Entries(ctx, p.tag, p.state.LastID)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, entry := range entries {
+		job := models.NewJob("", entry.URL, "")
+		job.Title = entry.Title
+		job.Feed = "Wallabag"
+		if err := p.queue.EnqueueInput(job); err != nil {
+			return queued, err
+		}
+		if p.annotate {
+			p.state.Pending[job.ID] = entry.ID
+		}
+		p.state.LastID = entry.ID
+		queued++
+	}
+	return queued, p.persist()
+}
+
+// annotateDone adds the summaries of completed jobs to their entry.
+// Completed jobs leave the queue, so their summary is found in history; jobs
+// gone from the queue without completing, because they failed or were
+// purged, are forgotten.
+func (p *Poller) annotateDone(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	var annotateErr error
+	for jobID, entryID := range p.state.Pending {
+		if _, queued := p.queue.Get(jobID); queued {
+			continue
+		}
+
+		if path := p.summaryPath(jobID); path != "" {
+			summary, err := os.ReadFile(path)
+			if err != nil {
+				slog.Warn("Failed to read summary for Wallabag", "job_id", jobID, "error", err)
+			} else if err := p.client.Annotate(ctx, entryID, strings.TrimSpace(string(summary))); err != nil {
+				// Entries annotated so far are still saved, so they are not
+				// annotated twice
+				annotateErr = err
+				break
+			}
+		}
+		delete(p.state.Pending, jobID)
+		changed = true
+	}
+	if changed {
+		if err := p.persist(); err != nil {
+			return err
+		}
+	}
+	return annotateErr
+}
+
+// summaryPath returns the summary of the job with jobID, or "" when the job
+// did not complete
+func (p *Poller) summaryPath(jobID string) string {
+	if p.history == nil {
+		return ""
+	}
+	entries, err := p.history.Query(history.Filter{ID: jobID, Status: models.JobStatusCompleted})
+	if err != nil {
+		slog.Warn("Failed to query history", "error", err)
+		return ""
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1].OutputPath
+}
+
+func (p *Poller) persist() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.persistPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to persist Wallabag state: %w", err)
+	}
+	return nil
+}
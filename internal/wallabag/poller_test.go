@@ -0,0 +1,61 @@
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clobrano/briefly/brieflytest"
+)
+
+func TestAnnotateCompletedJob(t *testing.T) {
+	annotations := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /api/entries.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pages": 1, "_embedded": {"items": [{"id": 2, "url": "https://example.com/post", "title": "Post"}]}}`))
+	})
+	mux.HandleFunc("POST /api/annotations/2.json", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		annotations <- body.Text
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	h := brieflytest.New(t)
+	h.Fetcher.Pages["https://example.com/post"] = "article text"
+	p := NewPoller(NewClient(server.URL, Credentials{}), h.Queue, h.History, "", true, time.Hour,
+		filepath.Join(t.TempDir(), "wallabag.json"))
+	p.state.LastID = 1
+
+	ctx := context.Background()
+	if n, err := p.Poll(ctx); err != nil || n != 1 {
+		t.Fatalf("Poll() = %d, %v, want 1 queued entry", n, err)
+	}
+	h.Drain(t)
+	if err := p.annotateDone(ctx); err != nil {
+		t.Fatalf("annotateDone() = %v", err)
+	}
+
+	select {
+	case text := <-annotations:
+		if !strings.Contains(text, "Summary of 12 characters") {
+			t.Fatalf("unexpected annotation:\n%s", text)
+		}
+	default:
+		t.Fatal("the entry was not annotated")
+	}
+	if len(p.state.Pending) != 0 {
+		t.Fatalf("entries still pending: %v", p.state.Pending)
+	}
+}
@@ -0,0 +1,214 @@
+// Package wallabag queues the entries saved to a self-hosted Wallabag
+// instance, and can add the summary back to each entry as an annotation.
+package wallabag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a saved article
+type Entry struct {
+	ID    int    `json:"id"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// Credentials are the API client created in Wallabag's "API clients
+// management" page and the user it acts for
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+}
+
+// Client calls the Wallabag API, fetching an OAuth token as needed
+type Client struct {
+	baseURL string
+	creds   Credentials
+	client  *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewClient(baseURL string, creds Credentials) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		creds:   creds,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Entries returns the unread entries newer than afterID, oldest first,
+// only those with tag when set
+func (c *Client) Entries(ctx context.Context, tag string, afterID int) ([]Entry, error) {
+	var entries []Entry
+	for page := 1; ; page++ {
+		query := url.Values{
+			"archive": {"0"},
+			"sort":    {"created"},
+			"order":   {"desc"},
+			"perPage": {"50"},
+			"page":    {strconv.Itoa(page)},
+		}
+		if tag != "" {
+			query.Set("tags", tag)
+		}
+
+		var resp struct {
+			Pages    int `json:"pages"`
+			Embedded struct {
+				Items []Entry `json:"items"`
+			} `json:"_embedded"`
+		}
+		if err := c.call(ctx, http.MethodGet, "/api/entries.json?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, entry := range resp.Embedded.Items {
+			if entry.ID <= afterID {
+				slices.Reverse(entries)
+				return entries, nil
+			}
+			entries = append(entries, entry)
+		}
+		if page >= resp.Pages {
+			slices.Reverse(entries)
+			return entries, nil
+		}
+	}
+}
+
+// LatestID returns the ID of the newest entry, or 0 when there is none
+func (c *Client) LatestID(ctx context.Context) (int, error) {
+	var resp struct {
+		Embedded struct {
+			Items []Entry `json:"items"`
+		} `json:"_embedded"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/api/entries.json?sort=created&order=desc&perPage=1", nil, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Embedded.Items) == 0 {
+		return 0, nil
+	}
+	return resp.Embedded.Items[0].ID, nil
+}
+
+// Annotate adds an annotation with text to an entry
+func (c *Client) Annotate(ctx context.Context, entryID int, text string) error {
+	// Annotations are meant for highlights; an empty range attaches the
+	// text to the entry as a whole
+	body := map[string]any{
+		"text":  text,
+		"quote": "",
+		"ranges": []map[string]any{
+			{"start": "", "startOffset": 0, "end": "", "endOffset": 0},
+		},
+	}
+	return c.call(ctx, http.MethodPost, fmt.Sprintf("/api/annotations/%d.json", entryID), body, nil)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Wallabag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			c.mu.Lock()
+			c.token = ""
+			c.mu.Unlock()
+		}
+		return fmt.Errorf("wallabag returned status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns a valid OAuth token, logging in with the password
+// grant when there is none or it expired
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {c.creds.ClientID},
+		"client_secret": {c.creds.ClientSecret},
+		"username":      {c.creds.Username},
+		"password":      {c.creds.Password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to Wallabag: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("wallabag login returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode Wallabag token: %w", err)
+	}
+
+	c.token = token.AccessToken
+	// Renewed a minute early, so it does not expire during a request
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
@@ -0,0 +1,49 @@
+package watcher
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Control files confirm or cancel a batch held by queue triage
+const (
+	confirmExt = ".confirm"
+	cancelExt  = ".cancel"
+)
+
+func isControlFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == confirmExt || ext == cancelExt
+}
+
+// processControl confirms or cancels the held batch named by a
+// "<batch>.confirm" or "<batch>.cancel" file
+func (w *Watcher) processControl(path string) {
+	defer os.Remove(path)
+	ext := filepath.Ext(path)
+	batch := strings.TrimSuffix(filepath.Base(path), ext)
+
+	if strings.ToLower(ext) == confirmExt {
+		released, err := w.queue.ReleaseBatch(batch)
+		if err != nil {
+			slog.Error("Failed to confirm batch", "batch", batch, "error", err)
+			return
+		}
+		slog.Info("Batch confirmed", "batch", batch, "jobs", released)
+		return
+	}
+
+	dropped, err := w.queue.DropBatch(batch)
+	if err != nil {
+		slog.Error("Failed to cancel batch", "batch", batch, "error", err)
+		return
+	}
+	for _, job := range dropped {
+		if job.FilePath != "" {
+			os.Remove(job.FilePath)
+		}
+	}
+	slog.Info("Batch cancelled", "batch", batch, "jobs", len(dropped))
+}
@@ -0,0 +1,256 @@
+package watcher
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/rating"
+	"github.com/clobrano/briefly/internal/sources"
+)
+
+// OutputWatcher re-enqueues the source URL of summaries deleted from the
+// output directory, so removing a summary on any synced device means "redo
+// this one".
+type OutputWatcher struct {
+	fsWatcher *fsnotify.Watcher
+	outputDir string
+	queue     *queue.Queue
+	done      chan struct{}
+
+	// Deletions are confirmed after graceTime, so editors and sync tools that
+	// replace files by delete+create are not mistaken for a redo request
+	graceTime time.Duration
+
+	mu      sync.Mutex
+	sources map[string]sources.Source // summary path relative to outputDir, without extension -> request
+}
+
+func NewOutputWatcher(outputDir string, q *queue.Queue) (*OutputWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutputWatcher{
+		fsWatcher: fsw,
+		outputDir: outputDir,
+		queue:     q,
+		done:      make(chan struct{}),
+		graceTime: 2 * time.Second,
+		sources:   make(map[string]sources.Source),
+	}, nil
+}
+
+func (w *OutputWatcher) Start() error {
+	w.load()
+
+	// Summaries deleted while stopped are forgotten rather than regenerated
+	known := w.sources
+	w.sources = make(map[string]sources.Source)
+	if err := w.watchTree(w.outputDir, known); err != nil {
+		return err
+	}
+	w.save()
+
+	go w.run()
+	return nil
+}
+
+func (w *OutputWatcher) Stop() error {
+	close(w.done)
+	return w.fsWatcher.Close()
+}
+
+// watchTree watches dir and its subfolders, such as feed and date folders,
+// and remembers the summaries in them, keeping the requests in known
+func (w *OutputWatcher) watchTree(dir string, known map[string]sources.Source) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return w.fsWatcher.Add(path)
+		}
+		if !w.tracked(path) {
+			return nil
+		}
+		if src, ok := known[w.key(path)]; ok {
+			w.mu.Lock()
+			w.sources[w.key(path)] = src
+			w.mu.Unlock()
+			return nil
+		}
+		w.remember(path)
+		return nil
+	})
+}
+
+func (w *OutputWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+					// Summaries may be written before the folder is watched
+					if err := w.watchTree(event.Name, nil); err != nil {
+						slog.Warn("Failed to watch output folder", "dir", event.Name, "error", err)
+					}
+					w.save()
+					continue
+				}
+			}
+			if !w.tracked(event.Name) {
+				continue
+			}
+			switch {
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				w.remember(event.Name)
+				w.save()
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				path := event.Name
+				time.AfterFunc(w.graceTime, func() { w.handleRemoved(path) })
+			}
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Output watcher error", "error", err)
+		}
+	}
+}
+
+// remember records how the summary at path was requested: with the
+// settings of the job writing it, or else with the URL in its header
+func (w *OutputWatcher) remember(path string) {
+	key, name := w.key(path), summaryName(path)
+	header, _ := rating.ParseSummaryHeader(path)
+	url := header["URL"]
+
+	for _, job := range w.queue.List() {
+		if job.Status != models.JobStatusProcessing || job.URL == "" || job.IsDirectText {
+			continue
+		}
+		if outputBase(job) == name || (url != "" && job.URL == url) {
+			w.mu.Lock()
+			w.sources[key] = sources.Of(job)
+			w.mu.Unlock()
+			return
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sources[key]; ok || url == "" {
+		// An edited summary keeps the settings it was requested with
+		return
+	}
+	w.sources[key] = sources.Source{URL: url}
+}
+
+// outputBase is the name of the summary file of job, without extension
+func outputBase(job models.Job) string {
+	if job.OutputName != "" {
+		return job.OutputName
+	}
+	return job.Filename
+}
+
+// Forget stops tracking the summary at path, so removing it next does not
+// regenerate it
+func (w *OutputWatcher) Forget(path string) {
+	w.mu.Lock()
+	delete(w.sources, w.key(path))
+	w.mu.Unlock()
+	w.save()
+}
+
+func (w *OutputWatcher) handleRemoved(path string) {
+	if _, err := os.Stat(path); err == nil {
+		// Replaced rather than deleted
+		return
+	}
+
+	key, name := w.key(path), summaryName(path)
+	w.mu.Lock()
+	src, ok := w.sources[key]
+	delete(w.sources, key)
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+	w.save()
+
+	// Ratings move low-rated summaries aside and queue their own regeneration
+	for _, job := range w.queue.List() {
+		if job.OutputName == name && (job.Status == models.JobStatusPending || job.Status == models.JobStatusProcessing) {
+			return
+		}
+	}
+
+	job := src.Job()
+	job.OutputName = name
+	job.NoCache = true
+	if err := w.queue.Enqueue(job); err != nil {
+		slog.Error("Failed to re-enqueue deleted summary", "summary", name, "error", err)
+		return
+	}
+	slog.Info("Summary was deleted, regenerating", "summary", name, "url", src.URL)
+}
+
+// load restores the requests of the summaries recorded before a restart
+func (w *OutputWatcher) load() {
+	known, err := sources.Load(w.outputDir)
+	if err != nil {
+		slog.Warn("Ignoring unreadable summary sources", "dir", w.outputDir, "error", err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, src := range known {
+		w.sources[key] = src
+	}
+}
+
+func (w *OutputWatcher) save() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := sources.Save(w.outputDir, w.sources); err != nil {
+		slog.Warn("Failed to save summary sources", "error", err)
+	}
+}
+
+// tracked reports whether path is a summary, leaving out the summary index
+func (w *OutputWatcher) tracked(path string) bool {
+	name := filepath.Base(path)
+	if filepath.Dir(path) == w.outputDir && (name == "index.md" || name == "index.json") {
+		return false
+	}
+	return sources.IsSummaryFile(name)
+}
+
+// key identifies the summary at path, whatever its format
+func (w *OutputWatcher) key(path string) string {
+	return sources.Key(w.outputDir, path)
+}
+
+func summaryName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -1,10 +1,13 @@
 package watcher
 
 import (
-	"bufio"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,8 +15,11 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v3"
 
+	"github.com/clobrano/briefly/internal/config"
 	"github.com/clobrano/briefly/internal/models"
+	"github.com/clobrano/briefly/internal/notifier"
 	"github.com/clobrano/briefly/internal/queue"
+	"github.com/clobrano/briefly/internal/rating"
 )
 
 type Watcher struct {
@@ -24,6 +30,16 @@ type Watcher struct {
 	pending      map[string]time.Time
 	mu           sync.Mutex
 	done         chan struct{}
+	ratings      *rating.Store
+
+	// Mass syncs are drained in batches of batchSize every batchInterval,
+	// with a warning once more than pendingLimit files are waiting
+	batchSize     int
+	batchInterval time.Duration
+	pendingLimit  int
+	lastBatch     time.Time
+	overflowing   bool
+	notifier      *notifier.Notifier
 }
 
 func New(watchDir string, q *queue.Queue) (*Watcher, error) {
@@ -39,9 +55,30 @@ func New(watchDir string, q *queue.Queue) (*Watcher, error) {
 		debounceTime: 500 * time.Millisecond,
 		pending:      make(map[string]time.Time),
 		done:         make(chan struct{}),
+
+		batchSize:     20,
+		batchInterval: time.Second,
+		pendingLimit:  100,
 	}, nil
 }
 
+// SetLimits configures batch draining of the pending map and the size above
+// which an overflow warning is sent through n
+func (w *Watcher) SetLimits(batchSize, pendingLimit int, n *notifier.Notifier) {
+	if batchSize > 0 {
+		w.batchSize = batchSize
+	}
+	if pendingLimit > 0 {
+		w.pendingLimit = pendingLimit
+	}
+	w.notifier = n
+}
+
+// SetRatings enables handling of "<name>.rate" files dropped in the watch dir
+func (w *Watcher) SetRatings(r *rating.Store) {
+	w.ratings = r
+}
+
 func (w *Watcher) Start() error {
 	if err := w.fsWatcher.Add(w.watchDir); err != nil {
 		return err
@@ -49,7 +86,7 @@ func (w *Watcher) Start() error {
 
 	// Process existing files on startup
 	if err := w.processExisting(); err != nil {
-		log.Printf("Warning: error processing existing files: %v", err)
+		slog.Warn("Error processing existing files", "error", err)
 	}
 
 	go w.run()
@@ -74,8 +111,8 @@ func (w *Watcher) processExisting() error {
 			continue
 		}
 		if w.isValidFile(entry.Name()) {
-			path := filepath.Join(w.watchDir, entry.Name())
-			w.processFile(path)
+			// Go through the pending map so large inboxes are drained in batches
+			w.scheduleProcess(filepath.Join(w.watchDir, entry.Name()))
 		}
 	}
 
@@ -100,7 +137,7 @@ func (w *Watcher) run() {
 			if !ok {
 				return
 			}
-			log.Printf("Watcher error: %v", err)
+			slog.Error("Watcher error", "error", err)
 		}
 	}
 }
@@ -120,64 +157,262 @@ func (w *Watcher) debounceLoop() {
 		case <-w.done:
 			return
 		case <-ticker.C:
-			w.mu.Lock()
-			now := time.Now()
-			var toProcess []string
-			for path, deadline := range w.pending {
-				if now.After(deadline) {
-					toProcess = append(toProcess, path)
-					delete(w.pending, path)
-				}
-			}
-			w.mu.Unlock()
-
-			for _, path := range toProcess {
+			for _, path := range w.nextBatch() {
 				w.processFile(path)
 			}
 		}
 	}
 }
 
+// nextBatch removes and returns the files whose debounce expired, at most
+// batchSize of them per batchInterval, oldest first
+func (w *Watcher) nextBatch() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.checkOverflow()
+
+	now := time.Now()
+	var due []string
+	for path, deadline := range w.pending {
+		if now.After(deadline) {
+			due = append(due, path)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	if len(due) > w.batchSize {
+		if now.Sub(w.lastBatch) < w.batchInterval {
+			return nil
+		}
+		sort.Slice(due, func(i, j int) bool {
+			return w.pending[due[i]].Before(w.pending[due[j]])
+		})
+		due = due[:w.batchSize]
+		w.lastBatch = now
+		slog.Info("Processing batch of files", "files", len(due), "pending", len(w.pending)-len(due))
+	}
+
+	for _, path := range due {
+		delete(w.pending, path)
+	}
+	return due
+}
+
+// checkOverflow warns once when the pending map grows beyond pendingLimit,
+// and re-arms when it drains. Must be called with w.mu held.
+func (w *Watcher) checkOverflow() {
+	count := len(w.pending)
+	if count <= w.pendingLimit {
+		w.overflowing = false
+		return
+	}
+	if w.overflowing {
+		return
+	}
+	w.overflowing = true
+
+	slog.Warn("Too many files pending, processing in batches",
+		"pending", count, "dir", w.watchDir, "limit", w.pendingLimit, "batch_size", w.batchSize)
+	if w.notifier != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := w.notifier.SendOverflow(ctx, count, w.batchSize); err != nil {
+				slog.Warn("Failed to send overflow notification", "error", err)
+			}
+		}()
+	}
+}
+
 func (w *Watcher) processFile(path string) {
-	url, customPrompt, err := parseInputFile(path)
+	if isRatingFile(path) {
+		w.processRating(path)
+		return
+	}
+	if isControlFile(path) {
+		w.processControl(path)
+		return
+	}
+
+	if isMediaFile(path) {
+		job := models.NewMediaJob(path)
+		if err := w.queue.EnqueueInput(job); err != nil {
+			slog.Error("Failed to enqueue job", "path", path, "error", err)
+			return
+		}
+		slog.Info("Queued job", "job_id", job.ID, "file", job.Filename, "media", filepath.Base(path))
+		return
+	}
+
+	input, err := parseInputFile(path)
 	if err != nil {
-		log.Printf("Error parsing file %s: %v", path, err)
+		slog.Error("Failed to parse input file", "path", path, "error", err)
+		return
+	}
+
+	if len(input.URLs) > 0 {
+		w.processList(path, input)
+		return
+	}
+
+	var job *models.Job
+	if input.Text != "" {
+		job = models.NewTextJob(path, input.Text, input.Prompt)
+	} else {
+		job = models.NewJob(path, input.URL, input.Prompt)
+	}
+	applyInput(job, input, path)
+	if input.Name != "" {
+		job.OutputName = filepath.Base(input.Name)
+	}
+	if err := w.queue.EnqueueInput(job); err != nil {
+		slog.Error("Failed to enqueue job", "path", path, "error", err)
+		return
+	}
+
+	if job.IsDirectText {
+		slog.Info("Queued job", "job_id", job.ID, "file", job.Filename, "chars", len(job.Text))
 		return
 	}
+	slog.Info("Queued job", "job_id", job.ID, "file", job.Filename, "url", job.URL)
+}
+
+// processList queues one job per URL of a reading list file, sharing its
+// prompt and settings, then removes the file: the jobs have no input file
+// of their own, so each summary is named after its title or job ID
+func (w *Watcher) processList(path string, input inputFile) {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	queued := 0
+	for i, url := range input.URLs {
+		job := models.NewJob("", url, input.Prompt)
+		job.Filename = fmt.Sprintf("%s-%d", base, i+1)
+		applyInput(job, input, path)
+		if input.Name != "" {
+			job.OutputName = fmt.Sprintf("%s-%d", filepath.Base(input.Name), i+1)
+		}
+		if err := w.queue.EnqueueInput(job); err != nil {
+			slog.Error("Failed to enqueue job", "path", path, "url", url, "error", err)
+			continue
+		}
+		queued++
+		slog.Info("Queued job", "job_id", job.ID, "file", job.Filename, "url", job.URL)
+	}
+
+	// Keep the list while some of it is not queued, so it can be fixed
+	if queued == len(input.URLs) {
+		os.Remove(path)
+	}
+	slog.Info("Queued reading list", "path", path, "jobs", queued)
+}
 
-	job := models.NewJob(path, url, customPrompt)
-	if err := w.queue.Enqueue(job); err != nil {
-		log.Printf("Error enqueuing job for %s: %v", path, err)
+// applyInput copies the settings of an input file shared by all its jobs
+func applyInput(job *models.Job, input inputFile, path string) {
+	var err error
+	job.Notes = input.Notes
+	job.Feed = input.Feed
+	job.Persona = input.Persona
+	job.SummaryLanguage = strings.ToLower(input.Language)
+	job.Language = strings.ToLower(input.SourceLanguage)
+	job.Style = input.Style
+	job.OutputFormat = strings.ToLower(input.Format)
+	job.Questions = input.Questions
+	if job.Priority, err = models.ParsePriority(input.Priority); err != nil {
+		slog.Warn("Ignoring input file priority", "path", path, "error", err)
+	}
+	if job.NotBefore, err = models.ParseNotBefore(input.ProcessAfter, time.Now()); err != nil {
+		slog.Warn("Ignoring input file process_after", "path", path, "error", err)
+	}
+	job.Provider, job.Model = config.SplitModel(input.Provider, input.Model)
+}
+
+func (w *Watcher) processRating(path string) {
+	r, err := w.ratings.HandleFile(path)
+	if err != nil {
+		slog.Error("Failed to handle rating", "path", path, "error", err)
 		return
 	}
+	os.Remove(path)
 
-	log.Printf("Queued job %s for URL: %s", job.Filename, url)
+	slog.Info("Recorded rating", "rating", r.Rating, "summary", r.Name, "model", r.Model)
 }
 
 func (w *Watcher) isValidFile(name string) bool {
+	if w.ratings != nil && isRatingFile(name) {
+		return true
+	}
+	if w.queue.HoldsBatches() && isControlFile(name) {
+		return true
+	}
 	ext := strings.ToLower(filepath.Ext(name))
-	return ext == ".briefly" || ext == ".url" || ext == ".txt"
+	return ext == ".briefly" || ext == ".url" || ext == ".txt" || mediaExtensions[ext]
+}
+
+// mediaExtensions are audio and video files transcribed directly
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".wav": true, ".ogg": true, ".opus": true, ".flac": true,
+	".mp4": true, ".mov": true, ".mkv": true, ".webm": true,
+}
+
+func isMediaFile(name string) bool {
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+func isRatingFile(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".rate"
 }
 
 type inputFile struct {
-	URL    string `yaml:"url"`
-	Prompt string `yaml:"prompt"`
+	URL string `yaml:"url"`
+	// URLs makes the file a reading list, one job per URL
+	URLs   []string `yaml:"urls"`
+	Prompt string   `yaml:"prompt"`
+	Text   string   `yaml:"text"`
+
+	// Feed names the subscription the input came from, if any
+	Feed string `yaml:"feed"`
+
+	// Name overrides the output file name and Format its format. Provider
+	// and Model select the LLM, Model also accepting "provider:model".
+	Name     string `yaml:"name"`
+	Format   string `yaml:"format"`
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+
+	// Persona selects a summary persona preset, Style a summary style
+	Persona string `yaml:"persona"`
+	Style   string `yaml:"style"`
+
+	// Language is the ISO 639-1 code the summary is written in, and
+	// SourceLanguage the one of the content, detected when empty
+	Language       string `yaml:"language"`
+	SourceLanguage string `yaml:"source_language"`
+
+	// Priority is low, normal, high, or a number; higher runs first
+	Priority string `yaml:"priority"`
+
+	// Questions are answered from the content next to the summary
+	Questions []string `yaml:"questions"`
+
+	// ProcessAfter defers the job, see models.ParseNotBefore
+	ProcessAfter string `yaml:"process_after"`
+
+	// Notes is the user's own commentary found after the front matter or URL
+	Notes string `yaml:"-"`
 }
 
-func parseInputFile(path string) (url, customPrompt string, err error) {
-	file, err := os.Open(path)
+func parseInputFile(path string) (inputFile, error) {
+	// Read whole, as pasted text may have lines of any length
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", "", err
+		return inputFile{}, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	if err := scanner.Err(); err != nil {
-		return "", "", err
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
 	}
 
 	content := strings.Join(lines, "\n")
@@ -188,16 +423,104 @@ func parseInputFile(path string) (url, customPrompt string, err error) {
 		parts := strings.SplitN(content, "---", 3)
 		if len(parts) >= 3 {
 			var input inputFile
-			if err := yaml.Unmarshal([]byte(parts[1]), &input); err == nil && input.URL != "" {
-				return strings.TrimSpace(input.URL), strings.TrimSpace(input.Prompt), nil
+			if err := yaml.Unmarshal([]byte(parts[1]), &input); err != nil {
+				return inputFile{}, fmt.Errorf("invalid front matter: %w", err)
+			}
+			if input.URL == "" && input.Text == "" && len(input.URLs) == 0 {
+				return inputFile{}, fmt.Errorf("front matter has no url, urls, or text")
 			}
+			input.URL = strings.TrimSpace(input.URL)
+			if input.URLs = readingList(input.URL, input.URLs); len(input.URLs) == 1 {
+				input.URL, input.URLs = input.URLs[0], nil
+			}
+			input.Prompt = strings.TrimSpace(input.Prompt)
+			input.Text = strings.TrimSpace(input.Text)
+			input.Feed = strings.TrimSpace(input.Feed)
+			input.Name = strings.TrimSpace(input.Name)
+			input.Format = strings.TrimSpace(input.Format)
+			input.Provider = strings.TrimSpace(input.Provider)
+			input.Model = strings.TrimSpace(input.Model)
+			input.Persona = strings.TrimSpace(input.Persona)
+			input.Style = strings.TrimSpace(input.Style)
+			input.Questions = trimQuestions(input.Questions)
+			input.Language = strings.TrimSpace(input.Language)
+			input.SourceLanguage = strings.TrimSpace(input.SourceLanguage)
+			input.Priority = strings.TrimSpace(input.Priority)
+			input.ProcessAfter = strings.TrimSpace(input.ProcessAfter)
+			input.Notes = strings.TrimSpace(parts[2])
+			return input, nil
 		}
 	}
 
+	// A list of URLs, one per line
+	if urls := urlLines(lines); len(urls) > 1 {
+		return inputFile{URLs: urls}, nil
+	}
+
 	// Simple URL-only format
-	if len(lines) > 0 {
-		return strings.TrimSpace(lines[0]), "", nil
+	if len(lines) > 0 && isURL(lines[0]) {
+		return inputFile{
+			URL:   strings.TrimSpace(lines[0]),
+			Notes: strings.TrimSpace(strings.Join(lines[1:], "\n")),
+		}, nil
 	}
 
-	return "", "", nil
+	// A mistyped URL is not summarized as text
+	if len(lines) > 0 && schemelessURL.MatchString(strings.TrimSpace(lines[0])) {
+		return inputFile{}, fmt.Errorf("%q is not a URL, it needs http:// or https://", strings.TrimSpace(lines[0]))
+	}
+
+	// Anything else is text to summarize directly
+	return inputFile{Text: content}, nil
+}
+
+// schemelessURL matches a host name, with an optional path, such as
+// example.com/post
+var schemelessURL = regexp.MustCompile(`(?i)^(www\.)?[a-z0-9-]+(\.[a-z0-9-]+)*\.[a-z]{2,}(/\S*)?$`)
+
+// trimQuestions drops the blank entries of a questions list
+func trimQuestions(questions []string) []string {
+	var trimmed []string
+	for _, q := range questions {
+		if q = strings.TrimSpace(q); q != "" {
+			trimmed = append(trimmed, q)
+		}
+	}
+	return trimmed
+}
+
+// readingList merges the url and urls front matter fields
+func readingList(url string, urls []string) []string {
+	var list []string
+	if url != "" {
+		list = append(list, url)
+	}
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			list = append(list, u)
+		}
+	}
+	return list
+}
+
+// urlLines returns the URLs of a file where every line is a URL, ignoring
+// blank lines and "#" comments, or nil otherwise
+func urlLines(lines []string) []string {
+	var urls []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isURL(line) {
+			return nil
+		}
+		urls = append(urls, line)
+	}
+	return urls
+}
+
+func isURL(line string) bool {
+	line = strings.ToLower(strings.TrimSpace(line))
+	return strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://")
 }